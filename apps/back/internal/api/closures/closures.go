@@ -0,0 +1,205 @@
+package closures
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"place-picker/internal/closures"
+	closuresRepo "place-picker/internal/db/repo/closures"
+	"place-picker/internal/offices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Наибольший размер импортируемого файла календаря.
+const maxCalendarSize = 1 << 20
+
+// Закрытия офиса в интервале дат. По умолчанию — закрытия офиса по умолчанию на год вперед.
+func GetClosuresHandler(c *gin.Context, repo *closuresRepo.ClosuresRepository) {
+	office, ok := loadOffice(c, "GetClosuresHandler", c.Query("office"))
+	if !ok {
+		return
+	}
+
+	from := c.DefaultQuery("from", office.Day(time.Now()))
+	fromDay, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date in YYYY-MM-DD format"})
+		return
+	}
+
+	to := c.DefaultQuery("to", fromDay.AddDate(1, 0, 0).Format(time.DateOnly))
+	if _, err := time.Parse(time.DateOnly, to); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date in YYYY-MM-DD format"})
+		return
+	}
+
+	list, err := repo.GetClosures(c.Request.Context(), office.Key, from, to)
+	if err != nil {
+		slog.Error("GetClosuresHandler | Failed to load closures", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load closures"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ClosuresPayload{Office: office.Key, Closures: list})
+}
+
+// Закрывает офис на интервал дат и отменяет брони на эти дни с уведомлением владельцев.
+func CreateClosureHandler(c *gin.Context, repo *closuresRepo.ClosuresRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("CreateClosureHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req ClosureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("CreateClosureHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	officeKey := ""
+	if req.Office != nil {
+		officeKey = *req.Office
+	}
+	office, ok := loadOffice(c, "CreateClosureHandler", officeKey)
+	if !ok {
+		return
+	}
+
+	_, errFrom := time.Parse(time.DateOnly, req.DateFrom)
+	_, errTo := time.Parse(time.DateOnly, req.DateTo)
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dateFrom and dateTo must be dates in YYYY-MM-DD format"})
+		return
+	}
+
+	closure := closuresRepo.Closure{DateFrom: req.DateFrom, DateTo: req.DateTo, Reason: req.Reason}
+
+	ids, cancelled, ok := saveClosures(c, "CreateClosureHandler", repo, office, userId.(string), []closuresRepo.Closure{closure})
+	if !ok {
+		return
+	}
+
+	slog.Info("CreateClosureHandler | Closure created", "id", ids[0], "office", office.Key, "cancelled", cancelled)
+	c.JSON(http.StatusCreated, gin.H{"id": ids[0], "cancelled": cancelled})
+}
+
+// Импортирует закрытия офиса из файла iCalendar в теле запроса. Повторный импорт того же файла
+// обновляет закрытия по UID событий. Повторяющиеся события не импортируются и перечисляются в ответе.
+func ImportClosuresHandler(c *gin.Context, repo *closuresRepo.ClosuresRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("ImportClosuresHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	office, ok := loadOffice(c, "ImportClosuresHandler", c.Query("office"))
+	if !ok {
+		return
+	}
+
+	events, recurring, err := closures.ParseICal(http.MaxBytesReader(c.Writer, c.Request.Body, maxCalendarSize), office.Location)
+	if err != nil {
+		slog.Error("ImportClosuresHandler | Unable parse calendar", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(events) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "calendar has no events to import"})
+		return
+	}
+
+	list := make([]closuresRepo.Closure, 0, len(events))
+	for _, event := range events {
+		closure := closuresRepo.Closure{DateFrom: event.DateFrom, DateTo: event.DateTo, Reason: event.Summary}
+		if event.Uid != "" {
+			uid := event.Uid
+			closure.SourceUid = &uid
+		}
+		list = append(list, closure)
+	}
+
+	_, cancelled, ok := saveClosures(c, "ImportClosuresHandler", repo, office, userId.(string), list)
+	if !ok {
+		return
+	}
+
+	if recurring == nil {
+		recurring = []string{}
+	}
+
+	slog.Info("ImportClosuresHandler | Closures imported", "office", office.Key, "imported", len(list), "skipped", len(recurring), "cancelled", cancelled)
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "closures imported successfully",
+		"imported":         len(list),
+		"skippedRecurring": recurring,
+		"cancelled":        cancelled,
+	})
+}
+
+func DeleteClosureHandler(c *gin.Context, repo *closuresRepo.ClosuresRepository) {
+	closureId := c.Param("id")
+
+	if err := repo.DeleteClosure(c.Request.Context(), closureId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "closure not found"})
+			return
+		}
+
+		slog.Error("DeleteClosureHandler | Failed to delete closure", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete closure"})
+		return
+	}
+
+	slog.Info("DeleteClosureHandler | Closure deleted", "id", closureId)
+	c.JSON(http.StatusOK, gin.H{"message": "closure deleted successfully"})
+}
+
+// Сохраняет закрытия и уведомляет владельцев отмененных броней. Возвращает число отмененных броней.
+func saveClosures(c *gin.Context, handler string, repo *closuresRepo.ClosuresRepository, office offices.Office, userId string, list []closuresRepo.Closure) ([]string, int, bool) {
+	defaultOffice, err := offices.Get("")
+	if err != nil {
+		slog.Error(handler+" | Failed to load default office", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save closures"})
+		return nil, 0, false
+	}
+
+	ids, cancelled, err := repo.SaveClosures(c.Request.Context(), office, defaultOffice.Key, userId, list)
+	if err != nil {
+		if errors.Is(err, closuresRepo.ErrInvalidClosure) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, 0, false
+		}
+
+		slog.Error(handler+" | Failed to save closures", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save closures"})
+		return nil, 0, false
+	}
+
+	closures.NotifyCancelled(cancelled)
+
+	return ids, len(cancelled), true
+}
+
+func loadOffice(c *gin.Context, handler, key string) (offices.Office, bool) {
+	office, err := offices.Get(key)
+	if err != nil {
+		if errors.Is(err, offices.ErrUnknownOffice) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return offices.Office{}, false
+		}
+
+		slog.Error(handler+" | Failed to load office", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load office"})
+		return offices.Office{}, false
+	}
+
+	return office, true
+}
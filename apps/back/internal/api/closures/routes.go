@@ -0,0 +1,49 @@
+package closures
+
+import (
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+
+	closuresRepo "place-picker/internal/db/repo/closures"
+	userRepo "place-picker/internal/db/repo/user"
+	adminMiddleware "place-picker/internal/server/middleware/admin"
+)
+
+type (
+	Closures struct {
+		ClosuresRepo *closuresRepo.ClosuresRepository
+		UserRepo     *userRepo.UserRepository
+	}
+
+	// Закрытие офиса на дни с dateFrom по dateTo включительно. Без office — офис по умолчанию.
+	ClosureRequest struct {
+		Office   *string `json:"office"`
+		DateFrom string  `json:"dateFrom" binding:"required"`
+		DateTo   string  `json:"dateTo" binding:"required"`
+		Reason   string  `json:"reason" binding:"required,max=200"`
+	}
+
+	ClosuresPayload struct {
+		Office   string                 `json:"office"`
+		Closures []closuresRepo.Closure `json:"closures"`
+	}
+)
+
+func New(db *sql.DB) *Closures {
+	return &Closures{
+		ClosuresRepo: closuresRepo.NewClosuresRepository(db),
+		UserRepo:     userRepo.NewUserRepository(db),
+	}
+}
+
+func (cl *Closures) RegisterPublicRoutes(r *gin.RouterGroup) {}
+
+func (cl *Closures) RegisterPrivateRoutes(r *gin.RouterGroup) {
+	admin := adminMiddleware.AdminMiddleware(cl.UserRepo)
+
+	r.GET("/closures", func(c *gin.Context) { GetClosuresHandler(c, cl.ClosuresRepo) })
+	r.POST("/closures", admin, func(c *gin.Context) { CreateClosureHandler(c, cl.ClosuresRepo) })
+	r.POST("/closures/import", admin, func(c *gin.Context) { ImportClosuresHandler(c, cl.ClosuresRepo) })
+	r.DELETE("/closures/:id", admin, func(c *gin.Context) { DeleteClosureHandler(c, cl.ClosuresRepo) })
+}
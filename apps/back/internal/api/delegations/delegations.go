@@ -0,0 +1,90 @@
+package delegations
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	delegationsRepo "place-picker/internal/db/repo/delegations"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetDelegationsHandler(c *gin.Context, repo *delegationsRepo.DelegationsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("GetDelegationsHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	granted, received, err := repo.GetUserGrants(c.Request.Context(), userId.(string))
+	if err != nil {
+		slog.Error("GetDelegationsHandler | Failed to load delegations", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load delegations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, GrantsPayload{Granted: granted, Received: received})
+}
+
+// Разрешает другому пользователю бронировать столы за текущего пользователя.
+func CreateDelegationHandler(c *gin.Context, repo *delegationsRepo.DelegationsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("CreateDelegationHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req GrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("CreateDelegationHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	grantId, err := repo.CreateGrant(c.Request.Context(), userId.(string), req.DelegateId)
+	if err != nil {
+		switch {
+		case errors.Is(err, delegationsRepo.ErrSelfDelegation):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, delegationsRepo.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, delegationsRepo.ErrDelegationExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			slog.Error("CreateDelegationHandler | Failed to create delegation", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create delegation"})
+		}
+		return
+	}
+
+	slog.Info("CreateDelegationHandler | Delegation granted", "grantId", grantId, "userId", userId, "delegateId", req.DelegateId)
+	c.JSON(http.StatusCreated, gin.H{"id": grantId})
+}
+
+func DeleteDelegationHandler(c *gin.Context, repo *delegationsRepo.DelegationsRepository) {
+	grantId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("DeleteDelegationHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := repo.DeleteGrant(c.Request.Context(), grantId, userId.(string)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "delegation not found"})
+			return
+		}
+
+		slog.Error("DeleteDelegationHandler | Failed to delete delegation", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete delegation"})
+		return
+	}
+
+	slog.Info("DeleteDelegationHandler | Delegation revoked", "grantId", grantId, "userId", userId)
+	c.JSON(http.StatusOK, gin.H{"message": "delegation deleted successfully"})
+}
@@ -0,0 +1,38 @@
+package delegations
+
+import (
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+
+	delegationsRepo "place-picker/internal/db/repo/delegations"
+)
+
+type (
+	Delegations struct {
+		DelegationsRepo *delegationsRepo.DelegationsRepository
+	}
+
+	GrantRequest struct {
+		DelegateId string `json:"delegateId" binding:"required"`
+	}
+
+	GrantsPayload struct {
+		// Кому пользователь разрешил бронировать за себя
+		Granted []delegationsRepo.Grant `json:"granted"`
+		// За кого пользователь может бронировать
+		Received []delegationsRepo.Grant `json:"received"`
+	}
+)
+
+func New(db *sql.DB) *Delegations {
+	return &Delegations{DelegationsRepo: delegationsRepo.NewDelegationsRepository(db)}
+}
+
+func (d *Delegations) RegisterPublicRoutes(r *gin.RouterGroup) {}
+
+func (d *Delegations) RegisterPrivateRoutes(r *gin.RouterGroup) {
+	r.GET("/delegations", func(c *gin.Context) { GetDelegationsHandler(c, d.DelegationsRepo) })
+	r.POST("/delegations", func(c *gin.Context) { CreateDelegationHandler(c, d.DelegationsRepo) })
+	r.DELETE("/delegations/:id", func(c *gin.Context) { DeleteDelegationHandler(c, d.DelegationsRepo) })
+}
@@ -0,0 +1,210 @@
+package desks
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	assignmentsRepo "place-picker/internal/db/repo/assignments"
+	userRepo "place-picker/internal/db/repo/user"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const dateLayout = "2006-01-02"
+
+func GetDeskAssignmentsHandler(c *gin.Context, repo *assignmentsRepo.AssignmentsRepository) {
+	deskId := c.Param("id")
+
+	assignments, err := repo.GetDeskAssignments(c.Request.Context(), deskId)
+	if err != nil {
+		slog.Error("GetDeskAssignmentsHandler | Failed to load assignments", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load assignments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, AssignmentsPayload{Assignments: assignments})
+}
+
+func AssignDeskHandler(c *gin.Context, repo *assignmentsRepo.AssignmentsRepository) {
+	deskId := c.Param("id")
+
+	var req AssignDeskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("AssignDeskHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if !isValidOptionalDate(req.DateFrom) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dateFrom format"})
+		return
+	}
+	if !isValidOptionalDate(req.DateTo) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dateTo format"})
+		return
+	}
+	if req.DateFrom != nil && req.DateTo != nil && *req.DateTo < *req.DateFrom {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dateTo cannot be before dateFrom"})
+		return
+	}
+
+	id, err := repo.AssignDesk(c.Request.Context(), deskId, req.UserId, req.DateFrom, req.DateTo)
+	if err != nil {
+		var reserved *assignmentsRepo.ReservedPeriodError
+		switch {
+		case errors.As(err, &reserved):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "conflicts": reserved.Reservations})
+		case errors.Is(err, assignmentsRepo.ErrAssignmentOverlap):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, assignmentsRepo.ErrDeskOrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			slog.Error("AssignDeskHandler | Failed to assign desk", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to assign desk"})
+		}
+		return
+	}
+
+	slog.Info("AssignDeskHandler | Desk assigned", "deskId", deskId, "userId", req.UserId, "assignmentId", id)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func DeleteAssignmentHandler(c *gin.Context, repo *assignmentsRepo.AssignmentsRepository) {
+	deskId := c.Param("id")
+	assignmentId := c.Param("assignmentId")
+
+	if err := repo.DeleteAssignment(c.Request.Context(), deskId, assignmentId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "assignment not found"})
+			return
+		}
+
+		slog.Error("DeleteAssignmentHandler | Failed to delete assignment", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete assignment"})
+		return
+	}
+
+	slog.Info("DeleteAssignmentHandler | Assignment deleted", "deskId", deskId, "assignmentId", assignmentId)
+	c.JSON(http.StatusOK, gin.H{"message": "assignment deleted successfully"})
+}
+
+func ReleaseDaysHandler(c *gin.Context, repo *assignmentsRepo.AssignmentsRepository, users *userRepo.UserRepository) {
+	assignment, ok := loadManagedAssignment(c, repo, users)
+	if !ok {
+		return
+	}
+
+	var req ReleaseDaysRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("ReleaseDaysHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	for _, day := range req.Days {
+		if _, err := time.Parse(dateLayout, day); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid day format", "day": day})
+			return
+		}
+
+		// Даты в формате YYYY-MM-DD корректно сравниваются как строки
+		if (assignment.DateFrom != nil && day < *assignment.DateFrom) || (assignment.DateTo != nil && day > *assignment.DateTo) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "day is outside of the assignment period", "day": day})
+			return
+		}
+	}
+
+	if err := repo.ReleaseDays(c.Request.Context(), assignment.Id, req.Days); err != nil {
+		slog.Error("ReleaseDaysHandler | Failed to release days", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to release days"})
+		return
+	}
+
+	slog.Info("ReleaseDaysHandler | Days released", "assignmentId", assignment.Id, "days", req.Days)
+	c.JSON(http.StatusOK, gin.H{"message": "days released successfully"})
+}
+
+func ReclaimDayHandler(c *gin.Context, repo *assignmentsRepo.AssignmentsRepository, users *userRepo.UserRepository) {
+	assignment, ok := loadManagedAssignment(c, repo, users)
+	if !ok {
+		return
+	}
+
+	day := c.Param("day")
+	if _, err := time.Parse(dateLayout, day); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid day format"})
+		return
+	}
+
+	if err := repo.ReclaimDay(c.Request.Context(), assignment.Id, day); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "day is not released"})
+		case errors.Is(err, assignmentsRepo.ErrDayAlreadyReserved):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			slog.Error("ReclaimDayHandler | Failed to reclaim day", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reclaim day"})
+		}
+		return
+	}
+
+	slog.Info("ReclaimDayHandler | Day reclaimed", "assignmentId", assignment.Id, "day", day)
+	c.JSON(http.StatusOK, gin.H{"message": "day reclaimed successfully"})
+}
+
+// Загружает закрепление из пути запроса и проверяет, что им управляет владелец стола или администратор.
+// В случае ошибки сам отправляет ответ клиенту.
+func loadManagedAssignment(c *gin.Context, repo *assignmentsRepo.AssignmentsRepository, users *userRepo.UserRepository) (*assignmentsRepo.Assignment, bool) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, false
+	}
+
+	assignment, err := repo.GetAssignment(c.Request.Context(), c.Param("assignmentId"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "assignment not found"})
+			return nil, false
+		}
+
+		slog.Error("loadManagedAssignment | Failed to load assignment", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load assignment"})
+		return nil, false
+	}
+
+	if assignment.DeskId != c.Param("id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "assignment not found"})
+		return nil, false
+	}
+
+	if assignment.UserId == userId.(string) {
+		return assignment, true
+	}
+
+	user, err := users.GetUserByID(c.Request.Context(), userId.(string))
+	if err != nil {
+		slog.Error("loadManagedAssignment | Failed to get user", "error", err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, false
+	}
+
+	if user.Role != userRepo.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the desk owner or admin can manage this assignment"})
+		return nil, false
+	}
+
+	return assignment, true
+}
+
+func isValidOptionalDate(value *string) bool {
+	if value == nil {
+		return true
+	}
+
+	_, err := time.Parse(dateLayout, *value)
+	return err == nil
+}
@@ -7,10 +7,15 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"place-picker/internal/checkin"
 	desksRepo "place-picker/internal/db/repo/desks"
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"place-picker/internal/offices"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
 )
 
 type (
@@ -75,16 +80,34 @@ func LoadDesksHandler(c *gin.Context, repo *desksRepo.DesksRepository) {
 	c.JSON(http.StatusOK, gin.H{"message": "desks initialized successfully", "count": count})
 }
 
-func GetDesksHandler(c *gin.Context, repo *desksRepo.DesksRepository) {
-	desks, err := repo.GetAllDesks(c.Request.Context())
+func GetDesksHandler(c *gin.Context, repo *desksRepo.DesksRepository, reservations *reservationsRepo.ReservationsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("GetDesksHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	desks, err := repo.GetAllDesks(c.Request.Context(), userId.(string), desksRepo.ResourceTypeDesk)
 	if err != nil {
 		slog.Error("GetDesksHandler | Unable to get desks", "error", err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load desks"})
 		return
 	}
 
+	zones := []reservationsRepo.ZoneOccupancy{}
+	for _, office := range offices.All() {
+		officeZones, err := reservations.GetZonesOccupancy(c.Request.Context(), office, office.Day(time.Now()))
+		if err != nil {
+			slog.Error("GetDesksHandler | Unable to get zones occupancy", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load desks"})
+			return
+		}
+		zones = append(zones, officeZones...)
+	}
+
 	slog.Info("GetDesksHandler | Desks get successful")
-	c.JSON(http.StatusOK, AllDesksPayload{Desks: desks})
+	c.JSON(http.StatusOK, AllDesksPayload{Desks: desks, Zones: zones})
 }
 
 func ChangeDeskName(c *gin.Context, repo *desksRepo.DesksRepository) {
@@ -147,3 +170,183 @@ func DeleteDeskHandler(c *gin.Context, repo *desksRepo.DesksRepository) {
 	slog.Info("DeleteDeskHandler | Desk deleted successful")
 	c.JSON(http.StatusOK, gin.H{"message": "desk deleted successfully"})
 }
+
+func SetDeskZoneHandler(c *gin.Context, repo *desksRepo.DesksRepository) {
+	deskId := c.Param("id")
+
+	var req SetDeskZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("SetDeskZoneHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := repo.SetDeskZone(c.Request.Context(), deskId, req.ZoneId); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "desk not found"})
+		case errors.Is(err, desksRepo.ErrZoneNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, desksRepo.ErrZoneInAnotherOffice):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			slog.Error("SetDeskZoneHandler | Failed to update desk zone", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update desk zone"})
+		}
+		return
+	}
+
+	slog.Info("SetDeskZoneHandler | Desk zone updated", "deskId", deskId, "zoneId", req.ZoneId)
+	c.JSON(http.StatusOK, gin.H{"message": "desk zone updated successfully"})
+}
+
+func SetDeskOfficeHandler(c *gin.Context, repo *desksRepo.DesksRepository) {
+	deskId := c.Param("id")
+
+	var req SetDeskOfficeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("SetDeskOfficeHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if req.Office != nil && !offices.Exists(*req.Office) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown office"})
+		return
+	}
+
+	if err := repo.SetDeskOffice(c.Request.Context(), deskId, req.Office); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "desk not found"})
+		case errors.Is(err, desksRepo.ErrZoneInAnotherOffice):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			slog.Error("SetDeskOfficeHandler | Failed to update desk office", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update desk office"})
+		}
+		return
+	}
+
+	slog.Info("SetDeskOfficeHandler | Desk office updated", "deskId", deskId, "office", req.Office)
+	c.JSON(http.StatusOK, gin.H{"message": "desk office updated successfully"})
+}
+
+func SetDeskPositionHandler(c *gin.Context, repo *desksRepo.DesksRepository) {
+	deskId := c.Param("id")
+
+	var req SetDeskPositionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("SetDeskPositionHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := repo.SetDeskPosition(c.Request.Context(), deskId, req.Position); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "desk not found"})
+			return
+		}
+
+		slog.Error("SetDeskPositionHandler | Failed to update desk position", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update desk position"})
+		return
+	}
+
+	slog.Info("SetDeskPositionHandler | Desk position updated", "deskId", deskId, "position", req.Position)
+	c.JSON(http.StatusOK, gin.H{"message": "desk position updated successfully"})
+}
+
+// Задает время на уборку после брони стола.
+func SetDeskCleaningBufferHandler(c *gin.Context, repo *desksRepo.DesksRepository) {
+	deskId := c.Param("id")
+
+	var req SetDeskCleaningBufferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("SetDeskCleaningBufferHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := repo.SetDeskCleaningBuffer(c.Request.Context(), deskId, req.Minutes); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "desk not found"})
+			return
+		}
+
+		slog.Error("SetDeskCleaningBufferHandler | Failed to update desk cleaning buffer", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update desk cleaning buffer"})
+		return
+	}
+
+	slog.Info("SetDeskCleaningBufferHandler | Desk cleaning buffer updated", "deskId", deskId, "minutes", req.Minutes)
+	c.JSON(http.StatusOK, gin.H{"message": "desk cleaning buffer updated successfully"})
+}
+
+// Включает подтверждение броней стола и задает подтверждающих.
+func SetDeskApprovalHandler(c *gin.Context, repo *desksRepo.DesksRepository) {
+	deskId := c.Param("id")
+
+	var req SetDeskApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("SetDeskApprovalHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := repo.SetDeskApproval(c.Request.Context(), deskId, *req.RequiresApproval, req.ApproverIds); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "desk not found"})
+		case errors.Is(err, desksRepo.ErrApproverNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			slog.Error("SetDeskApprovalHandler | Failed to update desk approval", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update desk approval"})
+		}
+		return
+	}
+
+	slog.Info("SetDeskApprovalHandler | Desk approval updated", "deskId", deskId, "requiresApproval", *req.RequiresApproval)
+	c.JSON(http.StatusOK, gin.H{"message": "desk approval updated successfully"})
+}
+
+func SetDeskGroupsHandler(c *gin.Context, repo *desksRepo.DesksRepository) {
+	deskId := c.Param("id")
+
+	var req SetGroupsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("SetDeskGroupsHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := repo.SetDeskGroups(c.Request.Context(), deskId, req.GroupIds); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "desk not found"})
+		case errors.Is(err, desksRepo.ErrGroupNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			slog.Error("SetDeskGroupsHandler | Failed to update desk groups", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update desk groups"})
+		}
+		return
+	}
+
+	slog.Info("SetDeskGroupsHandler | Desk groups updated", "deskId", deskId, "groupIds", req.GroupIds)
+	c.JSON(http.StatusOK, gin.H{"message": "desk groups updated successfully"})
+}
+
+func GetDeskQRCodeHandler(c *gin.Context) {
+	deskId := c.Param("id")
+
+	png, err := qrcode.Encode(checkin.DeskCheckInURL(deskId), qrcode.Medium, 512)
+	if err != nil {
+		slog.Error("GetDeskQRCodeHandler | Failed to generate QR code", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate qr code"})
+		return
+	}
+
+	c.Data(http.StatusOK, "image/png", png)
+}
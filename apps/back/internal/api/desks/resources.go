@@ -0,0 +1,176 @@
+package desks
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	desksRepo "place-picker/internal/db/repo/desks"
+	"place-picker/internal/offices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Возвращает ресурсы типа из параметра type. По умолчанию — столы, как GET /desks.
+func GetResourcesHandler(c *gin.Context, repo *desksRepo.DesksRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("GetResourcesHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	resourceType := c.DefaultQuery("type", desksRepo.ResourceTypeDesk)
+
+	resources, err := repo.GetAllDesks(c.Request.Context(), userId.(string), resourceType)
+	if err != nil {
+		slog.Error("GetResourcesHandler | Unable to get resources", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load resources"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ResourcesPayload{Resources: resources})
+}
+
+func CreateResourceHandler(c *gin.Context, repo *desksRepo.DesksRepository) {
+	var req CreateResourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("CreateResourceHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if req.Office != nil && !offices.Exists(*req.Office) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown office"})
+		return
+	}
+
+	id, err := repo.CreateResource(c.Request.Context(), desksRepo.NewResource{
+		Type:     req.Type,
+		Name:     req.Name,
+		ZoneId:   req.ZoneId,
+		Office:   req.Office,
+		Capacity: req.Capacity,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, desksRepo.ErrResourceNameTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, desksRepo.ErrUnknownResourceType):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, desksRepo.ErrZoneNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, desksRepo.ErrZoneInAnotherOffice):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			slog.Error("CreateResourceHandler | Failed to create resource", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create resource"})
+		}
+		return
+	}
+
+	slog.Info("CreateResourceHandler | Resource created", "id", id, "type", req.Type)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func SetResourceCapacityHandler(c *gin.Context, repo *desksRepo.DesksRepository) {
+	resourceId := c.Param("id")
+
+	var req SetCapacityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("SetResourceCapacityHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := repo.SetResourceCapacity(c.Request.Context(), resourceId, req.Capacity); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "resource not found"})
+			return
+		}
+
+		slog.Error("SetResourceCapacityHandler | Failed to update capacity", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update resource capacity"})
+		return
+	}
+
+	slog.Info("SetResourceCapacityHandler | Resource capacity updated", "id", resourceId, "capacity", req.Capacity)
+	c.JSON(http.StatusOK, gin.H{"message": "resource capacity updated successfully"})
+}
+
+func GetResourceTypesHandler(c *gin.Context, repo *desksRepo.DesksRepository) {
+	types, err := repo.GetResourceTypes(c.Request.Context())
+	if err != nil {
+		slog.Error("GetResourceTypesHandler | Failed to load resource types", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load resource types"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ResourceTypesPayload{Types: types})
+}
+
+func UpdateResourceTypeHandler(c *gin.Context, repo *desksRepo.DesksRepository) {
+	var req UpdateResourceTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("UpdateResourceTypeHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if message := validateResourceType(req); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+
+	resourceType := desksRepo.ResourceType{
+		Key:                c.Param("type"),
+		Name:               req.Name,
+		WorkStart:          req.WorkStart,
+		WorkEnd:            req.WorkEnd,
+		MinDurationMinutes: req.MinDurationMinutes,
+		MaxDurationMinutes: req.MaxDurationMinutes,
+		AllowsAttendees:    req.AllowsAttendees,
+		CheckInRequired:    req.CheckInRequired,
+	}
+
+	if err := repo.UpdateResourceType(c.Request.Context(), resourceType); err != nil {
+		if errors.Is(err, desksRepo.ErrUnknownResourceType) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		slog.Error("UpdateResourceTypeHandler | Failed to update resource type", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update resource type"})
+		return
+	}
+
+	slog.Info("UpdateResourceTypeHandler | Resource type updated", "type", resourceType.Key)
+	c.JSON(http.StatusOK, gin.H{"message": "resource type updated successfully"})
+}
+
+func validateResourceType(req UpdateResourceTypeRequest) string {
+	if (req.WorkStart == nil) != (req.WorkEnd == nil) {
+		return "workStart and workEnd must be set together"
+	}
+
+	if req.WorkStart != nil {
+		start, err := time.Parse("15:04", *req.WorkStart)
+		if err != nil {
+			return "workStart must be in HH:MM format"
+		}
+		end, err := time.Parse("15:04", *req.WorkEnd)
+		if err != nil {
+			return "workEnd must be in HH:MM format"
+		}
+		if !end.After(start) {
+			return "workEnd must be after workStart"
+		}
+	}
+
+	if req.MinDurationMinutes != nil && req.MaxDurationMinutes != nil && *req.MaxDurationMinutes < *req.MinDurationMinutes {
+		return "maxDurationMinutes must not be less than minDurationMinutes"
+	}
+
+	return ""
+}
@@ -5,28 +5,137 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	assignmentsRepo "place-picker/internal/db/repo/assignments"
 	desksRepo "place-picker/internal/db/repo/desks"
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	userRepo "place-picker/internal/db/repo/user"
+	adminMiddleware "place-picker/internal/server/middleware/admin"
 )
 
 type (
 	Desks struct {
-		DesksRepo *desksRepo.DesksRepository
+		DesksRepo        *desksRepo.DesksRepository
+		AssignmentsRepo  *assignmentsRepo.AssignmentsRepository
+		ReservationsRepo *reservationsRepo.ReservationsRepository
+		UserRepo         *userRepo.UserRepository
 	}
 
+	// Zones — занятость зон со столами на сегодня по местному времени их офиса.
 	AllDesksPayload struct {
-		Desks []desksRepo.Desk `json:"desks"`
+		Desks []desksRepo.Desk                 `json:"desks"`
+		Zones []reservationsRepo.ZoneOccupancy `json:"zones"`
+	}
+
+	ResourcesPayload struct {
+		Resources []desksRepo.Desk `json:"resources"`
+	}
+
+	ResourceTypesPayload struct {
+		Types []desksRepo.ResourceType `json:"types"`
+	}
+
+	CreateResourceRequest struct {
+		Type     string  `json:"type" binding:"required"`
+		Name     string  `json:"name" binding:"required,max=100"`
+		ZoneId   *string `json:"zoneId"`
+		Office   *string `json:"office"`
+		Capacity *int    `json:"capacity" binding:"omitempty,min=1"`
+	}
+
+	// Вместимость ресурса. null снимает ограничение.
+	SetCapacityRequest struct {
+		Capacity *int `json:"capacity" binding:"omitempty,min=1"`
+	}
+
+	// Правила типа ресурса. Часы работы в формате HH:MM задаются вместе, null — часы офиса.
+	UpdateResourceTypeRequest struct {
+		Name               string  `json:"name" binding:"required"`
+		WorkStart          *string `json:"workStart"`
+		WorkEnd            *string `json:"workEnd"`
+		MinDurationMinutes *int    `json:"minDurationMinutes" binding:"omitempty,min=1"`
+		MaxDurationMinutes *int    `json:"maxDurationMinutes" binding:"omitempty,min=1"`
+		AllowsAttendees    bool    `json:"allowsAttendees"`
+		CheckInRequired    bool    `json:"checkInRequired"`
+	}
+
+	AssignmentsPayload struct {
+		Assignments []assignmentsRepo.Assignment `json:"assignments"`
+	}
+
+	AssignDeskRequest struct {
+		UserId   string  `json:"userId" binding:"required"`
+		DateFrom *string `json:"dateFrom"`
+		DateTo   *string `json:"dateTo"`
+	}
+
+	SetDeskZoneRequest struct {
+		ZoneId *string `json:"zoneId"`
+	}
+
+	SetDeskOfficeRequest struct {
+		Office *string `json:"office"`
+	}
+
+	// Координаты стола на плане этажа. null в position убирает стол с плана.
+	SetDeskPositionRequest struct {
+		Position *desksRepo.Position `json:"position"`
+	}
+
+	// Время на уборку после брони в минутах. null берет значение зоны.
+	SetDeskCleaningBufferRequest struct {
+		Minutes *int `json:"minutes" binding:"omitempty,min=0,max=240"`
+	}
+
+	// Без подтверждающих брони стола подтверждают администраторы.
+	SetDeskApprovalRequest struct {
+		RequiresApproval *bool    `json:"requiresApproval" binding:"required"`
+		ApproverIds      []string `json:"approverIds"`
+	}
+
+	SetGroupsRequest struct {
+		GroupIds []string `json:"groupIds" binding:"required"`
+	}
+
+	ReleaseDaysRequest struct {
+		Days []string `json:"days" binding:"required,min=1"`
 	}
 )
 
 func New(db *sql.DB) *Desks {
-	return &Desks{DesksRepo: desksRepo.NewDesksRepository(db)}
+	return &Desks{
+		DesksRepo:        desksRepo.NewDesksRepository(db),
+		AssignmentsRepo:  assignmentsRepo.NewAssignmentsRepository(db),
+		ReservationsRepo: reservationsRepo.NewReservationsRepository(db),
+		UserRepo:         userRepo.NewUserRepository(db),
+	}
 }
 
 func (d *Desks) RegisterPublicRoutes(r *gin.RouterGroup) {}
 
 func (d *Desks) RegisterPrivateRoutes(r *gin.RouterGroup) {
+	admin := adminMiddleware.AdminMiddleware(d.UserRepo)
+
 	// r.POST("/desks/load", func(c *gin.Context) { LoadDesksHandler(c, d.DesksRepo) })
-	r.GET("/desks", func(c *gin.Context) { GetDesksHandler(c, d.DesksRepo) })
+	r.GET("/desks", func(c *gin.Context) { GetDesksHandler(c, d.DesksRepo, d.ReservationsRepo) })
 	r.PUT("/desks/:id", func(c *gin.Context) { ChangeDeskName(c, d.DesksRepo) })
 	r.DELETE("/desks/:id", func(c *gin.Context) { DeleteDeskHandler(c, d.DesksRepo) })
+	r.GET("/desks/:id/qr", admin, GetDeskQRCodeHandler)
+	r.PUT("/desks/:id/zone", admin, func(c *gin.Context) { SetDeskZoneHandler(c, d.DesksRepo) })
+	r.PUT("/desks/:id/office", admin, func(c *gin.Context) { SetDeskOfficeHandler(c, d.DesksRepo) })
+	r.PUT("/desks/:id/position", admin, func(c *gin.Context) { SetDeskPositionHandler(c, d.DesksRepo) })
+	r.PUT("/desks/:id/cleaning-buffer", admin, func(c *gin.Context) { SetDeskCleaningBufferHandler(c, d.DesksRepo) })
+	r.PUT("/desks/:id/approval", admin, func(c *gin.Context) { SetDeskApprovalHandler(c, d.DesksRepo) })
+	r.PUT("/desks/:id/groups", admin, func(c *gin.Context) { SetDeskGroupsHandler(c, d.DesksRepo) })
+
+	r.GET("/resources", func(c *gin.Context) { GetResourcesHandler(c, d.DesksRepo) })
+	r.POST("/resources", admin, func(c *gin.Context) { CreateResourceHandler(c, d.DesksRepo) })
+	r.PUT("/resources/:id/capacity", admin, func(c *gin.Context) { SetResourceCapacityHandler(c, d.DesksRepo) })
+	r.GET("/resources/types", func(c *gin.Context) { GetResourceTypesHandler(c, d.DesksRepo) })
+	r.PUT("/resources/types/:type", admin, func(c *gin.Context) { UpdateResourceTypeHandler(c, d.DesksRepo) })
+
+	r.GET("/desks/:id/assignments", func(c *gin.Context) { GetDeskAssignmentsHandler(c, d.AssignmentsRepo) })
+	r.POST("/desks/:id/assignments", admin, func(c *gin.Context) { AssignDeskHandler(c, d.AssignmentsRepo) })
+	r.DELETE("/desks/:id/assignments/:assignmentId", admin, func(c *gin.Context) { DeleteAssignmentHandler(c, d.AssignmentsRepo) })
+	r.POST("/desks/:id/assignments/:assignmentId/releases", func(c *gin.Context) { ReleaseDaysHandler(c, d.AssignmentsRepo, d.UserRepo) })
+	r.DELETE("/desks/:id/assignments/:assignmentId/releases/:day", func(c *gin.Context) { ReclaimDayHandler(c, d.AssignmentsRepo, d.UserRepo) })
 }
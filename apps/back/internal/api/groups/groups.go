@@ -0,0 +1,121 @@
+package groups
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	groupsRepo "place-picker/internal/db/repo/groups"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetGroupsHandler(c *gin.Context, repo *groupsRepo.GroupsRepository) {
+	groups, err := repo.GetAllGroups(c.Request.Context())
+	if err != nil {
+		slog.Error("GetGroupsHandler | Failed to load groups", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load groups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, GroupsPayload{Groups: groups})
+}
+
+func CreateGroupHandler(c *gin.Context, repo *groupsRepo.GroupsRepository) {
+	var req CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("CreateGroupHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	id, err := repo.CreateGroup(c.Request.Context(), req.Name)
+	if err != nil {
+		if errors.Is(err, groupsRepo.ErrGroupAlreadyExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+
+		slog.Error("CreateGroupHandler | Failed to create group", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create group"})
+		return
+	}
+
+	slog.Info("CreateGroupHandler | Group created", "groupId", id, "name", req.Name)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func DeleteGroupHandler(c *gin.Context, repo *groupsRepo.GroupsRepository) {
+	groupId := c.Param("id")
+
+	if err := repo.DeleteGroup(c.Request.Context(), groupId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+			return
+		}
+
+		slog.Error("DeleteGroupHandler | Failed to delete group", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete group"})
+		return
+	}
+
+	slog.Info("DeleteGroupHandler | Group deleted", "groupId", groupId)
+	c.JSON(http.StatusOK, gin.H{"message": "group deleted successfully"})
+}
+
+func GetGroupMembersHandler(c *gin.Context, repo *groupsRepo.GroupsRepository) {
+	members, err := repo.GetGroupMembers(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		slog.Error("GetGroupMembersHandler | Failed to load group members", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load group members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, MembersPayload{Members: members})
+}
+
+func AddGroupMemberHandler(c *gin.Context, repo *groupsRepo.GroupsRepository) {
+	groupId := c.Param("id")
+
+	var req AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("AddGroupMemberHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := repo.AddMember(c.Request.Context(), groupId, req.UserId); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+		case errors.Is(err, groupsRepo.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			slog.Error("AddGroupMemberHandler | Failed to add group member", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add group member"})
+		}
+		return
+	}
+
+	slog.Info("AddGroupMemberHandler | Group member added", "groupId", groupId, "userId", req.UserId)
+	c.JSON(http.StatusOK, gin.H{"message": "group member added successfully"})
+}
+
+func RemoveGroupMemberHandler(c *gin.Context, repo *groupsRepo.GroupsRepository) {
+	groupId := c.Param("id")
+	userId := c.Param("userId")
+
+	if err := repo.RemoveMember(c.Request.Context(), groupId, userId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "group member not found"})
+			return
+		}
+
+		slog.Error("RemoveGroupMemberHandler | Failed to remove group member", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove group member"})
+		return
+	}
+
+	slog.Info("RemoveGroupMemberHandler | Group member removed", "groupId", groupId, "userId", userId)
+	c.JSON(http.StatusOK, gin.H{"message": "group member removed successfully"})
+}
@@ -0,0 +1,54 @@
+package groups
+
+import (
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+
+	groupsRepo "place-picker/internal/db/repo/groups"
+	userRepo "place-picker/internal/db/repo/user"
+	adminMiddleware "place-picker/internal/server/middleware/admin"
+)
+
+type (
+	Groups struct {
+		GroupsRepo *groupsRepo.GroupsRepository
+		UserRepo   *userRepo.UserRepository
+	}
+
+	GroupsPayload struct {
+		Groups []groupsRepo.Group `json:"groups"`
+	}
+
+	MembersPayload struct {
+		Members []groupsRepo.Member `json:"members"`
+	}
+
+	CreateGroupRequest struct {
+		Name string `json:"name" binding:"required"`
+	}
+
+	AddMemberRequest struct {
+		UserId string `json:"userId" binding:"required"`
+	}
+)
+
+func New(db *sql.DB) *Groups {
+	return &Groups{
+		GroupsRepo: groupsRepo.NewGroupsRepository(db),
+		UserRepo:   userRepo.NewUserRepository(db),
+	}
+}
+
+func (g *Groups) RegisterPublicRoutes(r *gin.RouterGroup) {}
+
+func (g *Groups) RegisterPrivateRoutes(r *gin.RouterGroup) {
+	admin := adminMiddleware.AdminMiddleware(g.UserRepo)
+
+	r.GET("/groups", func(c *gin.Context) { GetGroupsHandler(c, g.GroupsRepo) })
+	r.POST("/groups", admin, func(c *gin.Context) { CreateGroupHandler(c, g.GroupsRepo) })
+	r.DELETE("/groups/:id", admin, func(c *gin.Context) { DeleteGroupHandler(c, g.GroupsRepo) })
+	r.GET("/groups/:id/members", func(c *gin.Context) { GetGroupMembersHandler(c, g.GroupsRepo) })
+	r.POST("/groups/:id/members", admin, func(c *gin.Context) { AddGroupMemberHandler(c, g.GroupsRepo) })
+	r.DELETE("/groups/:id/members/:userId", admin, func(c *gin.Context) { RemoveGroupMemberHandler(c, g.GroupsRepo) })
+}
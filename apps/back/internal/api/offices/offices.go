@@ -0,0 +1,12 @@
+package offices
+
+import (
+	"net/http"
+	"place-picker/internal/offices"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetOfficesHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, OfficesPayload{Offices: offices.All()})
+}
@@ -0,0 +1,25 @@
+package offices
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"place-picker/internal/offices"
+)
+
+type (
+	Offices struct{}
+
+	OfficesPayload struct {
+		Offices []offices.Office `json:"offices"`
+	}
+)
+
+func New() *Offices {
+	return &Offices{}
+}
+
+func (o *Offices) RegisterPublicRoutes(r *gin.RouterGroup) {}
+
+func (o *Offices) RegisterPrivateRoutes(r *gin.RouterGroup) {
+	r.GET("/offices", GetOfficesHandler)
+}
@@ -0,0 +1,88 @@
+package policies
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	policiesRepo "place-picker/internal/db/repo/policies"
+	userRepo "place-picker/internal/db/repo/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetPoliciesHandler(c *gin.Context, repo *policiesRepo.PoliciesRepository) {
+	policies, err := repo.GetPolicies(c.Request.Context())
+	if err != nil {
+		slog.Error("GetPoliciesHandler | Failed to load policies", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load policies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, PoliciesPayload{Policies: policies})
+}
+
+// Создает или заменяет правила роли. Правила применяются к новым броням сразу, без перезапуска.
+func UpdatePolicyHandler(c *gin.Context, repo *policiesRepo.PoliciesRepository) {
+	role := c.Param("role")
+	if !isKnownRole(role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown role"})
+		return
+	}
+
+	var req PolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("UpdatePolicyHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	policy := policiesRepo.Policy{
+		Role:               role,
+		MaxDaysAhead:       req.MaxDaysAhead,
+		MaxPerWeek:         req.MaxPerWeek,
+		MaxPerMonth:        req.MaxPerMonth,
+		MinDurationMinutes: req.MinDurationMinutes,
+		MaxDurationMinutes: req.MaxDurationMinutes,
+		BlackoutWeekdays:   req.BlackoutWeekdays,
+	}
+
+	if err := repo.UpsertPolicy(c.Request.Context(), policy); err != nil {
+		if errors.Is(err, policiesRepo.ErrInvalidPolicy) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		slog.Error("UpdatePolicyHandler | Failed to save policy", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save policy"})
+		return
+	}
+
+	slog.Info("UpdatePolicyHandler | Policy updated", "role", role)
+	c.JSON(http.StatusOK, gin.H{"message": "policy updated successfully"})
+}
+
+// Удаляет правила роли, после чего к ней применяются правила по умолчанию.
+func DeletePolicyHandler(c *gin.Context, repo *policiesRepo.PoliciesRepository) {
+	role := c.Param("role")
+
+	if err := repo.DeletePolicy(c.Request.Context(), role); err != nil {
+		switch {
+		case errors.Is(err, policiesRepo.ErrDefaultPolicyRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "policy not found"})
+		default:
+			slog.Error("DeletePolicyHandler | Failed to delete policy", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete policy"})
+		}
+		return
+	}
+
+	slog.Info("DeletePolicyHandler | Policy deleted", "role", role)
+	c.JSON(http.StatusOK, gin.H{"message": "policy deleted successfully"})
+}
+
+func isKnownRole(role string) bool {
+	return role == policiesRepo.DefaultRole || role == userRepo.RoleUser || role == userRepo.RoleAdmin || role == userRepo.RoleReception
+}
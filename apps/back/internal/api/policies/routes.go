@@ -0,0 +1,48 @@
+package policies
+
+import (
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+
+	policiesRepo "place-picker/internal/db/repo/policies"
+	userRepo "place-picker/internal/db/repo/user"
+	adminMiddleware "place-picker/internal/server/middleware/admin"
+)
+
+type (
+	Policies struct {
+		PoliciesRepo *policiesRepo.PoliciesRepository
+		UserRepo     *userRepo.UserRepository
+	}
+
+	PoliciesPayload struct {
+		Policies []policiesRepo.Policy `json:"policies"`
+	}
+
+	PolicyRequest struct {
+		MaxDaysAhead       *int    `json:"maxDaysAhead" binding:"omitempty,min=0"`
+		MaxPerWeek         *int    `json:"maxPerWeek" binding:"omitempty,min=0"`
+		MaxPerMonth        *int    `json:"maxPerMonth" binding:"omitempty,min=0"`
+		MinDurationMinutes *int    `json:"minDurationMinutes" binding:"omitempty,min=1"`
+		MaxDurationMinutes *int    `json:"maxDurationMinutes" binding:"omitempty,min=1"`
+		BlackoutWeekdays   []int64 `json:"blackoutWeekdays" binding:"dive,min=0,max=6"`
+	}
+)
+
+func New(db *sql.DB) *Policies {
+	return &Policies{
+		PoliciesRepo: policiesRepo.NewPoliciesRepository(db),
+		UserRepo:     userRepo.NewUserRepository(db),
+	}
+}
+
+func (p *Policies) RegisterPublicRoutes(r *gin.RouterGroup) {}
+
+func (p *Policies) RegisterPrivateRoutes(r *gin.RouterGroup) {
+	admin := adminMiddleware.AdminMiddleware(p.UserRepo)
+
+	r.GET("/policies", admin, func(c *gin.Context) { GetPoliciesHandler(c, p.PoliciesRepo) })
+	r.PUT("/policies/:role", admin, func(c *gin.Context) { UpdatePolicyHandler(c, p.PoliciesRepo) })
+	r.DELETE("/policies/:role", admin, func(c *gin.Context) { DeletePolicyHandler(c, p.PoliciesRepo) })
+}
@@ -0,0 +1,69 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+var ErrRequired = errors.New("is required")
+
+// Ошибка значения конкретного поля запроса.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Разбирает тело запроса. Ошибки валидации возвращаются клиенту списком полей.
+// В случае ошибки сам отправляет ответ клиенту.
+func BindJSON(c *gin.Context, handler string, req any) bool {
+	err := c.ShouldBindJSON(req)
+	if err == nil {
+		return true
+	}
+
+	slog.Error(handler+" | Unable parse request body", "error", err.Error())
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fields := make([]FieldError, 0, len(validationErrors))
+		for _, fieldErr := range validationErrors {
+			fields = append(fields, FieldError{Field: jsonFieldName(fieldErr.Field()), Message: validationMessage(fieldErr)})
+		}
+		RespondFieldErrors(c, fields...)
+		return false
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+	return false
+}
+
+func RespondFieldErrors(c *gin.Context, fields ...FieldError) {
+	c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request", "fields": fields})
+}
+
+func jsonFieldName(name string) string {
+	if name == "" {
+		return name
+	}
+
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+func validationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return ErrRequired.Error()
+	case "min":
+		return fmt.Sprintf("must contain at least %s items", fieldErr.Param())
+	default:
+		return fmt.Sprintf("failed on the %s rule", fieldErr.Tag())
+	}
+}
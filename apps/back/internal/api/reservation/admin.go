@@ -0,0 +1,169 @@
+package reservation
+
+import (
+	"log/slog"
+	"net/http"
+	"place-picker/internal/offices"
+	"place-picker/internal/waitlist"
+
+	"github.com/gin-gonic/gin"
+
+	"place-picker/internal/api/request"
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+)
+
+// Брони всех пользователей с отбором по столу, пользователю, датам и статусам.
+// Без статусов возвращаются брони, которые занимают стол.
+func GetAdminReservationsHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	var query AdminReservationsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		slog.Error("GetAdminReservationsHandler | Unable parse query", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	filter, ok := buildReservationFilter(c, "GetAdminReservationsHandler", repo, query.ReservationFilterInput)
+	if !ok {
+		return
+	}
+
+	filter.Statuses = query.Status
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []string{reservationsRepo.StatusActive, reservationsRepo.StatusPending}
+	}
+
+	reservations, err := repo.GetReservations(c.Request.Context(), filter)
+	if err != nil {
+		slog.Error("GetAdminReservationsHandler | Failed to load reservations", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load reservations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, AdminReservationsPayload{Reservations: reservations})
+}
+
+// Отменяет бронь любого пользователя. Причина сохраняется в брони и отправляется владельцу.
+func AdminCancelReservationHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	var req CancelReservationRequest
+	if !request.BindJSON(c, "AdminCancelReservationHandler", &req) {
+		return
+	}
+
+	filter := reservationsRepo.ReservationFilter{Ids: []string{c.Param("id")}}
+
+	cancelled, ok := cancelReservationsByAdmin(c, "AdminCancelReservationHandler", repo, filter, req.Reason)
+	if !ok {
+		return
+	}
+
+	if len(cancelled) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "reservation not found or already finished"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "reservation cancelled successfully"})
+}
+
+// Отменяет брони, подходящие под все условия запроса. Нужно хотя бы одно условие,
+// чтобы случайно не отменить все брони.
+func BulkCancelReservationsHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	var req BulkCancelRequest
+	if !request.BindJSON(c, "BulkCancelReservationsHandler", &req) {
+		return
+	}
+
+	input := req.ReservationFilterInput
+	if len(req.ReservationIds) == 0 && input.DeskId == nil && input.UserId == nil && input.DateFrom == "" && input.DateTo == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one filter is required"})
+		return
+	}
+
+	filter, ok := buildReservationFilter(c, "BulkCancelReservationsHandler", repo, input)
+	if !ok {
+		return
+	}
+	filter.Ids = req.ReservationIds
+
+	cancelled, ok := cancelReservationsByAdmin(c, "BulkCancelReservationsHandler", repo, filter, req.Reason)
+	if !ok {
+		return
+	}
+
+	ids := make([]string, 0, len(cancelled))
+	for _, reservation := range cancelled {
+		ids = append(ids, reservation.Id)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "reservations cancelled successfully",
+		"cancelled": ids,
+	})
+}
+
+func cancelReservationsByAdmin(c *gin.Context, handler string, repo *reservationsRepo.ReservationsRepository, filter reservationsRepo.ReservationFilter, reason string) ([]reservationsRepo.AdminReservation, bool) {
+	adminId, exists := c.Get("userId")
+	if !exists {
+		slog.Error(handler + " | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, false
+	}
+
+	cancelled, err := repo.CancelReservationsByAdmin(c.Request.Context(), filter, adminId.(string), reason)
+	if err != nil {
+		slog.Error(handler+" | Failed to cancel reservations", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel reservations"})
+		return nil, false
+	}
+
+	if len(cancelled) > 0 {
+		waitlist.Wake()
+		notifyCancelledByAdmin(cancelled, reason)
+	}
+
+	slog.Info(handler+" | Reservations cancelled", "adminId", adminId, "count", len(cancelled), "reason", reason)
+	return cancelled, true
+}
+
+// Переводит отбор из запроса в отбор репозитория. Даты считаются по времени офиса стола,
+// если стол указан, иначе по времени офиса по умолчанию.
+func buildReservationFilter(c *gin.Context, handler string, repo *reservationsRepo.ReservationsRepository, input ReservationFilterInput) (reservationsRepo.ReservationFilter, bool) {
+	filter := reservationsRepo.ReservationFilter{DeskId: input.DeskId, UserId: input.UserId}
+	if input.DateFrom == "" && input.DateTo == "" {
+		return filter, true
+	}
+
+	var office offices.Office
+	if input.DeskId != nil {
+		var ok bool
+		if office, ok = loadDeskOffice(c, handler, repo, *input.DeskId); !ok {
+			return filter, false
+		}
+	} else {
+		var err error
+		if office, err = offices.Get(""); err != nil {
+			slog.Error(handler+" | Failed to load default office", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load office"})
+			return filter, false
+		}
+	}
+
+	if input.DateFrom != "" {
+		start, _, err := office.DayBounds(input.DateFrom)
+		if err != nil {
+			request.RespondFieldErrors(c, request.FieldError{Field: "dateFrom", Message: "must be a date in YYYY-MM-DD format"})
+			return filter, false
+		}
+		filter.From = &start
+	}
+
+	if input.DateTo != "" {
+		_, end, err := office.DayBounds(input.DateTo)
+		if err != nil {
+			request.RespondFieldErrors(c, request.FieldError{Field: "dateTo", Message: "must be a date in YYYY-MM-DD format"})
+			return filter, false
+		}
+		filter.To = &end
+	}
+
+	return filter, true
+}
@@ -0,0 +1,36 @@
+package reservation
+
+import (
+	"context"
+	"log/slog"
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"time"
+)
+
+// Подбирает варианты для занятого стола. Ошибка подбора только логируется: клиент все равно получает 409.
+func loadAlternatives(ctx context.Context, repo *reservationsRepo.ReservationsRepository, deskId, userId string, dateFrom, dateTo time.Time, excludeId *string) *reservationsRepo.Alternatives {
+	alternatives, err := repo.SuggestAlternatives(ctx, deskId, userId, dateFrom, dateTo, excludeId)
+	if err != nil {
+		slog.Error("loadAlternatives | Failed to suggest alternatives", "error", err.Error())
+		return nil
+	}
+
+	return &alternatives
+}
+
+// Варианты для первого дня, который не удалось забронировать из-за занятого стола.
+func batchAlternatives(ctx context.Context, repo *reservationsRepo.ReservationsRepository, deskId, userId string, slots []reservationsRepo.ReservationSlot, conflicts []reservationsRepo.DayConflict) *reservationsRepo.Alternatives {
+	for _, conflict := range conflicts {
+		if conflict.Error != reservationsRepo.ErrDeskAlreadyReserved.Error() {
+			continue
+		}
+
+		for _, slot := range slots {
+			if slot.DateFrom.Format(time.DateOnly) == conflict.Day {
+				return loadAlternatives(ctx, repo, deskId, userId, slot.DateFrom, slot.DateTo, nil)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"place-picker/internal/api/request"
+	"place-picker/internal/approval"
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"place-picker/internal/notifications"
+	"place-picker/internal/waitlist"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Возвращает брони, ожидающие решения текущего пользователя.
+func GetPendingApprovalsHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("GetPendingApprovalsHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	pending, err := repo.GetPendingApprovals(c.Request.Context(), userId.(string))
+	if err != nil {
+		slog.Error("GetPendingApprovalsHandler | Failed to load pending reservations", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load pending reservations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, PendingApprovalsPayload{Reservations: pending})
+}
+
+// Подтверждает или отклоняет ожидающую бронь и уведомляет пользователя о решении.
+func DecideReservationHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository, approve bool) {
+	reservationId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("DecideReservationHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req DecisionRequest
+	if c.Request.ContentLength != 0 && !request.BindJSON(c, "DecideReservationHandler", &req) {
+		return
+	}
+
+	notice, err := repo.DecideReservation(c.Request.Context(), reservationId, userId.(string), approve, req.Comment)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "reservation not found"})
+		case errors.Is(err, reservationsRepo.ErrNotApprover):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, reservationsRepo.ErrNotPending):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			slog.Error("DecideReservationHandler | Failed to decide reservation", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to decide reservation"})
+		}
+		return
+	}
+
+	subject, body := approval.DecisionEmail(notice)
+	notifications.Send(notice.UserEmail, subject, body)
+
+	message := "reservation approved successfully"
+	if approve {
+		sendGuestInvitation(c.Request.Context(), repo, reservationId)
+	} else {
+		message = "reservation declined successfully"
+		waitlist.Wake()
+	}
+
+	slog.Info("DecideReservationHandler | Reservation decided", "reservationId", reservationId, "approverId", userId, "status", notice.Status)
+	c.JSON(http.StatusOK, gin.H{"message": message, "status": notice.Status})
+}
+
+// Сообщает подтверждающим стола о новой брони, ожидающей решения.
+func notifyApprovers(ctx context.Context, repo *reservationsRepo.ReservationsRepository, deskId, userId string, slots []reservationsRepo.ReservationSlot) {
+	if len(slots) == 0 {
+		return
+	}
+
+	emails, err := repo.GetDeskApproverEmails(ctx, deskId)
+	if err != nil {
+		slog.Error("notifyApprovers | Failed to load approvers", "error", err.Error())
+		return
+	}
+
+	contacts, ok := loadBookingContacts(ctx, repo, deskId, userId, userId)
+	if !ok {
+		return
+	}
+
+	body := fmt.Sprintf(`
+		<h2>Бронь стола %s ждет подтверждения</h2>
+		<p>Пользователь %s забронировал стол с %s, дней: %d.</p>
+		<p>Подтвердите или отклоните бронь в разделе подтверждений.</p>
+	`, notifications.Escape(contacts.DeskName), notifications.Escape(contacts.UserName), notifications.FormatSlot(contacts.DeskOffice, slots[0].DateFrom, slots[0].DateTo), len(slots))
+
+	for _, email := range emails {
+		notifications.Send(email, "Бронь ждет подтверждения", body)
+	}
+}
@@ -0,0 +1,114 @@
+package reservation
+
+import (
+	"encoding/json"
+	"errors"
+	"place-picker/internal/api/request"
+	"strings"
+	"time"
+)
+
+// Устаревший формат времени брони. Поддерживается для совместимости, время указывается в UTC, как его отправляет фронтенд.
+const dateTimeLayout = "15:04 02.01.2006"
+
+// Форматы ISO 8601 без смещения. Время считается местным временем офиса.
+var localDateTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+var errInvalidDateTime = errors.New(`must be an RFC 3339 timestamp, a {"date", "time"} object or "15:04 02.01.2006"`)
+
+type (
+	// DateTimeInput принимает время брони в одном из форматов:
+	//   - RFC 3339 со смещением: "2025-05-01T09:00:00+03:00";
+	//   - ISO 8601 без смещения: "2025-05-01T09:00", по местному времени офиса;
+	//   - объект с датой и временем: {"date": "2025-05-01", "time": "09:00"}, по местному времени офиса;
+	//   - устаревший формат "09:00 01.05.2025", в UTC.
+	DateTimeInput struct {
+		raw json.RawMessage
+	}
+
+	dateTimeObject struct {
+		Date string `json:"date"`
+		Time string `json:"time"`
+	}
+)
+
+// Значение разбирается при вызове Resolve, когда известен часовой пояс офиса,
+// чтобы ошибка формата вернулась как ошибка конкретного поля.
+func (d *DateTimeInput) UnmarshalJSON(data []byte) error {
+	d.raw = append(d.raw[:0], data...)
+	return nil
+}
+
+// Возвращает значение в формате RFC 3339, как если бы оно пришло в запросе.
+func dateTimeValue(t time.Time) DateTimeInput {
+	return DateTimeInput{raw: json.RawMessage(`"` + t.Format(time.RFC3339) + `"`)}
+}
+
+func (d DateTimeInput) IsZero() bool {
+	return len(d.raw) == 0 || string(d.raw) == "null"
+}
+
+// Возвращает момент времени в часовом поясе location.
+func (d DateTimeInput) Resolve(location *time.Location) (time.Time, error) {
+	if d.IsZero() {
+		return time.Time{}, request.ErrRequired
+	}
+
+	switch d.raw[0] {
+	case '"':
+		var value string
+		if err := json.Unmarshal(d.raw, &value); err != nil {
+			return time.Time{}, errInvalidDateTime
+		}
+		return parseDateTimeString(strings.TrimSpace(value), location)
+	case '{':
+		var value dateTimeObject
+		if err := json.Unmarshal(d.raw, &value); err != nil {
+			return time.Time{}, errInvalidDateTime
+		}
+		return parseDateTimeObject(value, location)
+	default:
+		return time.Time{}, errInvalidDateTime
+	}
+}
+
+func parseDateTimeString(value string, location *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, request.ErrRequired
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(location), nil
+	}
+
+	for _, layout := range localDateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, nil
+		}
+	}
+
+	if t, err := time.Parse(dateTimeLayout, value); err == nil {
+		return t.In(location), nil
+	}
+
+	return time.Time{}, errInvalidDateTime
+}
+
+func parseDateTimeObject(value dateTimeObject, location *time.Location) (time.Time, error) {
+	date, err := time.Parse(time.DateOnly, value.Date)
+	if err != nil {
+		return time.Time{}, errors.New("date must be in YYYY-MM-DD format")
+	}
+
+	var clock time.Time
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if clock, err = time.Parse(layout, value.Time); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}, errors.New("time must be in HH:MM format")
+	}
+
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, location), nil
+}
@@ -0,0 +1,113 @@
+package reservation
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"place-picker/internal/api/request"
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"place-picker/internal/notifications"
+	"place-picker/internal/offices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Бронирует соседние столы одной зоны для всех участников командного дня. Брони создаются все или ни одной.
+func CreateGroupReservationHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("CreateGroupReservationHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req GroupReservationRequest
+	if !request.BindJSON(c, "CreateGroupReservationHandler", &req) {
+		return
+	}
+
+	seen := make(map[string]bool, len(req.Attendees))
+	for _, attendee := range req.Attendees {
+		if seen[attendee] {
+			request.RespondFieldErrors(c, request.FieldError{Field: "attendees", Message: "must not contain duplicates"})
+			return
+		}
+		seen[attendee] = true
+	}
+
+	var (
+		office offices.Office
+		err    error
+	)
+	switch {
+	case req.ZoneId != nil:
+		office, err = repo.GetZoneOffice(c.Request.Context(), *req.ZoneId)
+		if errors.Is(err, reservationsRepo.ErrZoneNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+	case req.Office != nil:
+		office, err = offices.Get(*req.Office)
+		if errors.Is(err, offices.ErrUnknownOffice) {
+			request.RespondFieldErrors(c, request.FieldError{Field: "office", Message: err.Error()})
+			return
+		}
+	default:
+		office, err = offices.Get("")
+	}
+	if err != nil {
+		slog.Error("CreateGroupReservationHandler | Failed to load office", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create group reservation"})
+		return
+	}
+
+	dateFrom, dateTo, ok := parseSingleDayWindow(c, office, req.DateFrom, req.DateTo)
+	if !ok {
+		return
+	}
+
+	booking, err := repo.CreateGroupBooking(c.Request.Context(), userId.(string), req.Attendees, req.ZoneId, office, dateFrom, dateTo)
+	if err != nil {
+		var attendeeErr *reservationsRepo.AttendeeError
+		var policyErr *reservationsRepo.PolicyError
+		switch {
+		case errors.Is(err, reservationsRepo.ErrNoAdjacentDesks):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.As(err, &policyErr) && errors.As(err, &attendeeErr):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": policyErr.Message, "code": policyErr.Code, "userId": attendeeErr.UserId})
+		case errors.As(err, &attendeeErr):
+			status := http.StatusConflict
+			if errors.Is(err, reservationsRepo.ErrNotDelegated) {
+				status = http.StatusForbidden
+			} else if errors.Is(err, reservationsRepo.ErrUserNotFound) {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, gin.H{"error": attendeeErr.Err.Error(), "userId": attendeeErr.UserId})
+		case errors.Is(err, reservationsRepo.ErrOutsideWorkingHours),
+			errors.Is(err, reservationsRepo.ErrInvalidPeriod):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			slog.Error("CreateGroupReservationHandler | Failed to create group reservation", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create group reservation"})
+		}
+		return
+	}
+
+	slot := notifications.FormatSlot(booking.DeskOffice, dateFrom, dateTo)
+	for _, seat := range booking.Seats {
+		notifications.Send(seat.UserEmail, "Командный день: стол забронирован", fmt.Sprintf(`
+			<h2>Стол %s забронирован</h2>
+			<p>Для вас забронирован стол в зоне %s на %s рядом с коллегами.</p>
+			<p>Бронь оформлена пользователем %s.</p>
+		`, notifications.Escape(seat.DeskName), notifications.Escape(booking.ZoneName), slot, notifications.Escape(booking.BookerName)))
+	}
+
+	slog.Info("CreateGroupReservationHandler | Group reservation created", "userId", userId, "zoneId", booking.ZoneId, "attendees", len(booking.Seats))
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "group reservation created successfully",
+		"zoneId":   booking.ZoneId,
+		"zoneName": booking.ZoneName,
+		"seats":    booking.Seats,
+	})
+}
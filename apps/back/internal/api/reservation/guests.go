@@ -0,0 +1,160 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"place-picker/internal/api/request"
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"place-picker/internal/notifications"
+	"place-picker/internal/offices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Бронирует стол для гостя текущего пользователя и отправляет гостю приглашение.
+// Для стола, требующего подтверждения, приглашение отправляется после подтверждения брони.
+func CreateGuestReservationHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("CreateGuestReservationHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req GuestReservationRequest
+	if !request.BindJSON(c, "CreateGuestReservationHandler", &req) {
+		return
+	}
+
+	guest := reservationsRepo.Guest{Name: strings.TrimSpace(req.Name), Email: req.Email, Company: req.Company}
+	if guest.Name == "" {
+		request.RespondFieldErrors(c, request.FieldError{Field: "name", Message: "must not be empty"})
+		return
+	}
+
+	office, ok := loadDeskOffice(c, "CreateGuestReservationHandler", repo, req.DeskId)
+	if !ok {
+		return
+	}
+
+	dateFrom, dateTo, ok := parseSingleDayWindow(c, office, req.DateFrom, req.DateTo)
+	if !ok {
+		return
+	}
+
+	reservationId, status, err := repo.CreateGuestReservation(c.Request.Context(), req.DeskId, userId.(string), guest, dateFrom, dateTo)
+	if err != nil {
+		if respondPolicyError(c, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, reservationsRepo.ErrDeskAlreadyReserved):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":        err.Error(),
+				"alternatives": loadAlternatives(c.Request.Context(), repo, req.DeskId, userId.(string), dateFrom, dateTo, nil),
+			})
+		case errors.Is(err, reservationsRepo.ErrDeskAssigned),
+			errors.Is(err, reservationsRepo.ErrDeskRestricted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, reservationsRepo.ErrDeskNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, reservationsRepo.ErrOutsideWorkingHours),
+			errors.Is(err, reservationsRepo.ErrInvalidPeriod):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			slog.Error("CreateGuestReservationHandler | Failed to create guest reservation", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create guest reservation"})
+		}
+		return
+	}
+
+	if status == reservationsRepo.StatusActive {
+		sendGuestInvitation(c.Request.Context(), repo, reservationId)
+	} else {
+		notifyApprovers(c.Request.Context(), repo, req.DeskId, userId.(string), []reservationsRepo.ReservationSlot{{DateFrom: dateFrom, DateTo: dateTo}})
+	}
+
+	slog.Info("CreateGuestReservationHandler | Guest reservation created", "reservationId", reservationId, "hostId", userId, "deskId", req.DeskId)
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "guest reservation created successfully",
+		"id":      reservationId,
+		"status":  status,
+	})
+}
+
+// Гости офиса на день для ресепшена. По умолчанию — сегодняшние гости офиса по умолчанию.
+func GetVisitorsHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	office, err := offices.Get(c.Query("office"))
+	if err != nil {
+		if errors.Is(err, offices.ErrUnknownOffice) {
+			request.RespondFieldErrors(c, request.FieldError{Field: "office", Message: err.Error()})
+			return
+		}
+
+		slog.Error("GetVisitorsHandler | Failed to load office", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load visitors"})
+		return
+	}
+
+	day := office.Day(time.Now())
+	if raw := c.Query("date"); raw != "" {
+		day = raw
+	}
+
+	dayStart, err := time.ParseInLocation(time.DateOnly, day, office.Location)
+	if err != nil {
+		request.RespondFieldErrors(c, request.FieldError{Field: "date", Message: "must be a date in YYYY-MM-DD format"})
+		return
+	}
+
+	defaultOffice, err := offices.Get("")
+	if err != nil {
+		slog.Error("GetVisitorsHandler | Failed to load default office", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load visitors"})
+		return
+	}
+
+	visitors, err := repo.GetVisitors(c.Request.Context(), office.Key, defaultOffice.Key, dayStart, dayStart.AddDate(0, 0, 1))
+	if err != nil {
+		slog.Error("GetVisitorsHandler | Failed to load visitors", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load visitors"})
+		return
+	}
+
+	c.JSON(http.StatusOK, VisitorsPayload{Date: day, Office: office.Key, Visitors: visitors})
+}
+
+// Отправляет гостю приглашение, если бронь гостевая, активна и у гостя указан email.
+func sendGuestInvitation(ctx context.Context, repo *reservationsRepo.ReservationsRepository, reservationId string) {
+	invitation, err := repo.GetGuestInvitation(ctx, reservationId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	if err != nil {
+		slog.Error("sendGuestInvitation | Failed to load guest invitation", "error", err.Error())
+		return
+	}
+
+	if invitation.Status != reservationsRepo.StatusActive || invitation.Guest.Email == nil {
+		return
+	}
+
+	location := notifications.Escape(invitation.DeskName)
+	if invitation.ZoneName != nil {
+		location = fmt.Sprintf("%s, зона %s", location, notifications.Escape(*invitation.ZoneName))
+	}
+
+	notifications.Send(*invitation.Guest.Email, "Приглашение в офис", fmt.Sprintf(`
+		<h2>Здравствуйте, %s!</h2>
+		<p>%s приглашает вас в офис на %s.</p>
+		<p>Для вас забронирован стол %s.</p>
+		<p>Назовите свое имя на ресепшене.</p>
+	`, notifications.Escape(invitation.Guest.Name), notifications.Escape(invitation.HostName), notifications.FormatSlot(invitation.DeskOffice, invitation.DateFrom, invitation.DateTo), location))
+}
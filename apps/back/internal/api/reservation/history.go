@@ -0,0 +1,63 @@
+package reservation
+
+import (
+	"log/slog"
+	"net/http"
+	"place-picker/internal/offices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+)
+
+const (
+	// Интервал истории по умолчанию, считая от сегодняшнего дня назад.
+	defaultHistoryDays = 30
+	// Наибольший интервал истории за один запрос.
+	maxHistoryDays = 366
+)
+
+// История броней пользователя, включая архив: отмененные с причиной отмены, завершившиеся и неявки.
+// from и to — даты в формате YYYY-MM-DD по времени офиса по умолчанию, to включительно.
+func GetReservationHistoryHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("GetReservationHistoryHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	office, err := offices.Get("")
+	if err != nil {
+		slog.Error("GetReservationHistoryHandler | Failed to load default office", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load history"})
+		return
+	}
+
+	lastDay, end, err := office.DayBounds(c.DefaultQuery("to", office.Day(time.Now())))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date in YYYY-MM-DD format"})
+		return
+	}
+
+	start, _, err := office.DayBounds(c.DefaultQuery("from", office.Day(lastDay.AddDate(0, 0, -defaultHistoryDays))))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date in YYYY-MM-DD format"})
+		return
+	}
+
+	if lastDay.Before(start) || start.AddDate(0, 0, maxHistoryDays).Before(lastDay) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "history range must not be empty or longer than 366 days"})
+		return
+	}
+
+	reservations, err := repo.GetReservationHistory(c.Request.Context(), userId.(string), start, end)
+	if err != nil {
+		slog.Error("GetReservationHistoryHandler | Failed to load history", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ReservationsPayload{Reservations: reservations})
+}
@@ -0,0 +1,242 @@
+package reservation
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"place-picker/internal/api/request"
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"place-picker/internal/offices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Лотереи офиса в интервале дат вместе с заявками текущего пользователя.
+// По умолчанию — лотереи офиса по умолчанию с сегодняшнего дня на 30 дней вперед.
+func GetLotteriesHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("GetLotteriesHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	office, ok := loadOffice(c, "GetLotteriesHandler", c.Query("office"))
+	if !ok {
+		return
+	}
+
+	from := c.DefaultQuery("from", office.Day(time.Now()))
+	fromDay, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		request.RespondFieldErrors(c, request.FieldError{Field: "from", Message: "must be a date in YYYY-MM-DD format"})
+		return
+	}
+
+	to := c.DefaultQuery("to", fromDay.AddDate(0, 0, 30).Format(time.DateOnly))
+	if _, err := time.Parse(time.DateOnly, to); err != nil {
+		request.RespondFieldErrors(c, request.FieldError{Field: "to", Message: "must be a date in YYYY-MM-DD format"})
+		return
+	}
+
+	lotteries, err := repo.GetLotteries(c.Request.Context(), userId.(string), office.Key, from, to)
+	if err != nil {
+		slog.Error("GetLotteriesHandler | Failed to load lotteries", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load lotteries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, LotteriesPayload{Office: office.Key, Lotteries: lotteries})
+}
+
+// Объявляет лотерею на день офиса. До розыгрыша обычная бронь столов офиса на этот день закрыта.
+func CreateLotteryHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("CreateLotteryHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req CreateLotteryRequest
+	if !request.BindJSON(c, "CreateLotteryHandler", &req) {
+		return
+	}
+
+	officeKey := ""
+	if req.Office != nil {
+		officeKey = *req.Office
+	}
+	office, ok := loadOffice(c, "CreateLotteryHandler", officeKey)
+	if !ok {
+		return
+	}
+
+	dayStart, _, err := office.DayBounds(req.Day)
+	if err != nil {
+		request.RespondFieldErrors(c, request.FieldError{Field: "day", Message: "must be a date in YYYY-MM-DD format"})
+		return
+	}
+
+	cutoff, err := req.CutoffAt.Resolve(office.Location)
+	if err != nil {
+		request.RespondFieldErrors(c, request.FieldError{Field: "cutoffAt", Message: err.Error()})
+		return
+	}
+
+	if !cutoff.After(time.Now()) {
+		request.RespondFieldErrors(c, request.FieldError{Field: "cutoffAt", Message: "must be in the future"})
+		return
+	}
+
+	if cutoff.After(dayStart) {
+		request.RespondFieldErrors(c, request.FieldError{Field: "cutoffAt", Message: "must be before the lottery day"})
+		return
+	}
+
+	lottery, err := repo.CreateLottery(c.Request.Context(), office.Key, req.Day, cutoff, userId.(string))
+	if err != nil {
+		if errors.Is(err, reservationsRepo.ErrLotteryExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+
+		slog.Error("CreateLotteryHandler | Failed to create lottery", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create lottery"})
+		return
+	}
+
+	slog.Info("CreateLotteryHandler | Lottery created", "lotteryId", lottery.Id, "office", office.Key, "day", req.Day, "userId", userId)
+	c.JSON(http.StatusCreated, lottery)
+}
+
+// Отменяет лотерею, пока она не разыграна. Заявки удаляются, обычная бронь на день открывается.
+func DeleteLotteryHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	lotteryId := c.Param("id")
+
+	if err := repo.DeleteLottery(c.Request.Context(), lotteryId); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "lottery not found"})
+		case errors.Is(err, reservationsRepo.ErrLotteryDrawn):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			slog.Error("DeleteLotteryHandler | Failed to delete lottery", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete lottery"})
+		}
+		return
+	}
+
+	slog.Info("DeleteLotteryHandler | Lottery deleted", "lotteryId", lotteryId)
+	c.JSON(http.StatusOK, gin.H{"message": "lottery deleted successfully"})
+}
+
+// Все заявки лотереи в порядке розыгрыша с данными, по которым его можно проверить.
+func GetLotteryDrawHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	lottery, requests, err := repo.GetLotteryDraw(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "lottery not found"})
+			return
+		}
+
+		slog.Error("GetLotteryDrawHandler | Failed to load lottery draw", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load lottery draw"})
+		return
+	}
+
+	c.JSON(http.StatusOK, LotteryDrawPayload{Lottery: lottery, Requests: requests})
+}
+
+// Подает или заменяет заявку текущего пользователя с предпочтениями в порядке убывания.
+func SubmitLotteryRequestHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	lotteryId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("SubmitLotteryRequestHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req LotteryPreferencesRequest
+	if !request.BindJSON(c, "SubmitLotteryRequestHandler", &req) {
+		return
+	}
+
+	preferences := make([]reservationsRepo.LotteryPreference, 0, len(req.Preferences))
+	for _, pref := range req.Preferences {
+		if (pref.DeskId == nil) == (pref.ZoneId == nil) {
+			request.RespondFieldErrors(c, request.FieldError{Field: "preferences", Message: "each preference needs exactly one of deskId and zoneId"})
+			return
+		}
+		preferences = append(preferences, reservationsRepo.LotteryPreference{DeskId: pref.DeskId, ZoneId: pref.ZoneId})
+	}
+
+	lotteryRequest, err := repo.SubmitLotteryRequest(c.Request.Context(), lotteryId, userId.(string), preferences)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "lottery not found"})
+		case errors.Is(err, reservationsRepo.ErrDeskNotFound), errors.Is(err, reservationsRepo.ErrZoneNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, reservationsRepo.ErrLotteryClosed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, reservationsRepo.ErrLotteryPreference):
+			request.RespondFieldErrors(c, request.FieldError{Field: "preferences", Message: err.Error()})
+		default:
+			slog.Error("SubmitLotteryRequestHandler | Failed to submit lottery request", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to submit lottery request"})
+		}
+		return
+	}
+
+	slog.Info("SubmitLotteryRequestHandler | Lottery request submitted", "lotteryId", lotteryId, "userId", userId, "preferences", len(preferences))
+	c.JSON(http.StatusOK, lotteryRequest)
+}
+
+// Отзывает заявку текущего пользователя до окончания приема заявок.
+func WithdrawLotteryRequestHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	lotteryId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("WithdrawLotteryRequestHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := repo.WithdrawLotteryRequest(c.Request.Context(), lotteryId, userId.(string)); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "lottery request not found"})
+		case errors.Is(err, reservationsRepo.ErrLotteryClosed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			slog.Error("WithdrawLotteryRequestHandler | Failed to withdraw lottery request", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to withdraw lottery request"})
+		}
+		return
+	}
+
+	slog.Info("WithdrawLotteryRequestHandler | Lottery request withdrawn", "lotteryId", lotteryId, "userId", userId)
+	c.JSON(http.StatusOK, gin.H{"message": "lottery request withdrawn successfully"})
+}
+
+func loadOffice(c *gin.Context, handler, key string) (offices.Office, bool) {
+	office, err := offices.Get(key)
+	if err != nil {
+		if errors.Is(err, offices.ErrUnknownOffice) {
+			request.RespondFieldErrors(c, request.FieldError{Field: "office", Message: err.Error()})
+			return offices.Office{}, false
+		}
+
+		slog.Error(handler+" | Failed to load office", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load office"})
+		return offices.Office{}, false
+	}
+
+	return office, true
+}
@@ -0,0 +1,130 @@
+package reservation
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"place-picker/internal/notifications"
+)
+
+// Письма пользователю, за которого бронь оформил, изменил или отменил другой пользователь.
+// О своих действиях с бронью пользователь не уведомляется.
+
+func notifyBookedOnBehalf(ctx context.Context, repo *reservationsRepo.ReservationsRepository, deskId, userId, bookedBy string, slots []reservationsRepo.ReservationSlot) {
+	if userId == bookedBy || len(slots) == 0 {
+		return
+	}
+
+	contacts, ok := loadBookingContacts(ctx, repo, deskId, userId, bookedBy)
+	if !ok {
+		return
+	}
+
+	var items strings.Builder
+	for _, slot := range slots {
+		items.WriteString("<li>" + notifications.FormatSlot(contacts.DeskOffice, slot.DateFrom, slot.DateTo) + "</li>")
+	}
+
+	notifications.Send(contacts.UserEmail, "Для вас забронирован стол", fmt.Sprintf(`
+		<h2>Стол %s забронирован</h2>
+		<p>Бронь оформлена пользователем %s:</p>
+		<ul>%s</ul>
+	`, notifications.Escape(contacts.DeskName), notifications.Escape(contacts.BookerName), items.String()))
+}
+
+func notifySeriesOnBehalf(ctx context.Context, repo *reservationsRepo.ReservationsRepository, series reservationsRepo.Series, occurrences int) {
+	if series.UserId == series.BookedBy {
+		return
+	}
+
+	contacts, ok := loadBookingContacts(ctx, repo, series.DeskId, series.UserId, series.BookedBy)
+	if !ok {
+		return
+	}
+
+	notifications.Send(contacts.UserEmail, "Для вас забронирован стол", fmt.Sprintf(`
+		<h2>Стол %s забронирован</h2>
+		<p>Повторяющаяся бронь оформлена пользователем %s, первое занятие — %s.</p>
+		<p>Создано броней: %d.</p>
+	`, notifications.Escape(contacts.DeskName), notifications.Escape(contacts.BookerName), notifications.FormatSlot(contacts.DeskOffice, series.DateFrom, series.DateTo), occurrences))
+}
+
+func notifyMovedOnBehalf(ctx context.Context, repo *reservationsRepo.ReservationsRepository, reservation *reservationsRepo.Reservation, actorId string, slot reservationsRepo.ReservationSlot) {
+	if reservation.UserId == actorId {
+		return
+	}
+
+	contacts, ok := loadBookingContacts(ctx, repo, reservation.DeskId, reservation.UserId, actorId)
+	if !ok {
+		return
+	}
+
+	notifications.Send(contacts.UserEmail, "Ваша бронь изменена", fmt.Sprintf(`
+		<h2>Бронь стола %s изменена</h2>
+		<p>Бронь изменена пользователем %s. Новое время: %s.</p>
+	`, notifications.Escape(contacts.DeskName), notifications.Escape(contacts.BookerName), notifications.FormatSlot(contacts.DeskOffice, slot.DateFrom, slot.DateTo)))
+}
+
+func notifyCancelledOnBehalf(ctx context.Context, repo *reservationsRepo.ReservationsRepository, reservation *reservationsRepo.Reservation, actorId string) {
+	if reservation.UserId == actorId {
+		return
+	}
+
+	contacts, ok := loadBookingContacts(ctx, repo, reservation.DeskId, reservation.UserId, actorId)
+	if !ok {
+		return
+	}
+
+	notifications.Send(contacts.UserEmail, "Ваша бронь отменена", fmt.Sprintf(`
+		<h2>Бронь стола %s отменена</h2>
+		<p>Бронь на %s отменена пользователем %s.</p>
+	`, notifications.Escape(contacts.DeskName), notifications.FormatSlot(contacts.DeskOffice, reservation.DateFrom, reservation.DateTo), notifications.Escape(contacts.BookerName)))
+}
+
+func loadBookingContacts(ctx context.Context, repo *reservationsRepo.ReservationsRepository, deskId, userId, actorId string) (reservationsRepo.BookingContacts, bool) {
+	contacts, err := repo.GetBookingContacts(ctx, deskId, userId, actorId)
+	if err != nil {
+		slog.Error("loadBookingContacts | Failed to load booking contacts", "error", err.Error())
+		return reservationsRepo.BookingContacts{}, false
+	}
+
+	return contacts, true
+}
+
+// Сообщает владельцам броней, что администратор отменил их брони, и причину отмены.
+// Каждому пользователю — одно письмо.
+func notifyCancelledByAdmin(cancelled []reservationsRepo.AdminReservation, reason string) {
+	byUser := map[string][]reservationsRepo.AdminReservation{}
+	var order []string
+	for _, reservation := range cancelled {
+		if _, ok := byUser[reservation.UserEmail]; !ok {
+			order = append(order, reservation.UserEmail)
+		}
+		byUser[reservation.UserEmail] = append(byUser[reservation.UserEmail], reservation)
+	}
+
+	for _, email := range order {
+		var items strings.Builder
+		for _, reservation := range byUser[email] {
+			guest := ""
+			if reservation.GuestName != nil {
+				guest = fmt.Sprintf(", гость %s", notifications.Escape(*reservation.GuestName))
+			}
+
+			items.WriteString(fmt.Sprintf("<li>Стол %s, %s%s</li>",
+				notifications.Escape(reservation.DeskName),
+				notifications.FormatSlot(reservation.DeskOffice, reservation.DateFrom, reservation.DateTo),
+				guest,
+			))
+		}
+
+		notifications.Send(email, "Бронь отменена администратором", fmt.Sprintf(`
+			<h2>Бронь отменена администратором</h2>
+			<p>Причина: %s</p>
+			<ul>%s</ul>
+		`, notifications.Escape(reason), items.String()))
+	}
+}
@@ -0,0 +1,34 @@
+package reservation
+
+import (
+	"log/slog"
+	"net/http"
+	"place-picker/internal/api/request"
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Занятость зон офиса за день против их лимитов. По умолчанию — на сегодня.
+func GetOccupancyHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	office, ok := loadOffice(c, "GetOccupancyHandler", c.Query("office"))
+	if !ok {
+		return
+	}
+
+	day := c.DefaultQuery("day", office.Day(time.Now()))
+	if _, err := time.Parse(time.DateOnly, day); err != nil {
+		request.RespondFieldErrors(c, request.FieldError{Field: "day", Message: "must be a date in YYYY-MM-DD format"})
+		return
+	}
+
+	zones, err := repo.GetZonesOccupancy(c.Request.Context(), office, day)
+	if err != nil {
+		slog.Error("GetOccupancyHandler | Failed to load zones occupancy", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load zones occupancy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, OccupancyPayload{Office: office.Key, Zones: zones})
+}
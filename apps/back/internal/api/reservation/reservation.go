@@ -5,14 +5,19 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"place-picker/internal/api/request"
+	"place-picker/internal/checkin"
 	reservationsRepo "place-picker/internal/db/repo/reservation"
-	"strings"
+	slotsRepo "place-picker/internal/db/repo/slots"
+	"place-picker/internal/offices"
+	"place-picker/internal/waitlist"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 )
 
-func ReserveDesk(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+func ReserveDesk(c *gin.Context, repo *reservationsRepo.ReservationsRepository, templates *slotsRepo.SlotsRepository) {
 	userId, exists := c.Get("userId")
 	if !exists {
 		slog.Error("ReserveDesk | user id is required")
@@ -21,58 +26,135 @@ func ReserveDesk(c *gin.Context, repo *reservationsRepo.ReservationsRepository)
 	}
 
 	var req CreateReservationRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		slog.Error("ReserveDesk | Unable parse request body", "error", err.Error())
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+	if !request.BindJSON(c, "ReserveDesk", &req) {
 		return
 	}
 
-	const layout = "15:04 02.01.2006"
-	dateFrom, err := time.Parse(layout, req.DateFrom)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dateFrom format"})
+	bookedBy := userId.(string)
+	occupantId := bookedBy
+	if req.UserId != nil {
+		occupantId = *req.UserId
+	}
+
+	office, ok := loadDeskOffice(c, "ReserveDesk", repo, req.DeskId)
+	if !ok {
 		return
 	}
 
-	dateTo, err := time.Parse(layout, req.DateTo)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dateTo format"})
+	if req.SlotTemplateId != nil && !applySlotTemplate(c, templates, office, &req) {
+		return
+	}
+
+	dateFrom, dateTo, fieldErrs := resolveDateTimes(office.Location, req.DateFrom, req.DateTo)
+	if len(fieldErrs) > 0 {
+		request.RespondFieldErrors(c, fieldErrs...)
+		return
+	}
+
+	// dateFrom и dateTo задают первый и последний день брони, а их время — интервал брони в каждый из дней.
+	if office.Day(dateTo) < office.Day(dateFrom) {
+		request.RespondFieldErrors(c, request.FieldError{Field: "dateTo", Message: "must not be before dateFrom"})
 		return
 	}
 
-	if dateTo.Before(dateFrom) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "dateTo cannot be before dateFrom"})
+	if clockOf(dateTo) <= clockOf(dateFrom) {
+		request.RespondFieldErrors(c, request.FieldError{Field: "dateTo", Message: "time of day must be after dateFrom"})
 		return
 	}
 
+	var closedDays map[string]bool
+	if req.SkipClosedDays {
+		var err error
+		closedDays, err = repo.GetClosedDays(c.Request.Context(), office, office.Day(dateFrom), office.Day(dateTo))
+		if err != nil {
+			slog.Error("ReserveDesk | Failed to load closed days", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create reservation"})
+			return
+		}
+	}
+
+	var slots []reservationsRepo.ReservationSlot
 	current := dateFrom
-	for current.Before(dateTo) || current.Equal(dateTo) {
-		dayStart := time.Date(current.Year(), current.Month(), current.Day(), dateFrom.Hour(), dateFrom.Minute(), 0, 0, current.Location())
-		dayEnd := time.Date(current.Year(), current.Month(), current.Day(), dateTo.Hour(), dateTo.Minute(), 0, 0, current.Location())
+	for ; current.Before(dateTo) || current.Equal(dateTo); current = current.AddDate(0, 0, 1) {
+		if req.SkipClosedDays && (isWeekend(current) || closedDays[current.Format(time.DateOnly)]) {
+			continue
+		}
+
+		dayStart := time.Date(current.Year(), current.Month(), current.Day(), dateFrom.Hour(), dateFrom.Minute(), 0, 0, office.Location)
+		dayEnd := time.Date(current.Year(), current.Month(), current.Day(), dateTo.Hour(), dateTo.Minute(), 0, 0, office.Location)
 
-		if dayStart.Hour() < 3 || dayEnd.Hour() > 18 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "reservation must be within working hours (08:00-21:00) UTC"})
+		if !office.Contains(dayStart, dayEnd) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reservation must be within working hours (" + office.Hours() + ")"})
 			return
 		}
 
-		err := repo.CreateReservation(c.Request.Context(), req.DeskId, userId.(string), dayStart, dayEnd)
-		if err != nil {
-			slog.Error("ReserveDesk | Failed to create reservation", "error", err.Error())
-			if strings.Contains(err.Error(), "already has a reservation") || strings.Contains(err.Error(), "already reserved") {
-				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-				return
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create reservation"})
+		slots = append(slots, reservationsRepo.ReservationSlot{DateFrom: dayStart, DateTo: dayEnd})
+	}
+
+	if len(slots) == 0 {
+		request.RespondFieldErrors(c, request.FieldError{Field: "dateTo", Message: "range has no working days"})
+		return
+	}
+
+	result, err := repo.CreateReservations(c.Request.Context(), req.DeskId, occupantId, bookedBy, req.Attendees, slots, req.BestEffort)
+	if err != nil {
+		if errors.Is(err, reservationsRepo.ErrBatchConflict) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":        err.Error(),
+				"conflicts":    result.Conflicts,
+				"alternatives": batchAlternatives(c.Request.Context(), repo, req.DeskId, occupantId, slots, result.Conflicts),
+			})
+			return
+		}
+		if errors.Is(err, reservationsRepo.ErrNotDelegated) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, reservationsRepo.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, reservationsRepo.ErrOutsideWorkingHours) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, reservationsRepo.ErrAttendeesNotAllowed) || errors.Is(err, reservationsRepo.ErrTooManyAttendees) {
+			request.RespondFieldErrors(c, request.FieldError{Field: "attendees", Message: err.Error()})
+			return
+		}
+
+		slog.Error("ReserveDesk | Failed to create reservation", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create reservation"})
+		return
+	}
 
-		current = current.AddDate(0, 0, 1)
+	reserved := make(map[string]bool, len(result.Reserved))
+	for _, day := range result.Reserved {
+		reserved[day] = true
 	}
 
-	slog.Info("ReserveDesk | Reservation created", "userId", userId.(string), "deskId", req.DeskId, "dateFrom", dateFrom, "dateTo", dateTo)
-	c.JSON(http.StatusCreated, gin.H{"message": "reservations created successfully"})
+	var reservedSlots []reservationsRepo.ReservationSlot
+	for _, slot := range slots {
+		if reserved[slot.DateFrom.Format(time.DateOnly)] {
+			reservedSlots = append(reservedSlots, slot)
+		}
+	}
+	notifyBookedOnBehalf(c.Request.Context(), repo, req.DeskId, occupantId, bookedBy, reservedSlots)
+	if result.Status == reservationsRepo.StatusPending {
+		notifyApprovers(c.Request.Context(), repo, req.DeskId, occupantId, reservedSlots)
+	}
+
+	slog.Info("ReserveDesk | Reservation created", "userId", occupantId, "bookedBy", bookedBy, "deskId", req.DeskId, "dateFrom", dateFrom, "dateTo", dateTo, "skipped", len(result.Conflicts))
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "reservations created successfully",
+		"reserved": result.Reserved,
+		"skipped":  result.Conflicts,
+		"status":   result.Status,
+	})
 }
 
+// Возвращает брони пользователя и брони, которые он оформил за других.
+// С параметром userId возвращает брони пользователя, за которого текущий пользователь может бронировать.
 func GetUserReservationsHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
 	userId, exists := c.Get("userId")
 	if !exists {
@@ -81,7 +163,23 @@ func GetUserReservationsHandler(c *gin.Context, repo *reservationsRepo.Reservati
 		return
 	}
 
-	reservations, err := repo.GetUserReservations(c.Request.Context(), userId.(string))
+	targetId := userId.(string)
+	if requested := c.Query("userId"); requested != "" && requested != targetId {
+		allowed, err := repo.CanBookFor(c.Request.Context(), targetId, requested)
+		if err != nil {
+			slog.Error("GetUserReservationsHandler | Failed to check booking permission", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load reservations"})
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": reservationsRepo.ErrNotDelegated.Error()})
+			return
+		}
+
+		targetId = requested
+	}
+
+	reservations, err := repo.GetUserReservations(c.Request.Context(), targetId)
 	if err != nil {
 		slog.Error("GetUserReservationsHandler | Failed to load reservations", "error", err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load reservations"})
@@ -106,22 +204,114 @@ func DeleteReservationHandler(c *gin.Context, repo *reservationsRepo.Reservation
 		return
 	}
 
-	err := repo.DeleteReservation(c.Request.Context(), reservationId, userId.(string))
+	reservation, err := repo.CancelReservation(c.Request.Context(), reservationId, userId.(string))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "reservation not found or not owned by user"})
 			return
 		}
 
-		slog.Error("DeleteReservationHandler | Failed to delete reservation", "error", err.Error())
+		slog.Error("DeleteReservationHandler | Failed to cancel reservation", "error", err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete reservation"})
 		return
 	}
 
-	slog.Info("DeleteReservationHandler | Reservation deleted", "reservationId", reservationId, "userId", userId)
+	waitlist.Wake()
+	notifyCancelledOnBehalf(c.Request.Context(), repo, reservation, userId.(string))
+
+	slog.Info("DeleteReservationHandler | Reservation cancelled", "reservationId", reservationId, "userId", userId)
 	c.JSON(http.StatusOK, gin.H{"message": "reservation deleted successfully"})
 }
 
+// Переносит бронь на другой стол или время без удаления, идентификатор брони сохраняется.
+// Не переданные поля остаются прежними.
+func UpdateReservationHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	reservationId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("UpdateReservationHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req UpdateReservationRequest
+	if !request.BindJSON(c, "UpdateReservationHandler", &req) {
+		return
+	}
+
+	reservation, err := repo.GetReservation(c.Request.Context(), reservationId, userId.(string))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "reservation not found or not owned by user"})
+			return
+		}
+
+		slog.Error("UpdateReservationHandler | Failed to load reservation", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update reservation"})
+		return
+	}
+
+	deskId := reservation.DeskId
+	if req.DeskId != nil {
+		deskId = *req.DeskId
+	}
+
+	office, ok := loadDeskOffice(c, "UpdateReservationHandler", repo, deskId)
+	if !ok {
+		return
+	}
+
+	if req.DateFrom.IsZero() {
+		req.DateFrom = dateTimeValue(reservation.DateFrom)
+	}
+	if req.DateTo.IsZero() {
+		req.DateTo = dateTimeValue(reservation.DateTo)
+	}
+
+	dateFrom, dateTo, ok := parseSingleDayWindow(c, office, req.DateFrom, req.DateTo)
+	if !ok {
+		return
+	}
+
+	err = repo.UpdateReservation(c.Request.Context(), reservationId, userId.(string), deskId, dateFrom, dateTo)
+	if err != nil {
+		if respondPolicyError(c, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "reservation not found or not owned by user"})
+		case errors.Is(err, reservationsRepo.ErrDeskNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, reservationsRepo.ErrDeskAlreadyReserved):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":        err.Error(),
+				"alternatives": loadAlternatives(c.Request.Context(), repo, deskId, userId.(string), dateFrom, dateTo, &reservationId),
+			})
+		case errors.Is(err, reservationsRepo.ErrUserHasReservation),
+			errors.Is(err, reservationsRepo.ErrDeskAssigned),
+			errors.Is(err, reservationsRepo.ErrDeskRestricted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, reservationsRepo.ErrOutsideWorkingHours),
+			errors.Is(err, reservationsRepo.ErrInvalidPeriod):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			slog.Error("UpdateReservationHandler | Failed to update reservation", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update reservation"})
+		}
+		return
+	}
+
+	waitlist.Wake()
+	reservation.DeskId = deskId
+	notifyMovedOnBehalf(c.Request.Context(), repo, reservation, userId.(string), reservationsRepo.ReservationSlot{DateFrom: dateFrom, DateTo: dateTo})
+
+	slog.Info("UpdateReservationHandler | Reservation updated", "reservationId", reservationId, "userId", userId, "deskId", deskId)
+	c.JSON(http.StatusOK, gin.H{"message": "reservation updated successfully"})
+}
+
 func DeleteAllUserReservationsHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
 	userId, exists := c.Get("userId")
 	if !exists {
@@ -130,18 +320,186 @@ func DeleteAllUserReservationsHandler(c *gin.Context, repo *reservationsRepo.Res
 		return
 	}
 
-	err := repo.DeleteAllUserReservations(c.Request.Context(), userId.(string))
+	err := repo.CancelAllUserReservations(c.Request.Context(), userId.(string))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "no reservations found for user"})
 			return
 		}
 
-		slog.Error("DeleteAllUserReservationsHandler | Failed to delete reservations", "error", err.Error())
+		slog.Error("DeleteAllUserReservationsHandler | Failed to cancel reservations", "error", err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user reservations"})
 		return
 	}
 
-	slog.Info("DeleteAllUserReservationsHandler | All reservations deleted", "userId", userId)
+	waitlist.Wake()
+
+	slog.Info("DeleteAllUserReservationsHandler | All reservations cancelled", "userId", userId)
 	c.JSON(http.StatusOK, gin.H{"message": "all reservations deleted successfully"})
 }
+
+func CheckInHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	reservationId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("CheckInHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req CheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("CheckInHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	reservation, err := repo.GetReservation(c.Request.Context(), reservationId, userId.(string))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "reservation not found or not owned by user"})
+			return
+		}
+
+		slog.Error("CheckInHandler | Failed to load reservation", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check in"})
+		return
+	}
+
+	// Отметиться может только тот, кто занимает стол, а не тот, кто оформил бронь.
+	if reservation.UserId != userId.(string) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the user occupying the desk can check in"})
+		return
+	}
+
+	if reservation.Status != reservationsRepo.StatusActive {
+		c.JSON(http.StatusConflict, gin.H{"error": reservationsRepo.ErrReservationNotActive.Error()})
+		return
+	}
+
+	if !checkin.VerifyDeskSignature(reservation.DeskId, req.Signature) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "check-in code does not match the reserved desk"})
+		return
+	}
+
+	windowStart := reservation.DateFrom.Add(-viper.GetDuration("checkin.window_before"))
+	windowEnd := reservation.DateFrom.Add(viper.GetDuration("checkin.grace_period"))
+	now := time.Now()
+	if now.Before(windowStart) || now.After(windowEnd) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":       "check-in is not available at this time",
+			"windowStart": windowStart,
+			"windowEnd":   windowEnd,
+		})
+		return
+	}
+
+	if err := repo.CheckIn(c.Request.Context(), reservation.Id); err != nil {
+		if errors.Is(err, reservationsRepo.ErrAlreadyCheckedIn) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+
+		slog.Error("CheckInHandler | Failed to check in", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check in"})
+		return
+	}
+
+	slog.Info("CheckInHandler | Reservation checked in", "reservationId", reservation.Id, "userId", userId)
+	c.JSON(http.StatusOK, gin.H{"message": "checked in successfully"})
+}
+
+// Разбирает начало и конец брони в часовом поясе офиса и собирает ошибки обоих полей.
+func resolveDateTimes(location *time.Location, rawFrom, rawTo DateTimeInput) (time.Time, time.Time, []request.FieldError) {
+	var fieldErrs []request.FieldError
+
+	dateFrom, err := rawFrom.Resolve(location)
+	if err != nil {
+		fieldErrs = append(fieldErrs, request.FieldError{Field: "dateFrom", Message: err.Error()})
+	}
+
+	dateTo, err := rawTo.Resolve(location)
+	if err != nil {
+		fieldErrs = append(fieldErrs, request.FieldError{Field: "dateTo", Message: err.Error()})
+	}
+
+	return dateFrom, dateTo, fieldErrs
+}
+
+// Заменяет день и последний день брони на dateFrom и dateTo по интервалу шаблона.
+// Шаблон должен принадлежать офису стола. В случае ошибки сам отправляет ответ клиенту.
+func applySlotTemplate(c *gin.Context, templates *slotsRepo.SlotsRepository, office offices.Office, req *CreateReservationRequest) bool {
+	if !req.DateFrom.IsZero() || !req.DateTo.IsZero() {
+		request.RespondFieldErrors(c, request.FieldError{Field: "slotTemplateId", Message: "must not be combined with dateFrom and dateTo"})
+		return false
+	}
+	if req.Day == nil {
+		request.RespondFieldErrors(c, request.FieldError{Field: "day", Message: "is required with slotTemplateId"})
+		return false
+	}
+
+	template, err := templates.GetTemplate(c.Request.Context(), *req.SlotTemplateId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			request.RespondFieldErrors(c, request.FieldError{Field: "slotTemplateId", Message: "slot template not found"})
+			return false
+		}
+
+		slog.Error("ReserveDesk | Failed to load slot template", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create reservation"})
+		return false
+	}
+
+	if template.Office != office.Key {
+		request.RespondFieldErrors(c, request.FieldError{Field: "slotTemplateId", Message: "slot template belongs to another office"})
+		return false
+	}
+
+	lastDay := *req.Day
+	if req.LastDay != nil {
+		lastDay = *req.LastDay
+	}
+
+	var fieldErrs []request.FieldError
+	dateFrom, _, err := template.Bounds(office.Location, *req.Day)
+	if err != nil {
+		fieldErrs = append(fieldErrs, request.FieldError{Field: "day", Message: "must be a date in YYYY-MM-DD format"})
+	}
+	_, dateTo, err := template.Bounds(office.Location, lastDay)
+	if err != nil {
+		fieldErrs = append(fieldErrs, request.FieldError{Field: "lastDay", Message: "must be a date in YYYY-MM-DD format"})
+	}
+	if len(fieldErrs) > 0 {
+		request.RespondFieldErrors(c, fieldErrs...)
+		return false
+	}
+
+	req.DateFrom, req.DateTo = dateTimeValue(dateFrom), dateTimeValue(dateTo)
+	return true
+}
+
+func clockOf(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// Загружает офис стола, по которому проверяются рабочие часы. В случае ошибки сам отправляет ответ клиенту.
+func loadDeskOffice(c *gin.Context, handler string, repo *reservationsRepo.ReservationsRepository, deskId string) (offices.Office, bool) {
+	office, err := repo.GetDeskOffice(c.Request.Context(), deskId)
+	if err != nil {
+		if errors.Is(err, reservationsRepo.ErrDeskNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return offices.Office{}, false
+		}
+
+		slog.Error(handler+" | Failed to load desk office", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load desk office"})
+		return offices.Office{}, false
+	}
+
+	return office, true
+}
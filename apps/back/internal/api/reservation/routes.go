@@ -6,33 +6,235 @@ import (
 	"github.com/gin-gonic/gin"
 
 	reservationsRepo "place-picker/internal/db/repo/reservation"
+	slotsRepo "place-picker/internal/db/repo/slots"
+	userRepo "place-picker/internal/db/repo/user"
+	adminMiddleware "place-picker/internal/server/middleware/admin"
 )
 
 type (
 	Reservation struct {
 		ReservationsRepo *reservationsRepo.ReservationsRepository
+		SlotsRepo        *slotsRepo.SlotsRepository
+		UserRepo         *userRepo.UserRepository
 	}
 
 	CreateReservationRequest struct {
-		DeskId   string `json:"deskId" binding:"required"`
-		DateFrom string `json:"dateFrom" binding:"required"`
-		DateTo   string `json:"dateTo" binding:"required"`
+		DeskId string `json:"deskId" binding:"required"`
+		// Пользователь, за которого оформляется бронь. По умолчанию — текущий пользователь.
+		UserId   *string       `json:"userId"`
+		DateFrom DateTimeInput `json:"dateFrom"`
+		DateTo   DateTimeInput `json:"dateTo"`
+		// Шаблон интервала вместо dateFrom и dateTo: бронь на интервал шаблона с day по lastDay включительно.
+		SlotTemplateId *string `json:"slotTemplateId"`
+		Day            *string `json:"day"`
+		LastDay        *string `json:"lastDay"`
+		// Бронирует свободные дни и пропускает занятые вместо отказа всего запроса.
+		BestEffort bool `json:"bestEffort"`
+		// Участники брони переговорной.
+		Attendees []string `json:"attendees" binding:"omitempty,max=50,dive,required"`
+		// Пропускает выходные и дни закрытия офиса вместо конфликта по ним.
+		SkipClosedDays bool `json:"skipClosedDays"`
+	}
+
+	UpdateReservationRequest struct {
+		DeskId   *string       `json:"deskId"`
+		DateFrom DateTimeInput `json:"dateFrom"`
+		DateTo   DateTimeInput `json:"dateTo"`
+	}
+
+	SeriesRequest struct {
+		DeskId string `json:"deskId" binding:"required"`
+		// Пользователь, за которого оформляется серия. Учитывается только при создании серии.
+		UserId   *string       `json:"userId"`
+		DateFrom DateTimeInput `json:"dateFrom"`
+		DateTo   DateTimeInput `json:"dateTo"`
+		RRule    string        `json:"rrule" binding:"required"`
+		ExDates  []string      `json:"exdates"`
+	}
+
+	// Групповая бронь на один интервал. Без zoneId зона подбирается среди зон офиса office
+	// или офиса по умолчанию.
+	GroupReservationRequest struct {
+		Attendees []string      `json:"attendees" binding:"required,min=1,max=50,dive,required"`
+		ZoneId    *string       `json:"zoneId"`
+		Office    *string       `json:"office"`
+		DateFrom  DateTimeInput `json:"dateFrom"`
+		DateTo    DateTimeInput `json:"dateTo"`
+	}
+
+	WaitlistRequest struct {
+		DeskId   *string       `json:"deskId"`
+		ZoneId   *string       `json:"zoneId"`
+		DateFrom DateTimeInput `json:"dateFrom"`
+		DateTo   DateTimeInput `json:"dateTo"`
+		// offer — предложить бронь с подтверждением, auto_book — забронировать сразу.
+		Mode string `json:"mode" binding:"omitempty,oneof=offer auto_book"`
+	}
+
+	WaitlistPayload struct {
+		Entries []reservationsRepo.WaitlistEntry `json:"entries"`
+	}
+
+	SeriesPayload struct {
+		Series []reservationsRepo.Series `json:"series"`
+	}
+
+	CheckInRequest struct {
+		Signature string `json:"signature" binding:"required"`
+	}
+
+	// Бронь стола для гостя. Время указывается по местному времени офиса стола.
+	GuestReservationRequest struct {
+		DeskId   string        `json:"deskId" binding:"required"`
+		Name     string        `json:"name" binding:"required,max=200"`
+		Email    *string       `json:"email" binding:"omitempty,email"`
+		Company  *string       `json:"company" binding:"omitempty,max=200"`
+		DateFrom DateTimeInput `json:"dateFrom"`
+		DateTo   DateTimeInput `json:"dateTo"`
+	}
+
+	VisitorsPayload struct {
+		Date     string                     `json:"date"`
+		Office   string                     `json:"office"`
+		Visitors []reservationsRepo.Visitor `json:"visitors"`
+	}
+
+	// Решение подтверждающего по брони. Комментарий отправляется пользователю вместе с решением.
+	DecisionRequest struct {
+		Comment *string `json:"comment" binding:"omitempty,max=500"`
+	}
+
+	PendingApprovalsPayload struct {
+		Reservations []reservationsRepo.PendingReservation `json:"reservations"`
 	}
 
 	ReservationsPayload struct {
 		Reservations []reservationsRepo.UserReservation `json:"reservations"`
 	}
+
+	// Отбор броней администратором. Даты — YYYY-MM-DD по времени офиса стола deskId
+	// или офиса по умолчанию, dateTo включительно.
+	ReservationFilterInput struct {
+		DeskId   *string `json:"deskId" form:"deskId" binding:"omitempty,uuid"`
+		UserId   *string `json:"userId" form:"userId" binding:"omitempty,uuid"`
+		DateFrom string  `json:"dateFrom" form:"dateFrom"`
+		DateTo   string  `json:"dateTo" form:"dateTo"`
+	}
+
+	AdminReservationsQuery struct {
+		ReservationFilterInput
+		Status []string `form:"status" binding:"omitempty,dive,oneof=active pending declined expired cancelled completed no_show"`
+	}
+
+	CancelReservationRequest struct {
+		Reason string `json:"reason" binding:"required,max=500"`
+	}
+
+	// Массовая отмена: отменяются брони, подходящие под все переданные условия.
+	BulkCancelRequest struct {
+		ReservationFilterInput
+		ReservationIds []string `json:"reservationIds" binding:"omitempty,max=500,dive,uuid"`
+		Reason         string   `json:"reason" binding:"required,max=500"`
+	}
+
+	// Предложение обменять свою бронь reservationId на пересекающуюся с ней бронь другого пользователя.
+	SwapRequest struct {
+		ReservationId       string `json:"reservationId" binding:"required"`
+		TargetReservationId string `json:"targetReservationId" binding:"required"`
+	}
+
+	SwapsPayload struct {
+		Swaps []reservationsRepo.SwapRequest `json:"swaps"`
+	}
+
+	// Лотерея на день офиса. Без office — офис по умолчанию. cutoffAt — окончание приема заявок,
+	// по местному времени офиса, не позже начала дня лотереи.
+	CreateLotteryRequest struct {
+		Office   *string       `json:"office"`
+		Day      string        `json:"day" binding:"required"`
+		CutoffAt DateTimeInput `json:"cutoffAt"`
+	}
+
+	// Стол или любой стол зоны.
+	LotteryPreferenceInput struct {
+		DeskId *string `json:"deskId" binding:"omitempty,uuid"`
+		ZoneId *string `json:"zoneId" binding:"omitempty,uuid"`
+	}
+
+	// Предпочтения заявки на лотерею, первое — самое желательное.
+	LotteryPreferencesRequest struct {
+		Preferences []LotteryPreferenceInput `json:"preferences" binding:"required,min=1,max=5,dive"`
+	}
+
+	LotteriesPayload struct {
+		Office    string                     `json:"office"`
+		Lotteries []reservationsRepo.Lottery `json:"lotteries"`
+	}
+
+	OccupancyPayload struct {
+		Office string                           `json:"office"`
+		Zones  []reservationsRepo.ZoneOccupancy `json:"zones"`
+	}
+
+	LotteryDrawPayload struct {
+		Lottery  reservationsRepo.Lottery          `json:"lottery"`
+		Requests []reservationsRepo.LotteryRequest `json:"requests"`
+	}
+
+	AdminReservationsPayload struct {
+		Reservations []reservationsRepo.AdminReservation `json:"reservations"`
+	}
 )
 
 func New(db *sql.DB) *Reservation {
-	return &Reservation{ReservationsRepo: reservationsRepo.NewReservationsRepository(db)}
+	return &Reservation{
+		ReservationsRepo: reservationsRepo.NewReservationsRepository(db),
+		SlotsRepo:        slotsRepo.NewSlotsRepository(db),
+		UserRepo:         userRepo.NewUserRepository(db),
+	}
 }
 
 func (d *Reservation) RegisterPublicRoutes(r *gin.RouterGroup) {}
 
 func (d *Reservation) RegisterPrivateRoutes(r *gin.RouterGroup) {
-	r.POST("/reservation", func(c *gin.Context) { ReserveDesk(c, d.ReservationsRepo) })
+	reception := adminMiddleware.ReceptionMiddleware(d.UserRepo)
+	admin := adminMiddleware.AdminMiddleware(d.UserRepo)
+
+	r.POST("/reservation", func(c *gin.Context) { ReserveDesk(c, d.ReservationsRepo, d.SlotsRepo) })
 	r.GET("/reservation", func(c *gin.Context) { GetUserReservationsHandler(c, d.ReservationsRepo) })
+	r.GET("/reservation/history", func(c *gin.Context) { GetReservationHistoryHandler(c, d.ReservationsRepo) })
+	r.PATCH("/reservation/:id", func(c *gin.Context) { UpdateReservationHandler(c, d.ReservationsRepo) })
 	r.DELETE("/reservation/:id", func(c *gin.Context) { DeleteReservationHandler(c, d.ReservationsRepo) })
+	r.POST("/reservation/:id/checkin", func(c *gin.Context) { CheckInHandler(c, d.ReservationsRepo) })
+	r.GET("/reservation/series", func(c *gin.Context) { GetUserSeriesHandler(c, d.ReservationsRepo) })
+	r.POST("/reservation/series", func(c *gin.Context) { CreateSeriesHandler(c, d.ReservationsRepo) })
+	r.PUT("/reservation/series/:id", func(c *gin.Context) { UpdateSeriesHandler(c, d.ReservationsRepo) })
+	r.DELETE("/reservation/series/:id", func(c *gin.Context) { DeleteSeriesHandler(c, d.ReservationsRepo) })
+	r.PUT("/reservation/series/:id/occurrences/:reservationId", func(c *gin.Context) { UpdateOccurrenceHandler(c, d.ReservationsRepo) })
+	r.POST("/reservation/guest", func(c *gin.Context) { CreateGuestReservationHandler(c, d.ReservationsRepo) })
+	r.GET("/reservation/visitors", reception, func(c *gin.Context) { GetVisitorsHandler(c, d.ReservationsRepo) })
+	r.POST("/reservation/group", func(c *gin.Context) { CreateGroupReservationHandler(c, d.ReservationsRepo) })
+	r.GET("/reservation/waitlist", func(c *gin.Context) { GetUserWaitlistHandler(c, d.ReservationsRepo) })
+	r.POST("/reservation/waitlist", func(c *gin.Context) { JoinWaitlistHandler(c, d.ReservationsRepo) })
+	r.POST("/reservation/waitlist/:id/confirm", func(c *gin.Context) { ConfirmWaitlistOfferHandler(c, d.ReservationsRepo) })
+	r.DELETE("/reservation/waitlist/:id", func(c *gin.Context) { LeaveWaitlistHandler(c, d.ReservationsRepo) })
+	r.GET("/reservation/swaps", func(c *gin.Context) { GetUserSwapsHandler(c, d.ReservationsRepo) })
+	r.POST("/reservation/swaps", func(c *gin.Context) { CreateSwapHandler(c, d.ReservationsRepo) })
+	r.POST("/reservation/swaps/:id/accept", func(c *gin.Context) { DecideSwapHandler(c, d.ReservationsRepo, true) })
+	r.POST("/reservation/swaps/:id/decline", func(c *gin.Context) { DecideSwapHandler(c, d.ReservationsRepo, false) })
+	r.DELETE("/reservation/swaps/:id", func(c *gin.Context) { CancelSwapHandler(c, d.ReservationsRepo) })
+	r.GET("/reservation/occupancy", func(c *gin.Context) { GetOccupancyHandler(c, d.ReservationsRepo) })
+	r.GET("/reservation/lotteries", func(c *gin.Context) { GetLotteriesHandler(c, d.ReservationsRepo) })
+	r.POST("/reservation/lotteries", admin, func(c *gin.Context) { CreateLotteryHandler(c, d.ReservationsRepo) })
+	r.DELETE("/reservation/lotteries/:id", admin, func(c *gin.Context) { DeleteLotteryHandler(c, d.ReservationsRepo) })
+	r.GET("/reservation/lotteries/:id/draw", admin, func(c *gin.Context) { GetLotteryDrawHandler(c, d.ReservationsRepo) })
+	r.PUT("/reservation/lotteries/:id/request", func(c *gin.Context) { SubmitLotteryRequestHandler(c, d.ReservationsRepo) })
+	r.DELETE("/reservation/lotteries/:id/request", func(c *gin.Context) { WithdrawLotteryRequestHandler(c, d.ReservationsRepo) })
+	r.GET("/reservation/approvals", func(c *gin.Context) { GetPendingApprovalsHandler(c, d.ReservationsRepo) })
+	r.POST("/reservation/:id/approve", func(c *gin.Context) { DecideReservationHandler(c, d.ReservationsRepo, true) })
+	r.POST("/reservation/:id/decline", func(c *gin.Context) { DecideReservationHandler(c, d.ReservationsRepo, false) })
 	r.DELETE("/reservation/all", func(c *gin.Context) { DeleteAllUserReservationsHandler(c, d.ReservationsRepo) })
+	r.GET("/reservation/admin", admin, func(c *gin.Context) { GetAdminReservationsHandler(c, d.ReservationsRepo) })
+	r.POST("/reservation/admin/cancel", admin, func(c *gin.Context) { BulkCancelReservationsHandler(c, d.ReservationsRepo) })
+	r.POST("/reservation/admin/:id/cancel", admin, func(c *gin.Context) { AdminCancelReservationHandler(c, d.ReservationsRepo) })
 }
@@ -0,0 +1,302 @@
+package reservation
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"place-picker/internal/api/request"
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"place-picker/internal/offices"
+	"place-picker/internal/recurrence"
+	"place-picker/internal/waitlist"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+func CreateSeriesHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("CreateSeriesHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req SeriesRequest
+	if !request.BindJSON(c, "CreateSeriesHandler", &req) {
+		return
+	}
+
+	series, occurrences, ok := buildSeries(c, repo, "CreateSeriesHandler", req, time.Now())
+	if !ok {
+		return
+	}
+	series.BookedBy = userId.(string)
+	series.UserId = series.BookedBy
+	if req.UserId != nil {
+		series.UserId = *req.UserId
+	}
+
+	seriesId, err := repo.CreateSeries(c.Request.Context(), series, occurrences)
+	if err != nil {
+		respondSeriesError(c, "CreateSeriesHandler", err)
+		return
+	}
+
+	notifySeriesOnBehalf(c.Request.Context(), repo, series, len(occurrences))
+
+	slog.Info("CreateSeriesHandler | Reservation series created", "seriesId", seriesId, "userId", series.UserId, "occurrences", len(occurrences))
+	c.JSON(http.StatusCreated, gin.H{"id": seriesId, "occurrences": len(occurrences)})
+}
+
+func GetUserSeriesHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("GetUserSeriesHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	series, err := repo.GetUserSeries(c.Request.Context(), userId.(string))
+	if err != nil {
+		slog.Error("GetUserSeriesHandler | Failed to load reservation series", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load reservation series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SeriesPayload{Series: series})
+}
+
+// Изменяет всю серию: будущие вхождения пересоздаются по новому правилу.
+func UpdateSeriesHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	seriesId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("UpdateSeriesHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req SeriesRequest
+	if !request.BindJSON(c, "UpdateSeriesHandler", &req) {
+		return
+	}
+
+	now := time.Now()
+	series, occurrences, ok := buildSeries(c, repo, "UpdateSeriesHandler", req, now)
+	if !ok {
+		return
+	}
+	series.Id = seriesId
+
+	if err := repo.ReplaceSeries(c.Request.Context(), series, userId.(string), occurrences, now); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "reservation series not found"})
+			return
+		}
+
+		respondSeriesError(c, "UpdateSeriesHandler", err)
+		return
+	}
+
+	slog.Info("UpdateSeriesHandler | Reservation series updated", "seriesId", seriesId, "occurrences", len(occurrences))
+	c.JSON(http.StatusOK, gin.H{"message": "reservation series updated successfully", "occurrences": len(occurrences)})
+}
+
+func DeleteSeriesHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	seriesId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("DeleteSeriesHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := repo.DeleteSeries(c.Request.Context(), seriesId, userId.(string)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "reservation series not found"})
+			return
+		}
+
+		slog.Error("DeleteSeriesHandler | Failed to delete reservation series", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete reservation series"})
+		return
+	}
+
+	waitlist.Wake()
+
+	slog.Info("DeleteSeriesHandler | Reservation series deleted", "seriesId", seriesId, "userId", userId)
+	c.JSON(http.StatusOK, gin.H{"message": "reservation series deleted successfully"})
+}
+
+// Изменяет одно вхождение серии. Остальные вхождения не меняются.
+func UpdateOccurrenceHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	seriesId := c.Param("id")
+	reservationId := c.Param("reservationId")
+
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("UpdateOccurrenceHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req CreateReservationRequest
+	if !request.BindJSON(c, "UpdateOccurrenceHandler", &req) {
+		return
+	}
+
+	office, ok := loadDeskOffice(c, "UpdateOccurrenceHandler", repo, req.DeskId)
+	if !ok {
+		return
+	}
+
+	dateFrom, dateTo, ok := parseSingleDayWindow(c, office, req.DateFrom, req.DateTo)
+	if !ok {
+		return
+	}
+
+	reservation, err := repo.GetReservation(c.Request.Context(), reservationId, userId.(string))
+	if err != nil || reservation.SeriesId == nil || *reservation.SeriesId != seriesId {
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("UpdateOccurrenceHandler | Failed to load reservation", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update occurrence"})
+			return
+		}
+
+		c.JSON(http.StatusNotFound, gin.H{"error": "occurrence not found"})
+		return
+	}
+
+	if err := repo.UpdateReservation(c.Request.Context(), reservationId, userId.(string), req.DeskId, dateFrom, dateTo); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "occurrence not found"})
+			return
+		}
+
+		respondSeriesError(c, "UpdateOccurrenceHandler", err)
+		return
+	}
+
+	waitlist.Wake()
+
+	slog.Info("UpdateOccurrenceHandler | Occurrence updated", "seriesId", seriesId, "reservationId", reservationId)
+	c.JSON(http.StatusOK, gin.H{"message": "occurrence updated successfully"})
+}
+
+// Проверяет запрос серии и разворачивает правило в вхождения от now до горизонта планирования.
+// В случае ошибки сам отправляет ответ клиенту.
+func buildSeries(c *gin.Context, repo *reservationsRepo.ReservationsRepository, handler string, req SeriesRequest, now time.Time) (reservationsRepo.Series, []reservationsRepo.ReservationSlot, bool) {
+	office, ok := loadDeskOffice(c, handler, repo, req.DeskId)
+	if !ok {
+		return reservationsRepo.Series{}, nil, false
+	}
+
+	dateFrom, dateTo, ok := parseSingleDayWindow(c, office, req.DateFrom, req.DateTo)
+	if !ok {
+		return reservationsRepo.Series{}, nil, false
+	}
+
+	rule, err := recurrence.ParseRule(req.RRule, dateFrom)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return reservationsRepo.Series{}, nil, false
+	}
+
+	exdates, err := recurrence.ParseExDates(req.ExDates)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return reservationsRepo.Series{}, nil, false
+	}
+
+	horizonEnd := now.Add(viper.GetDuration("recurrence.horizon"))
+	duration := dateTo.Sub(dateFrom)
+
+	var occurrences []reservationsRepo.ReservationSlot
+	for _, start := range recurrence.Occurrences(rule, exdates, now, horizonEnd) {
+		occurrences = append(occurrences, reservationsRepo.ReservationSlot{DateFrom: start, DateTo: start.Add(duration)})
+	}
+
+	if len(occurrences) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "recurrence rule has no occurrences within the booking horizon"})
+		return reservationsRepo.Series{}, nil, false
+	}
+
+	series := reservationsRepo.Series{
+		DeskId:        req.DeskId,
+		RRule:         rule.String(),
+		ExDates:       exdates,
+		DateFrom:      dateFrom,
+		DateTo:        dateTo,
+		ExpandedUntil: horizonEnd,
+	}
+
+	return series, occurrences, true
+}
+
+// Разбирает начало и конец брони, которая должна укладываться в рабочие часы одного дня офиса.
+// В случае ошибки сам отправляет ответ клиенту.
+func parseSingleDayWindow(c *gin.Context, office offices.Office, rawFrom, rawTo DateTimeInput) (time.Time, time.Time, bool) {
+	dateFrom, dateTo, fieldErrs := resolveDateTimes(office.Location, rawFrom, rawTo)
+	if len(fieldErrs) > 0 {
+		request.RespondFieldErrors(c, fieldErrs...)
+		return time.Time{}, time.Time{}, false
+	}
+
+	if office.Day(dateFrom) != office.Day(dateTo) {
+		request.RespondFieldErrors(c, request.FieldError{Field: "dateTo", Message: "must be on the same day as dateFrom"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	if !dateTo.After(dateFrom) {
+		request.RespondFieldErrors(c, request.FieldError{Field: "dateTo", Message: "must be after dateFrom"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	if !office.Contains(dateFrom, dateTo) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reservation must be within working hours (" + office.Hours() + ")"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	return dateFrom, dateTo, true
+}
+
+func respondSeriesError(c *gin.Context, handler string, err error) {
+	if respondPolicyError(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, reservationsRepo.ErrUserHasReservation),
+		errors.Is(err, reservationsRepo.ErrDeskAlreadyReserved),
+		errors.Is(err, reservationsRepo.ErrDeskAssigned),
+		errors.Is(err, reservationsRepo.ErrDeskRestricted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, reservationsRepo.ErrNotDelegated):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, reservationsRepo.ErrDeskNotFound),
+		errors.Is(err, reservationsRepo.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, reservationsRepo.ErrOutsideWorkingHours),
+		errors.Is(err, reservationsRepo.ErrInvalidPeriod):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		slog.Error(handler+" | Failed to save reservation series", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save reservation series"})
+	}
+}
+
+// Отвечает 422 с кодом нарушенного правила бронирования. Возвращает false, если ошибка не связана с правилами.
+func respondPolicyError(c *gin.Context, err error) bool {
+	var policyErr *reservationsRepo.PolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": policyErr.Message, "code": policyErr.Code})
+	return true
+}
@@ -0,0 +1,145 @@
+package reservation
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"place-picker/internal/api/request"
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"place-picker/internal/swaps"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// Предложения обмена столами, которые сделал пользователь или которые сделаны ему.
+func GetUserSwapsHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("GetUserSwapsHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	list, err := repo.GetUserSwaps(c.Request.Context(), userId.(string))
+	if err != nil {
+		slog.Error("GetUserSwapsHandler | Failed to load swap requests", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load swap requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SwapsPayload{Swaps: list})
+}
+
+// Предлагает владельцу другой брони обменяться столами и уведомляет его.
+func CreateSwapHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("CreateSwapHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req SwapRequest
+	if !request.BindJSON(c, "CreateSwapHandler", &req) {
+		return
+	}
+
+	swap, err := repo.CreateSwapRequest(c.Request.Context(), userId.(string), req.ReservationId, req.TargetReservationId, viper.GetDuration("swaps.request_ttl"))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "reservation not found or not owned by user"})
+		case errors.Is(err, reservationsRepo.ErrSwapTargetNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, reservationsRepo.ErrSwapSameUser),
+			errors.Is(err, reservationsRepo.ErrSwapNotOverlapping),
+			errors.Is(err, reservationsRepo.ErrSwapTypeMismatch):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, reservationsRepo.ErrSwapUnavailable),
+			errors.Is(err, reservationsRepo.ErrSwapExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			slog.Error("CreateSwapHandler | Failed to create swap request", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create swap request"})
+		}
+		return
+	}
+
+	swaps.Notify(swap)
+
+	slog.Info("CreateSwapHandler | Swap requested", "swapId", swap.Id, "userId", userId, "targetId", swap.Target.UserId)
+	c.JSON(http.StatusCreated, swap)
+}
+
+// Принимает или отклоняет предложение обмена. При согласии брони меняются столами.
+func DecideSwapHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository, accept bool) {
+	swapId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("DecideSwapHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	swap, err := repo.DecideSwap(c.Request.Context(), swapId, userId.(string), accept)
+	if err != nil {
+		if respondPolicyError(c, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "swap request not found"})
+		case errors.Is(err, reservationsRepo.ErrSwapNotPending),
+			errors.Is(err, reservationsRepo.ErrSwapUnavailable),
+			errors.Is(err, reservationsRepo.ErrDeskAlreadyReserved),
+			errors.Is(err, reservationsRepo.ErrUserHasReservation),
+			errors.Is(err, reservationsRepo.ErrDeskAssigned),
+			errors.Is(err, reservationsRepo.ErrDeskRestricted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, reservationsRepo.ErrOutsideWorkingHours):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			slog.Error("DecideSwapHandler | Failed to decide swap request", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to decide swap request"})
+		}
+		return
+	}
+
+	swaps.Notify(swap)
+
+	slog.Info("DecideSwapHandler | Swap decided", "swapId", swapId, "userId", userId, "status", swap.Status)
+	c.JSON(http.StatusOK, swap)
+}
+
+// Отзывает ожидающее предложение обмена.
+func CancelSwapHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	swapId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("CancelSwapHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	swap, err := repo.CancelSwapRequest(c.Request.Context(), swapId, userId.(string))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "pending swap request not found"})
+			return
+		}
+
+		slog.Error("CancelSwapHandler | Failed to cancel swap request", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel swap request"})
+		return
+	}
+
+	swaps.Notify(swap)
+
+	slog.Info("CancelSwapHandler | Swap cancelled", "swapId", swapId, "userId", userId)
+	c.JSON(http.StatusOK, gin.H{"message": "swap request cancelled successfully"})
+}
@@ -0,0 +1,170 @@
+package reservation
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"place-picker/internal/api/request"
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"place-picker/internal/offices"
+	"place-picker/internal/waitlist"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Добавляет пользователя в очередь ожидания на стол или на любой стол зоны.
+func JoinWaitlistHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("JoinWaitlistHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req WaitlistRequest
+	if !request.BindJSON(c, "JoinWaitlistHandler", &req) {
+		return
+	}
+
+	if (req.DeskId == nil) == (req.ZoneId == nil) {
+		request.RespondFieldErrors(c, request.FieldError{Field: "deskId", Message: "exactly one of deskId and zoneId is required"})
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = reservationsRepo.WaitlistModeOffer
+	}
+
+	var (
+		office offices.Office
+		err    error
+	)
+	if req.DeskId != nil {
+		office, err = repo.GetDeskOffice(c.Request.Context(), *req.DeskId)
+	} else {
+		office, err = repo.GetZoneOffice(c.Request.Context(), *req.ZoneId)
+	}
+	if err != nil {
+		if errors.Is(err, reservationsRepo.ErrDeskNotFound) || errors.Is(err, reservationsRepo.ErrZoneNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		slog.Error("JoinWaitlistHandler | Failed to load office", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to join waitlist"})
+		return
+	}
+
+	dateFrom, dateTo, ok := parseSingleDayWindow(c, office, req.DateFrom, req.DateTo)
+	if !ok {
+		return
+	}
+
+	if !dateFrom.After(time.Now()) {
+		request.RespondFieldErrors(c, request.FieldError{Field: "dateFrom", Message: "must be in the future"})
+		return
+	}
+
+	entry := reservationsRepo.WaitlistEntry{
+		UserId:   userId.(string),
+		DeskId:   req.DeskId,
+		ZoneId:   req.ZoneId,
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+		Mode:     req.Mode,
+	}
+
+	entryId, err := repo.CreateWaitlistEntry(c.Request.Context(), entry)
+	if err != nil {
+		switch {
+		case errors.Is(err, reservationsRepo.ErrAlreadyInWaitlist):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, reservationsRepo.ErrDeskNotFound), errors.Is(err, reservationsRepo.ErrZoneNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			slog.Error("JoinWaitlistHandler | Failed to create waitlist entry", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to join waitlist"})
+		}
+		return
+	}
+
+	// Стол мог освободиться, пока пользователь заполнял запрос.
+	waitlist.Wake()
+
+	slog.Info("JoinWaitlistHandler | User joined waitlist", "entryId", entryId, "userId", entry.UserId)
+	c.JSON(http.StatusCreated, gin.H{"id": entryId})
+}
+
+func GetUserWaitlistHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("GetUserWaitlistHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	entries, err := repo.GetUserWaitlist(c.Request.Context(), userId.(string))
+	if err != nil {
+		slog.Error("GetUserWaitlistHandler | Failed to load waitlist", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, WaitlistPayload{Entries: entries})
+}
+
+// Подтверждает бронь, предложенную из очереди ожидания.
+func ConfirmWaitlistOfferHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	entryId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("ConfirmWaitlistOfferHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := repo.ConfirmWaitlistOffer(c.Request.Context(), entryId, userId.(string)); err != nil {
+		if errors.Is(err, reservationsRepo.ErrOfferNotAvailable) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+
+		slog.Error("ConfirmWaitlistOfferHandler | Failed to confirm offer", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to confirm offer"})
+		return
+	}
+
+	slog.Info("ConfirmWaitlistOfferHandler | Waitlist offer confirmed", "entryId", entryId, "userId", userId)
+	c.JSON(http.StatusOK, gin.H{"message": "reservation confirmed successfully"})
+}
+
+// Удаляет запись из очереди. Для предложенной брони это отказ: стол переходит следующему в очереди.
+func LeaveWaitlistHandler(c *gin.Context, repo *reservationsRepo.ReservationsRepository) {
+	entryId := c.Param("id")
+
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("LeaveWaitlistHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := repo.DeleteWaitlistEntry(c.Request.Context(), entryId, userId.(string)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "waitlist entry not found"})
+			return
+		}
+
+		slog.Error("LeaveWaitlistHandler | Failed to delete waitlist entry", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to leave waitlist"})
+		return
+	}
+
+	waitlist.Wake()
+
+	slog.Info("LeaveWaitlistHandler | User left waitlist", "entryId", entryId, "userId", userId)
+	c.JSON(http.StatusOK, gin.H{"message": "waitlist entry deleted successfully"})
+}
@@ -0,0 +1,50 @@
+package slots
+
+import (
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+
+	slotsRepo "place-picker/internal/db/repo/slots"
+	userRepo "place-picker/internal/db/repo/user"
+	adminMiddleware "place-picker/internal/server/middleware/admin"
+)
+
+type (
+	Slots struct {
+		SlotsRepo *slotsRepo.SlotsRepository
+		UserRepo  *userRepo.UserRepository
+	}
+
+	// Шаблон интервала брони. Время — местное время офиса в формате HH:MM. Без office — офис по умолчанию.
+	TemplateRequest struct {
+		Office    *string `json:"office"`
+		Name      string  `json:"name" binding:"required,max=100"`
+		StartTime string  `json:"startTime" binding:"required"`
+		EndTime   string  `json:"endTime" binding:"required"`
+	}
+
+	TemplatesPayload struct {
+		Office    string               `json:"office"`
+		Templates []slotsRepo.Template `json:"templates"`
+	}
+)
+
+func New(db *sql.DB) *Slots {
+	return &Slots{
+		SlotsRepo: slotsRepo.NewSlotsRepository(db),
+		UserRepo:  userRepo.NewUserRepository(db),
+	}
+}
+
+func (s *Slots) RegisterPublicRoutes(r *gin.RouterGroup) {}
+
+func (s *Slots) RegisterPrivateRoutes(r *gin.RouterGroup) {
+	admin := adminMiddleware.AdminMiddleware(s.UserRepo)
+
+	r.GET("/slots/templates", func(c *gin.Context) { GetTemplatesHandler(c, s.SlotsRepo) })
+	r.POST("/slots/templates", admin, func(c *gin.Context) { CreateTemplateHandler(c, s.SlotsRepo) })
+	r.PUT("/slots/templates/:id", admin, func(c *gin.Context) { UpdateTemplateHandler(c, s.SlotsRepo) })
+	r.DELETE("/slots/templates/:id", admin, func(c *gin.Context) { DeleteTemplateHandler(c, s.SlotsRepo) })
+	r.GET("/slots/availability", func(c *gin.Context) { GetAvailabilityHandler(c, s.SlotsRepo) })
+}
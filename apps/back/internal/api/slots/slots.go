@@ -0,0 +1,241 @@
+package slots
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"place-picker/internal/api/request"
+	desksRepo "place-picker/internal/db/repo/desks"
+	slotsRepo "place-picker/internal/db/repo/slots"
+	"place-picker/internal/offices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Шаблоны интервалов офиса. По умолчанию — офиса по умолчанию.
+func GetTemplatesHandler(c *gin.Context, repo *slotsRepo.SlotsRepository) {
+	office, ok := loadOffice(c, "GetTemplatesHandler", c.Query("office"))
+	if !ok {
+		return
+	}
+
+	templates, err := repo.GetTemplates(c.Request.Context(), office.Key)
+	if err != nil {
+		slog.Error("GetTemplatesHandler | Failed to load slot templates", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load slot templates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, TemplatesPayload{Office: office.Key, Templates: templates})
+}
+
+func CreateTemplateHandler(c *gin.Context, repo *slotsRepo.SlotsRepository) {
+	var req TemplateRequest
+	if !request.BindJSON(c, "CreateTemplateHandler", &req) {
+		return
+	}
+
+	officeKey := ""
+	if req.Office != nil {
+		officeKey = *req.Office
+	}
+	office, ok := loadOffice(c, "CreateTemplateHandler", officeKey)
+	if !ok {
+		return
+	}
+
+	if !validTemplateTimes(c, office, req) {
+		return
+	}
+
+	id, err := repo.CreateTemplate(c.Request.Context(), office.Key, req.Name, req.StartTime, req.EndTime)
+	if err != nil {
+		if !respondTemplateError(c, err) {
+			slog.Error("CreateTemplateHandler | Failed to create slot template", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create slot template"})
+		}
+		return
+	}
+
+	slog.Info("CreateTemplateHandler | Slot template created", "id", id, "office", office.Key, "name", req.Name)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// Изменяет название и время шаблона. Офис шаблона не меняется.
+func UpdateTemplateHandler(c *gin.Context, repo *slotsRepo.SlotsRepository) {
+	templateId := c.Param("id")
+
+	var req TemplateRequest
+	if !request.BindJSON(c, "UpdateTemplateHandler", &req) {
+		return
+	}
+
+	template, err := repo.GetTemplate(c.Request.Context(), templateId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "slot template not found"})
+			return
+		}
+
+		slog.Error("UpdateTemplateHandler | Failed to load slot template", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update slot template"})
+		return
+	}
+
+	office, ok := loadOffice(c, "UpdateTemplateHandler", template.Office)
+	if !ok {
+		return
+	}
+
+	if !validTemplateTimes(c, office, req) {
+		return
+	}
+
+	if err := repo.UpdateTemplate(c.Request.Context(), templateId, req.Name, req.StartTime, req.EndTime); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "slot template not found"})
+			return
+		}
+		if !respondTemplateError(c, err) {
+			slog.Error("UpdateTemplateHandler | Failed to update slot template", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update slot template"})
+		}
+		return
+	}
+
+	slog.Info("UpdateTemplateHandler | Slot template updated", "id", templateId)
+	c.JSON(http.StatusOK, gin.H{"message": "slot template updated successfully"})
+}
+
+func DeleteTemplateHandler(c *gin.Context, repo *slotsRepo.SlotsRepository) {
+	templateId := c.Param("id")
+
+	if err := repo.DeleteTemplate(c.Request.Context(), templateId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "slot template not found"})
+			return
+		}
+
+		slog.Error("DeleteTemplateHandler | Failed to delete slot template", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete slot template"})
+		return
+	}
+
+	slog.Info("DeleteTemplateHandler | Slot template deleted", "id", templateId)
+	c.JSON(http.StatusOK, gin.H{"message": "slot template deleted successfully"})
+}
+
+// Сетка доступности ресурсов офиса по его шаблонам за день для текущего пользователя.
+// По умолчанию — столы офиса по умолчанию на сегодня.
+func GetAvailabilityHandler(c *gin.Context, repo *slotsRepo.SlotsRepository) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		slog.Error("GetAvailabilityHandler | Failed to check user id")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	office, ok := loadOffice(c, "GetAvailabilityHandler", c.Query("office"))
+	if !ok {
+		return
+	}
+
+	day := c.DefaultQuery("day", office.Day(time.Now()))
+	if _, err := time.Parse(time.DateOnly, day); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "day must be a date in YYYY-MM-DD format"})
+		return
+	}
+
+	defaultOffice, err := offices.Get("")
+	if err != nil {
+		slog.Error("GetAvailabilityHandler | Failed to load default office", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load availability"})
+		return
+	}
+
+	resourceType := c.DefaultQuery("type", desksRepo.ResourceTypeDesk)
+
+	availability, err := repo.GetAvailability(c.Request.Context(), office, defaultOffice.Key, resourceType, userId.(string), day)
+	if err != nil {
+		slog.Error("GetAvailabilityHandler | Failed to load availability", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load availability"})
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
+
+// Шаблон должен укладываться в рабочие часы офиса: иначе бронь по нему не пройдет проверку при брони.
+func validTemplateTimes(c *gin.Context, office offices.Office, req TemplateRequest) bool {
+	var fields []request.FieldError
+
+	start, err := time.Parse("15:04", req.StartTime)
+	if err != nil {
+		fields = append(fields, request.FieldError{Field: "startTime", Message: "must be a time in HH:MM format"})
+	}
+	end, err := time.Parse("15:04", req.EndTime)
+	if err != nil {
+		fields = append(fields, request.FieldError{Field: "endTime", Message: "must be a time in HH:MM format"})
+	}
+	if len(fields) > 0 {
+		request.RespondFieldErrors(c, fields...)
+		return false
+	}
+
+	if !end.After(start) {
+		request.RespondFieldErrors(c, request.FieldError{Field: "endTime", Message: "must be after startTime"})
+		return false
+	}
+
+	workStart, errStart := time.Parse("15:04", office.WorkStart)
+	workEnd, errEnd := time.Parse("15:04", office.WorkEnd)
+	if errStart != nil || errEnd != nil {
+		slog.Error("validTemplateTimes | Invalid office working hours", "office", office.Key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check office working hours"})
+		return false
+	}
+
+	message := "must be within office working hours " + office.Hours()
+	if start.Before(workStart) {
+		fields = append(fields, request.FieldError{Field: "startTime", Message: message})
+	}
+	if end.After(workEnd) {
+		fields = append(fields, request.FieldError{Field: "endTime", Message: message})
+	}
+	if len(fields) > 0 {
+		request.RespondFieldErrors(c, fields...)
+		return false
+	}
+
+	return true
+}
+
+func respondTemplateError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, slotsRepo.ErrTemplateNameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, slotsRepo.ErrInvalidTemplate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+func loadOffice(c *gin.Context, handler, key string) (offices.Office, bool) {
+	office, err := offices.Get(key)
+	if err != nil {
+		if errors.Is(err, offices.ErrUnknownOffice) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return offices.Office{}, false
+		}
+
+		slog.Error(handler+" | Failed to load office", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load office"})
+		return offices.Office{}, false
+	}
+
+	return office, true
+}
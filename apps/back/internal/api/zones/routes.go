@@ -0,0 +1,52 @@
+package zones
+
+import (
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+
+	userRepo "place-picker/internal/db/repo/user"
+	zonesRepo "place-picker/internal/db/repo/zones"
+	adminMiddleware "place-picker/internal/server/middleware/admin"
+)
+
+type (
+	Zones struct {
+		ZonesRepo *zonesRepo.ZonesRepository
+		UserRepo  *userRepo.UserRepository
+	}
+
+	ZonesPayload struct {
+		Zones []zonesRepo.Zone `json:"zones"`
+	}
+
+	ZoneRequest struct {
+		Name                  string `json:"name" binding:"required"`
+		OpenDaysBefore        *int   `json:"openDaysBefore" binding:"omitempty,min=0"`
+		CleaningBufferMinutes *int   `json:"cleaningBufferMinutes" binding:"omitempty,min=0,max=240"`
+		CapacityPercent       *int   `json:"capacityPercent" binding:"omitempty,min=0,max=100"`
+	}
+
+	SetGroupsRequest struct {
+		GroupIds []string `json:"groupIds" binding:"required"`
+	}
+)
+
+func New(db *sql.DB) *Zones {
+	return &Zones{
+		ZonesRepo: zonesRepo.NewZonesRepository(db),
+		UserRepo:  userRepo.NewUserRepository(db),
+	}
+}
+
+func (z *Zones) RegisterPublicRoutes(r *gin.RouterGroup) {}
+
+func (z *Zones) RegisterPrivateRoutes(r *gin.RouterGroup) {
+	admin := adminMiddleware.AdminMiddleware(z.UserRepo)
+
+	r.GET("/zones", func(c *gin.Context) { GetZonesHandler(c, z.ZonesRepo) })
+	r.POST("/zones", admin, func(c *gin.Context) { CreateZoneHandler(c, z.ZonesRepo) })
+	r.PUT("/zones/:id", admin, func(c *gin.Context) { UpdateZoneHandler(c, z.ZonesRepo) })
+	r.DELETE("/zones/:id", admin, func(c *gin.Context) { DeleteZoneHandler(c, z.ZonesRepo) })
+	r.PUT("/zones/:id/groups", admin, func(c *gin.Context) { SetZoneGroupsHandler(c, z.ZonesRepo) })
+}
@@ -0,0 +1,118 @@
+package zones
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	zonesRepo "place-picker/internal/db/repo/zones"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetZonesHandler(c *gin.Context, repo *zonesRepo.ZonesRepository) {
+	zones, err := repo.GetAllZones(c.Request.Context())
+	if err != nil {
+		slog.Error("GetZonesHandler | Failed to load zones", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load zones"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ZonesPayload{Zones: zones})
+}
+
+func CreateZoneHandler(c *gin.Context, repo *zonesRepo.ZonesRepository) {
+	var req ZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("CreateZoneHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	id, err := repo.CreateZone(c.Request.Context(), req.Name, req.OpenDaysBefore, req.CleaningBufferMinutes, req.CapacityPercent)
+	if err != nil {
+		if errors.Is(err, zonesRepo.ErrZoneAlreadyExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+
+		slog.Error("CreateZoneHandler | Failed to create zone", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create zone"})
+		return
+	}
+
+	slog.Info("CreateZoneHandler | Zone created", "zoneId", id, "name", req.Name)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func UpdateZoneHandler(c *gin.Context, repo *zonesRepo.ZonesRepository) {
+	zoneId := c.Param("id")
+
+	var req ZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("UpdateZoneHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := repo.UpdateZone(c.Request.Context(), zoneId, req.Name, req.OpenDaysBefore, req.CleaningBufferMinutes, req.CapacityPercent); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "zone not found"})
+		case errors.Is(err, zonesRepo.ErrZoneAlreadyExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			slog.Error("UpdateZoneHandler | Failed to update zone", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update zone"})
+		}
+		return
+	}
+
+	slog.Info("UpdateZoneHandler | Zone updated", "zoneId", zoneId)
+	c.JSON(http.StatusOK, gin.H{"message": "zone updated successfully"})
+}
+
+func DeleteZoneHandler(c *gin.Context, repo *zonesRepo.ZonesRepository) {
+	zoneId := c.Param("id")
+
+	if err := repo.DeleteZone(c.Request.Context(), zoneId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "zone not found"})
+			return
+		}
+
+		slog.Error("DeleteZoneHandler | Failed to delete zone", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete zone"})
+		return
+	}
+
+	slog.Info("DeleteZoneHandler | Zone deleted", "zoneId", zoneId)
+	c.JSON(http.StatusOK, gin.H{"message": "zone deleted successfully"})
+}
+
+func SetZoneGroupsHandler(c *gin.Context, repo *zonesRepo.ZonesRepository) {
+	zoneId := c.Param("id")
+
+	var req SetGroupsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("SetZoneGroupsHandler | Unable parse request body", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := repo.SetZoneGroups(c.Request.Context(), zoneId, req.GroupIds); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "zone not found"})
+		case errors.Is(err, zonesRepo.ErrGroupNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			slog.Error("SetZoneGroupsHandler | Failed to update zone groups", "error", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update zone groups"})
+		}
+		return
+	}
+
+	slog.Info("SetZoneGroupsHandler | Zone groups updated", "zoneId", zoneId, "groupIds", req.GroupIds)
+	c.JSON(http.StatusOK, gin.H{"message": "zone groups updated successfully"})
+}
@@ -0,0 +1,91 @@
+package approval
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"place-picker/internal/mail"
+	"place-picker/internal/notifications"
+	"place-picker/internal/waitlist"
+)
+
+// Периодически переводит в expired брони, которые не дождались подтверждения, и уведомляет пользователей.
+func StartApprovalExpiry(ctx context.Context, logger *slog.Logger, repo *reservationsRepo.ReservationsRepository, interval, pendingTTL time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	expirePending(ctx, logger, repo, pendingTTL)
+
+	logger.Info("StartApprovalExpiry | Started pending reservations expiry", "interval", interval, "pendingTTL", pendingTTL)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("StartApprovalExpiry | Stopping pending reservations expiry")
+			return
+		case <-ticker.C:
+			expirePending(ctx, logger, repo, pendingTTL)
+		}
+	}
+}
+
+func expirePending(ctx context.Context, logger *slog.Logger, repo *reservationsRepo.ReservationsRepository, pendingTTL time.Duration) {
+	notices, err := repo.ExpirePendingReservations(ctx, pendingTTL)
+	if err != nil {
+		logger.Error("expirePending | Failed to expire pending reservations", "error", err.Error())
+		return
+	}
+
+	if len(notices) == 0 {
+		return
+	}
+
+	logger.Info("expirePending | Expired pending reservations", "count", len(notices))
+
+	for _, notice := range notices {
+		subject, body := DecisionEmail(notice)
+		if err := mail.SendNotificationEmail(notice.UserEmail, subject, body); err != nil {
+			logger.Error("expirePending | Failed to send expiry notification", "reservationId", notice.ReservationId, "error", err.Error())
+		}
+	}
+
+	// Освободившиеся столы могут быть нужны очереди ожидания.
+	waitlist.Wake()
+}
+
+// Письмо пользователю о решении по его брони: подтверждена, отклонена или не дождалась подтверждения.
+func DecisionEmail(notice reservationsRepo.ApprovalNotice) (string, string) {
+	slot := notifications.FormatSlot(notice.DeskOffice, notice.DateFrom, notice.DateTo)
+
+	comment := ""
+	if notice.Comment != nil && *notice.Comment != "" {
+		comment = fmt.Sprintf("<p>Комментарий: %s</p>", notifications.Escape(*notice.Comment))
+	}
+
+	switch notice.Status {
+	case reservationsRepo.StatusActive:
+		return "Бронь подтверждена", fmt.Sprintf(`
+			<h2>Бронь стола %s подтверждена</h2>
+			<p>Стол забронирован на %s.</p>
+			%s
+		`, notifications.Escape(notice.DeskName), slot, comment)
+	case reservationsRepo.StatusDeclined:
+		return "Бронь отклонена", fmt.Sprintf(`
+			<h2>Бронь стола %s отклонена</h2>
+			<p>Бронь на %s отклонена, стол освобожден.</p>
+			%s
+		`, notifications.Escape(notice.DeskName), slot, comment)
+	default:
+		return "Бронь не подтверждена", fmt.Sprintf(`
+			<h2>Бронь стола %s не подтверждена</h2>
+			<p>Бронь на %s не дождалась подтверждения и отменена.</p>
+		`, notifications.Escape(notice.DeskName), slot)
+	}
+}
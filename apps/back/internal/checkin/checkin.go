@@ -0,0 +1,37 @@
+package checkin
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"place-picker/internal/config"
+
+	"github.com/spf13/viper"
+)
+
+// Возвращает подпись стола для QR-кода. Подпись подтверждает, что пользователь отсканировал код на месте.
+func SignDesk(deskId string) string {
+	mac := hmac.New(sha256.New, []byte(viper.GetString("checkin.secret")))
+	mac.Write([]byte("checkin:" + deskId))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func VerifyDeskSignature(deskId, signature string) bool {
+	return hmac.Equal([]byte(SignDesk(deskId)), []byte(signature))
+}
+
+// Возвращает ссылку на страницу отметки, которая зашивается в QR-код стола.
+func DeskCheckInURL(deskId string) string {
+	baseURL := viper.GetString("dev_frontend_url")
+	if config.IsProdMode() {
+		baseURL = viper.GetString("domain")
+	}
+
+	query := url.Values{}
+	query.Set("deskId", deskId)
+	query.Set("signature", SignDesk(deskId))
+
+	return baseURL + "/checkin?" + query.Encode()
+}
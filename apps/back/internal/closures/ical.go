@@ -0,0 +1,196 @@
+package closures
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCalendar = errors.New("invalid iCalendar file")
+
+// Событие календаря, превращенное в закрытие офиса. Даты — местные даты офиса, DateTo включительно.
+type Event struct {
+	Uid      string
+	Summary  string
+	DateFrom string
+	DateTo   string
+}
+
+// Разбирает события VEVENT из файла iCalendar (RFC 5545). Время событий переводится в часовой пояс офиса.
+// Повторяющиеся события (RRULE) не разворачиваются и возвращаются отдельно, чтобы сообщить о них администратору.
+func ParseICal(r io.Reader, location *time.Location) (events []Event, recurring []string, err error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		inEvent   bool
+		props     map[string]property
+		calendars int
+	)
+
+	for _, line := range lines {
+		name, prop, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && prop.value == "VCALENDAR":
+			calendars++
+		case name == "BEGIN" && prop.value == "VEVENT":
+			inEvent, props = true, map[string]property{}
+		case name == "END" && prop.value == "VEVENT":
+			inEvent = false
+
+			if _, ok := props["RRULE"]; ok {
+				recurring = append(recurring, props["SUMMARY"].text())
+				continue
+			}
+
+			event, err := buildEvent(props, location)
+			if err != nil {
+				return nil, nil, err
+			}
+			events = append(events, event)
+		case inEvent:
+			props[name] = prop
+		}
+	}
+
+	if calendars == 0 {
+		return nil, nil, fmt.Errorf("%w: VCALENDAR not found", ErrInvalidCalendar)
+	}
+
+	return events, recurring, nil
+}
+
+type property struct {
+	params map[string]string
+	value  string
+}
+
+// Значение текстового свойства без экранирования.
+func (p property) text() string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(p.value)
+}
+
+func buildEvent(props map[string]property, location *time.Location) (Event, error) {
+	start, ok := props["DTSTART"]
+	if !ok {
+		return Event{}, fmt.Errorf("%w: event without DTSTART", ErrInvalidCalendar)
+	}
+
+	from, allDay, err := parseDate(start, location)
+	if err != nil {
+		return Event{}, err
+	}
+
+	// Конец события не входит в него: у события на весь день DTEND — следующий день.
+	to := from
+	if end, ok := props["DTEND"]; ok {
+		to, _, err = parseDate(end, location)
+		if err != nil {
+			return Event{}, err
+		}
+		if allDay {
+			to = to.AddDate(0, 0, -1)
+		} else {
+			to = to.Add(-time.Second)
+		}
+		if to.Before(from) {
+			to = from
+		}
+	}
+
+	summary := props["SUMMARY"].text()
+	if summary == "" {
+		summary = "Office closed"
+	}
+
+	return Event{
+		Uid:      props["UID"].value,
+		Summary:  summary,
+		DateFrom: from.Format(time.DateOnly),
+		DateTo:   to.Format(time.DateOnly),
+	}, nil
+}
+
+// Разбирает DATE или DATE-TIME. Возвращает true для даты без времени.
+func parseDate(p property, location *time.Location) (time.Time, bool, error) {
+	value := p.value
+
+	if p.params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, location)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("%w: bad date %q", ErrInvalidCalendar, value)
+		}
+		return t, true, nil
+	}
+
+	eventLocation := location
+	if tzid, ok := p.params["TZID"]; ok {
+		if loaded, err := time.LoadLocation(tzid); err == nil {
+			eventLocation = loaded
+		}
+	}
+
+	var (
+		t   time.Time
+		err error
+	)
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse("20060102T150405Z", value)
+	} else {
+		t, err = time.ParseInLocation("20060102T150405", value, eventLocation)
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: bad date-time %q", ErrInvalidCalendar, value)
+	}
+
+	return t.In(location), false, nil
+}
+
+// Склеивает строки, перенесенные по RFC 5545: продолжение начинается с пробела или табуляции.
+func unfoldLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err)
+	}
+
+	return lines, nil
+}
+
+// Разбирает строку вида NAME;PARAM=VALUE:value.
+func parseProperty(line string) (string, property, bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", property{}, false
+	}
+
+	parts := strings.Split(head, ";")
+	prop := property{params: map[string]string{}, value: value}
+	for _, param := range parts[1:] {
+		if key, val, ok := strings.Cut(param, "="); ok {
+			prop.params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+		}
+	}
+
+	return strings.ToUpper(parts[0]), prop, true
+}
@@ -0,0 +1,44 @@
+package closures
+
+import (
+	"fmt"
+	"strings"
+
+	closuresRepo "place-picker/internal/db/repo/closures"
+	"place-picker/internal/notifications"
+)
+
+// Сообщает владельцам броней, что брони отменены из-за закрытия офиса. Каждому пользователю — одно письмо.
+func NotifyCancelled(cancelled []closuresRepo.CancelledReservation) {
+	byUser := map[string][]closuresRepo.CancelledReservation{}
+	var order []string
+	for _, reservation := range cancelled {
+		if _, ok := byUser[reservation.UserEmail]; !ok {
+			order = append(order, reservation.UserEmail)
+		}
+		byUser[reservation.UserEmail] = append(byUser[reservation.UserEmail], reservation)
+	}
+
+	for _, email := range order {
+		var items strings.Builder
+		for _, reservation := range byUser[email] {
+			guest := ""
+			if reservation.GuestName != nil {
+				guest = fmt.Sprintf(", гость %s", notifications.Escape(*reservation.GuestName))
+			}
+
+			items.WriteString(fmt.Sprintf("<li>Стол %s, %s%s — %s</li>",
+				notifications.Escape(reservation.DeskName),
+				notifications.FormatSlot(reservation.DeskOffice, reservation.DateFrom, reservation.DateTo),
+				guest,
+				notifications.Escape(reservation.Reason),
+			))
+		}
+
+		notifications.Send(email, "Брони отменены: офис закрыт", fmt.Sprintf(`
+			<h2>Офис будет закрыт</h2>
+			<p>Ваши брони на дни закрытия офиса отменены:</p>
+			<ul>%s</ul>
+		`, items.String()))
+	}
+}
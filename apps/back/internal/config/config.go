@@ -12,9 +12,82 @@ import (
 
 type Config struct {
 	// Mode определяет в каком режиме должно работать приложение: dev / prod / test
-	Mode       string `mapstructure:"mode" validate:"required,oneof=prod dev test"`
-	LogsPath   string `mapstructure:"logs_path"`
-	HTTPServer `mapstructure:"http_server" validate:"required"`
+	Mode        string `mapstructure:"mode" validate:"required,oneof=prod dev test"`
+	LogsPath    string `mapstructure:"logs_path"`
+	HTTPServer  `mapstructure:"http_server" validate:"required"`
+	CheckIn     `mapstructure:"checkin"`
+	Recurrence  `mapstructure:"recurrence"`
+	Waitlist    `mapstructure:"waitlist"`
+	Approval    `mapstructure:"approval"`
+	Retention   `mapstructure:"retention"`
+	Swaps       `mapstructure:"swaps"`
+	Idempotency `mapstructure:"idempotency"`
+	Lottery     `mapstructure:"lottery"`
+	// Offices задает рабочие часы и часовой пояс каждого офиса. Столы без офиса относятся к DefaultOffice.
+	Offices       map[string]Office `mapstructure:"offices" validate:"required,dive"`
+	DefaultOffice string            `mapstructure:"default_office" validate:"required"`
+}
+
+// Office задает рабочие часы офиса в формате HH:MM по местному времени и его часовой пояс IANA.
+type Office struct {
+	Timezone  string `mapstructure:"timezone" validate:"required"`
+	WorkStart string `mapstructure:"work_start" validate:"required"`
+	WorkEnd   string `mapstructure:"work_end" validate:"required"`
+}
+
+// CheckIn задает окно отметки о приходе: отметиться можно за WindowBefore до начала брони
+// и в течение GracePeriod после. Неподтвержденные брони освобождаются каждые ReleaseInterval.
+// Secret — ключ подписи QR-кодов столов, отдельный от ключа JWT. Смена ключа делает напечатанные коды недействительными.
+type CheckIn struct {
+	Secret          string        `mapstructure:"secret" validate:"required"`
+	WindowBefore    time.Duration `mapstructure:"window_before"`
+	GracePeriod     time.Duration `mapstructure:"grace_period"`
+	ReleaseInterval time.Duration `mapstructure:"release_interval"`
+}
+
+// Recurrence задает горизонт, на который заранее создаются вхождения повторяющихся броней,
+// и период, с которым горизонт продлевается.
+type Recurrence struct {
+	Horizon        time.Duration `mapstructure:"horizon"`
+	ExpandInterval time.Duration `mapstructure:"expand_interval"`
+}
+
+// Waitlist задает период обработки очереди ожидания и срок, в течение которого
+// пользователь должен подтвердить предложенную ему бронь.
+type Waitlist struct {
+	ProcessInterval time.Duration `mapstructure:"process_interval"`
+	OfferTTL        time.Duration `mapstructure:"offer_ttl"`
+}
+
+// Approval задает, сколько бронь может ждать подтверждения, и период, с которым снимаются просроченные.
+type Approval struct {
+	PendingTTL     time.Duration `mapstructure:"pending_ttl"`
+	ExpireInterval time.Duration `mapstructure:"expire_interval"`
+}
+
+// Retention задает, сколько закончившиеся брони хранятся в основной таблице до переноса в архив,
+// и период, с которым закончившиеся брони закрываются и архивируются.
+type Retention struct {
+	ArchiveAfter    time.Duration `mapstructure:"archive_after"`
+	ArchiveInterval time.Duration `mapstructure:"archive_interval"`
+}
+
+// Swaps задает, сколько действует предложение обмена столами, и период, с которым снимаются просроченные.
+type Swaps struct {
+	RequestTTL     time.Duration `mapstructure:"request_ttl"`
+	ExpireInterval time.Duration `mapstructure:"expire_interval"`
+}
+
+// Idempotency задает, сколько хранятся ответы на запросы с ключом идемпотентности, и период удаления просроченных ключей.
+type Idempotency struct {
+	TTL             time.Duration `mapstructure:"ttl"`
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
+}
+
+// Lottery задает период розыгрыша лотерей и срок, за который учитывается история выигрышей участников.
+type Lottery struct {
+	DrawInterval   time.Duration `mapstructure:"draw_interval"`
+	FairnessWindow time.Duration `mapstructure:"fairness_window"`
 }
 
 type HTTPServer struct {
@@ -32,6 +105,28 @@ func MustLoadConfig() *Config {
 	viper.SetDefault("http_server.read_timeout", 30*time.Second)
 	viper.SetDefault("http_server.write_timeout", 30*time.Second)
 	viper.SetDefault("frontend_path", "./")
+	viper.SetDefault("checkin.secret", "")
+	viper.SetDefault("checkin.window_before", 15*time.Minute)
+	viper.SetDefault("checkin.grace_period", 30*time.Minute)
+	viper.SetDefault("checkin.release_interval", 5*time.Minute)
+	viper.SetDefault("recurrence.horizon", 90*24*time.Hour)
+	viper.SetDefault("recurrence.expand_interval", 24*time.Hour)
+	viper.SetDefault("waitlist.process_interval", time.Minute)
+	viper.SetDefault("waitlist.offer_ttl", 2*time.Hour)
+	viper.SetDefault("approval.pending_ttl", 24*time.Hour)
+	viper.SetDefault("approval.expire_interval", 5*time.Minute)
+	viper.SetDefault("retention.archive_after", 90*24*time.Hour)
+	viper.SetDefault("retention.archive_interval", time.Hour)
+	viper.SetDefault("swaps.request_ttl", 24*time.Hour)
+	viper.SetDefault("swaps.expire_interval", 5*time.Minute)
+	viper.SetDefault("idempotency.ttl", 24*time.Hour)
+	viper.SetDefault("idempotency.cleanup_interval", time.Hour)
+	viper.SetDefault("lottery.draw_interval", time.Minute)
+	viper.SetDefault("lottery.fairness_window", 90*24*time.Hour)
+	viper.SetDefault("default_office", "main")
+	viper.SetDefault("offices.main.timezone", "Europe/Moscow")
+	viper.SetDefault("offices.main.work_start", "08:00")
+	viper.SetDefault("offices.main.work_end", "21:00")
 
 	viper.SetConfigName("config")
 	viper.AddConfigPath(".")
@@ -0,0 +1,55 @@
+package cleanup
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+)
+
+// Периодически закрывает закончившиеся брони и переносит в архив брони старше retention.
+func StartReservationsArchival(ctx context.Context, logger *slog.Logger, repo *reservationsRepo.ReservationsRepository, interval, retention time.Duration) {
+	if interval <= 0 {
+		interval = 1 * time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	archiveReservations(ctx, logger, repo, retention)
+
+	logger.Info("StartReservationsArchival | Started reservations archival", "interval", interval, "retention", retention)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("StartReservationsArchival | Stopping reservations archival")
+			return
+		case <-ticker.C:
+			archiveReservations(ctx, logger, repo, retention)
+		}
+	}
+}
+
+func archiveReservations(ctx context.Context, logger *slog.Logger, repo *reservationsRepo.ReservationsRepository, retention time.Duration) {
+	completed, err := repo.CompleteReservations(ctx)
+	if err != nil {
+		logger.Error("archiveReservations | Failed to complete reservations", "error", err.Error())
+		return
+	}
+
+	if completed > 0 {
+		logger.Info("archiveReservations | Completed reservations", "count", completed)
+	}
+
+	archived, err := repo.ArchiveReservations(ctx, retention)
+	if err != nil {
+		logger.Error("archiveReservations | Failed to archive reservations", "error", err.Error())
+		return
+	}
+
+	if archived > 0 {
+		logger.Info("archiveReservations | Archived reservations", "count", archived)
+	}
+}
@@ -0,0 +1,45 @@
+package cleanup
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	idempotencyRepo "place-picker/internal/db/repo/idempotency"
+)
+
+// Периодически удаляет ключи идемпотентности с истекшим сроком.
+func StartIdempotencyKeysCleanup(ctx context.Context, logger *slog.Logger, repo *idempotencyRepo.IdempotencyRepository, interval time.Duration) {
+	if interval <= 0 {
+		interval = 1 * time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	deleteExpiredIdempotencyKeys(ctx, logger, repo)
+
+	logger.Info("StartIdempotencyKeysCleanup | Started idempotency keys cleanup", "interval", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("StartIdempotencyKeysCleanup | Stopping idempotency keys cleanup")
+			return
+		case <-ticker.C:
+			deleteExpiredIdempotencyKeys(ctx, logger, repo)
+		}
+	}
+}
+
+func deleteExpiredIdempotencyKeys(ctx context.Context, logger *slog.Logger, repo *idempotencyRepo.IdempotencyRepository) {
+	deleted, err := repo.DeleteExpired(ctx)
+	if err != nil {
+		logger.Error("deleteExpiredIdempotencyKeys | Failed to delete expired idempotency keys", "error", err.Error())
+		return
+	}
+
+	if deleted > 0 {
+		logger.Info("deleteExpiredIdempotencyKeys | Deleted expired idempotency keys", "count", deleted)
+	}
+}
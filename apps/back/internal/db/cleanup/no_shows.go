@@ -0,0 +1,44 @@
+package cleanup
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+)
+
+func StartNoShowsRelease(ctx context.Context, logger *slog.Logger, repo *reservationsRepo.ReservationsRepository, interval, gracePeriod time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	releaseNoShows(ctx, logger, repo, gracePeriod)
+
+	logger.Info("StartNoShowsRelease | Started no-shows release", "interval", interval, "gracePeriod", gracePeriod)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("StartNoShowsRelease | Stopping no-shows release")
+			return
+		case <-ticker.C:
+			releaseNoShows(ctx, logger, repo, gracePeriod)
+		}
+	}
+}
+
+func releaseNoShows(ctx context.Context, logger *slog.Logger, repo *reservationsRepo.ReservationsRepository, gracePeriod time.Duration) {
+	rowsAffected, err := repo.ReleaseNoShows(ctx, gracePeriod)
+	if err != nil {
+		logger.Error("releaseNoShows | Failed to release no-show reservations", "error", err.Error())
+		return
+	}
+
+	if rowsAffected > 0 {
+		logger.Info("releaseNoShows | Released no-show reservations", "count", rowsAffected)
+	}
+}
@@ -0,0 +1,351 @@
+package assignments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"place-picker/internal/offices"
+)
+
+var ErrAssignmentOverlap = errors.New("desk is already assigned for this period")
+var ErrDeskOrUserNotFound = errors.New("desk or user not found")
+var ErrDayAlreadyReserved = errors.New("desk is already reserved by another user on this day")
+
+// Стол нельзя закрепить: на период закрепления у других пользователей есть брони.
+type ReservedPeriodError struct {
+	Reservations []ConflictingReservation
+}
+
+func (e *ReservedPeriodError) Error() string {
+	return "desk is reserved by other users during this period"
+}
+
+type (
+	AssignmentsRepository struct {
+		db *sql.DB
+	}
+
+	Assignment struct {
+		Id           string   `json:"id"`
+		DeskId       string   `json:"deskId"`
+		UserId       string   `json:"userId"`
+		UserName     string   `json:"userName"`
+		DateFrom     *string  `json:"dateFrom"`
+		DateTo       *string  `json:"dateTo"`
+		ReleasedDays []string `json:"releasedDays"`
+	}
+
+	ConflictingReservation struct {
+		ReservationId string    `json:"reservationId"`
+		UserId        string    `json:"userId"`
+		UserName      string    `json:"userName"`
+		Status        string    `json:"status"`
+		DateFrom      time.Time `json:"dateFrom"`
+		DateTo        time.Time `json:"dateTo"`
+	}
+)
+
+func NewAssignmentsRepository(db *sql.DB) *AssignmentsRepository {
+	return &AssignmentsRepository{db: db}
+}
+
+// Закрепляет стол за пользователем. Пустые границы периода означают бессрочное закрепление.
+// Если на период закрепления у других пользователей есть действующие брони стола, возвращает ReservedPeriodError.
+func (r *AssignmentsRepository) AssignDesk(ctx context.Context, deskId, userId string, dateFrom, dateTo *string) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	conflicts, err := reservationsInPeriod(ctx, tx, deskId, userId, dateFrom, dateTo)
+	if err != nil {
+		return "", err
+	}
+	if len(conflicts) > 0 {
+		return "", &ReservedPeriodError{Reservations: conflicts}
+	}
+
+	query := `
+		INSERT INTO desk_assignments (desk_id, user_id, date_from, date_to)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	var id string
+	err = tx.QueryRowContext(ctx, query, deskId, userId, dateFrom, dateTo).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			switch {
+			case pqErr.Constraint == "one_assignment_per_desk_per_period":
+				return "", ErrAssignmentOverlap
+			case pqErr.Code == "23503":
+				return "", ErrDeskOrUserNotFound
+			}
+		}
+
+		return "", fmt.Errorf("failed to assign desk: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return id, nil
+}
+
+// Действующие и ожидающие подтверждения брони стола другими пользователями, которые начинаются
+// в дни периода закрепления по времени офиса стола. Закончившиеся брони не учитываются.
+func reservationsInPeriod(ctx context.Context, tx *sql.Tx, deskId, userId string, dateFrom, dateTo *string) ([]ConflictingReservation, error) {
+	// Стол блокируется до конца транзакции: новые брони стола ждут закрепления.
+	var deskOffice sql.NullString
+	if err := tx.QueryRowContext(ctx, `SELECT office FROM desks WHERE id = $1 FOR UPDATE`, deskId).Scan(&deskOffice); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDeskOrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get desk office: %w", err)
+	}
+
+	office, err := offices.Get(deskOffice.String)
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to *time.Time
+	if dateFrom != nil {
+		start, _, err := office.DayBounds(*dateFrom)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dateFrom: %w", err)
+		}
+		from = &start
+	}
+	if dateTo != nil {
+		_, end, err := office.DayBounds(*dateTo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dateTo: %w", err)
+		}
+		to = &end
+	}
+
+	query := `
+		SELECT r.id, r.user_id, u.name, r.status, r.date_from, r.date_to
+		FROM reservations r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.desk_id = $1
+		  AND r.user_id <> $2
+		  AND r.status IN ('active', 'pending')
+		  AND r.date_to > NOW()
+		  AND ($3::timestamptz IS NULL OR r.date_from >= $3)
+		  AND ($4::timestamptz IS NULL OR r.date_from < $4)
+		ORDER BY r.date_from
+	`
+
+	rows, err := tx.QueryContext(ctx, query, deskId, userId, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reservations in period: %w", err)
+	}
+	defer rows.Close()
+
+	var conflicts []ConflictingReservation
+	for rows.Next() {
+		var c ConflictingReservation
+		if err := rows.Scan(&c.ReservationId, &c.UserId, &c.UserName, &c.Status, &c.DateFrom, &c.DateTo); err != nil {
+			return nil, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		conflicts = append(conflicts, c)
+	}
+
+	return conflicts, rows.Err()
+}
+
+func (r *AssignmentsRepository) GetAssignment(ctx context.Context, id string) (*Assignment, error) {
+	query := `
+		SELECT
+			a.id,
+			a.desk_id,
+			a.user_id,
+			u.name,
+			to_char(a.date_from, 'YYYY-MM-DD'),
+			to_char(a.date_to, 'YYYY-MM-DD'),
+			COALESCE(
+				(SELECT array_agg(to_char(dr.day, 'YYYY-MM-DD') ORDER BY dr.day) FROM desk_releases dr WHERE dr.assignment_id = a.id),
+				'{}'
+			)
+		FROM desk_assignments a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.id = $1
+	`
+
+	var a Assignment
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&a.Id,
+		&a.DeskId,
+		&a.UserId,
+		&a.UserName,
+		&a.DateFrom,
+		&a.DateTo,
+		pq.Array(&a.ReleasedDays),
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("failed to get assignment: %w", err)
+	}
+
+	return &a, nil
+}
+
+func (r *AssignmentsRepository) GetDeskAssignments(ctx context.Context, deskId string) ([]Assignment, error) {
+	query := `
+		SELECT
+			a.id,
+			a.desk_id,
+			a.user_id,
+			u.name,
+			to_char(a.date_from, 'YYYY-MM-DD'),
+			to_char(a.date_to, 'YYYY-MM-DD'),
+			COALESCE(
+				(SELECT array_agg(to_char(dr.day, 'YYYY-MM-DD') ORDER BY dr.day) FROM desk_releases dr WHERE dr.assignment_id = a.id),
+				'{}'
+			)
+		FROM desk_assignments a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.desk_id = $1
+		ORDER BY a.date_from NULLS FIRST
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, deskId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query desk assignments: %w", err)
+	}
+	defer rows.Close()
+
+	assignments := []Assignment{}
+	for rows.Next() {
+		var a Assignment
+		if err := rows.Scan(&a.Id, &a.DeskId, &a.UserId, &a.UserName, &a.DateFrom, &a.DateTo, pq.Array(&a.ReleasedDays)); err != nil {
+			return nil, fmt.Errorf("failed to scan assignment: %w", err)
+		}
+		assignments = append(assignments, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+func (r *AssignmentsRepository) DeleteAssignment(ctx context.Context, deskId, id string) error {
+	query := `DELETE FROM desk_assignments WHERE id = $1 AND desk_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, deskId)
+	if err != nil {
+		return fmt.Errorf("failed to delete assignment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Освобождает дни закрепленного стола, чтобы его могли забронировать другие пользователи.
+func (r *AssignmentsRepository) ReleaseDays(ctx context.Context, assignmentId string, days []string) error {
+	query := `
+		INSERT INTO desk_releases (assignment_id, day)
+		SELECT $1, d FROM unnest($2::date[]) AS d
+		ON CONFLICT (assignment_id, day) DO NOTHING
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, assignmentId, pq.Array(days)); err != nil {
+		return fmt.Errorf("failed to release days: %w", err)
+	}
+
+	return nil
+}
+
+// Возвращает освобожденный день владельцу, если его еще никто не забронировал.
+func (r *AssignmentsRepository) ReclaimDay(ctx context.Context, assignmentId, day string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var deskOffice sql.NullString
+	officeQuery := `
+		SELECT d.office
+		FROM desk_assignments a
+		JOIN desks d ON d.id = a.desk_id
+		WHERE a.id = $1
+	`
+	if err := tx.QueryRowContext(ctx, officeQuery, assignmentId).Scan(&deskOffice); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("failed to get desk office: %w", err)
+	}
+
+	office, err := offices.Get(deskOffice.String)
+	if err != nil {
+		return err
+	}
+
+	dayStart, dayEnd, err := office.DayBounds(day)
+	if err != nil {
+		return fmt.Errorf("invalid day: %w", err)
+	}
+
+	var reserved bool
+	reservedQuery := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM reservations r
+			JOIN desk_assignments a ON a.desk_id = r.desk_id
+			WHERE a.id = $1
+			  AND r.user_id <> a.user_id
+			  AND r.status IN ('active', 'pending')
+			  AND r.date_from >= $2
+			  AND r.date_from < $3
+		)
+	`
+	if err := tx.QueryRowContext(ctx, reservedQuery, assignmentId, dayStart, dayEnd).Scan(&reserved); err != nil {
+		return fmt.Errorf("failed to check reservations: %w", err)
+	}
+	if reserved {
+		return ErrDayAlreadyReserved
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM desk_releases WHERE assignment_id = $1 AND day = $2::date`, assignmentId, day)
+	if err != nil {
+		return fmt.Errorf("failed to reclaim day: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package closures
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"place-picker/internal/offices"
+
+	"github.com/lib/pq"
+)
+
+var ErrInvalidClosure = errors.New("closure must end on or after its first day")
+
+type (
+	ClosuresRepository struct {
+		db *sql.DB
+	}
+
+	// Закрытие офиса. DateFrom и DateTo — местные даты в формате YYYY-MM-DD, DateTo включительно.
+	Closure struct {
+		Id        string    `json:"id"`
+		Office    string    `json:"office"`
+		DateFrom  string    `json:"dateFrom"`
+		DateTo    string    `json:"dateTo"`
+		Reason    string    `json:"reason"`
+		SourceUid *string   `json:"sourceUid"`
+		CreatedBy *string   `json:"createdBy"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+
+	// Бронь, отмененная из-за закрытия офиса. UserEmail — занимающий стол или пригласивший гостя.
+	CancelledReservation struct {
+		ReservationId string
+		UserId        string
+		UserEmail     string
+		DeskName      string
+		DeskOffice    string
+		GuestName     *string
+		DateFrom      time.Time
+		DateTo        time.Time
+		Reason        string
+	}
+)
+
+func NewClosuresRepository(db *sql.DB) *ClosuresRepository {
+	return &ClosuresRepository{db: db}
+}
+
+// Закрытия офиса, пересекающие интервал дат [from, to].
+func (r *ClosuresRepository) GetClosures(ctx context.Context, office, from, to string) ([]Closure, error) {
+	query := `
+		SELECT id, office, to_char(date_from, 'YYYY-MM-DD'), to_char(date_to, 'YYYY-MM-DD'),
+			reason, source_uid, created_by, created_at
+		FROM office_closures
+		WHERE office = $1
+		  AND date_to >= $2::date
+		  AND date_from <= $3::date
+		ORDER BY date_from
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, office, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query closures: %w", err)
+	}
+	defer rows.Close()
+
+	closures := []Closure{}
+	for rows.Next() {
+		var c Closure
+		if err := rows.Scan(&c.Id, &c.Office, &c.DateFrom, &c.DateTo, &c.Reason, &c.SourceUid, &c.CreatedBy, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan closure: %w", err)
+		}
+		closures = append(closures, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return closures, nil
+}
+
+// Сохраняет закрытия офиса и в той же транзакции отменяет брони, которые на них приходятся.
+// Закрытие с уже импортированным SourceUid заменяет прежнее. Столы без офиса относятся к офису defaultOfficeKey.
+// Возвращает идентификаторы закрытий и отмененные брони, чтобы уведомить их владельцев.
+func (r *ClosuresRepository) SaveClosures(ctx context.Context, office offices.Office, defaultOfficeKey, createdBy string, closures []Closure) ([]string, []CancelledReservation, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO office_closures (office, date_from, date_to, reason, source_uid, created_by)
+		VALUES ($1, $2::date, $3::date, $4, $5, $6)
+		ON CONFLICT (office, source_uid) DO UPDATE SET
+			date_from = EXCLUDED.date_from,
+			date_to = EXCLUDED.date_to,
+			reason = EXCLUDED.reason
+		RETURNING id
+	`
+
+	ids := make([]string, 0, len(closures))
+	cancelled := []CancelledReservation{}
+	for _, closure := range closures {
+		var id string
+		err := tx.QueryRowContext(ctx, query, office.Key, closure.DateFrom, closure.DateTo, closure.Reason, closure.SourceUid, createdBy).Scan(&id)
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Constraint == "office_closure_period_valid" {
+				return nil, nil, ErrInvalidClosure
+			}
+			return nil, nil, fmt.Errorf("failed to save closure: %w", err)
+		}
+		ids = append(ids, id)
+
+		reservations, err := cancelClosedReservations(ctx, tx, office, defaultOfficeKey, createdBy, closure)
+		if err != nil {
+			return nil, nil, err
+		}
+		cancelled = append(cancelled, reservations...)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return ids, cancelled, nil
+}
+
+// Отменяет будущие брони офиса, которые начинаются в закрытые дни. Причина закрытия становится причиной отмены.
+func cancelClosedReservations(ctx context.Context, tx *sql.Tx, office offices.Office, defaultOfficeKey, cancelledBy string, closure Closure) ([]CancelledReservation, error) {
+	query := `
+		UPDATE reservations r
+		SET status = 'cancelled', cancelled_at = NOW(), cancelled_by = $6, cancellation_reason = $7, updated_at = NOW()
+		FROM desks d, users u
+		WHERE d.id = r.desk_id
+		  AND u.id = r.user_id
+		  AND COALESCE(d.office, $1) = $2
+		  AND r.status IN ('active', 'pending')
+		  AND r.date_to > NOW()
+		  AND (r.date_from AT TIME ZONE $3)::date BETWEEN $4::date AND $5::date
+		RETURNING r.id, u.id, u.email, d.name, COALESCE(d.office, ''), r.guest_name, r.date_from, r.date_to
+	`
+
+	rows, err := tx.QueryContext(ctx, query, defaultOfficeKey, office.Key, office.Timezone, closure.DateFrom, closure.DateTo, cancelledBy, closure.Reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel reservations in closure: %w", err)
+	}
+	defer rows.Close()
+
+	var cancelled []CancelledReservation
+	for rows.Next() {
+		var c CancelledReservation
+		if err := rows.Scan(&c.ReservationId, &c.UserId, &c.UserEmail, &c.DeskName, &c.DeskOffice, &c.GuestName, &c.DateFrom, &c.DateTo); err != nil {
+			return nil, fmt.Errorf("failed to scan cancelled reservation: %w", err)
+		}
+		c.Reason = closure.Reason
+		cancelled = append(cancelled, c)
+	}
+
+	return cancelled, rows.Err()
+}
+
+// Удаляет закрытие. Отмененные из-за него брони не восстанавливаются.
+func (r *ClosuresRepository) DeleteClosure(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM office_closures WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete closure: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
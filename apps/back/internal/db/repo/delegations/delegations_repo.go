@@ -0,0 +1,127 @@
+package delegations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var ErrDelegationExists = errors.New("delegation already granted")
+var ErrSelfDelegation = errors.New("cannot delegate to yourself")
+var ErrUserNotFound = errors.New("user not found")
+
+type (
+	DelegationsRepository struct {
+		db *sql.DB
+	}
+
+	// Grant разрешает DelegateId бронировать столы за UserId.
+	Grant struct {
+		Id           string    `json:"id"`
+		UserId       string    `json:"userId"`
+		UserName     string    `json:"userName"`
+		DelegateId   string    `json:"delegateId"`
+		DelegateName string    `json:"delegateName"`
+		CreatedAt    time.Time `json:"createdAt"`
+	}
+)
+
+func NewDelegationsRepository(db *sql.DB) *DelegationsRepository {
+	return &DelegationsRepository{db: db}
+}
+
+// Возвращает разрешения, которые пользователь выдал, и разрешения, выданные ему.
+func (r *DelegationsRepository) GetUserGrants(ctx context.Context, userId string) (granted []Grant, received []Grant, err error) {
+	query := `
+		SELECT g.id, g.user_id, u.name, g.delegate_id, d.name, g.created_at
+		FROM delegation_grants g
+		JOIN users u ON u.id = g.user_id
+		JOIN users d ON d.id = g.delegate_id
+		WHERE g.user_id = $1 OR g.delegate_id = $1
+		ORDER BY g.created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query delegation grants: %w", err)
+	}
+	defer rows.Close()
+
+	granted, received = []Grant{}, []Grant{}
+	for rows.Next() {
+		var g Grant
+		if err := rows.Scan(&g.Id, &g.UserId, &g.UserName, &g.DelegateId, &g.DelegateName, &g.CreatedAt); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan delegation grant: %w", err)
+		}
+
+		if g.UserId == userId {
+			granted = append(granted, g)
+		} else {
+			received = append(received, g)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return granted, received, nil
+}
+
+// Разрешает delegateId бронировать столы за userId.
+func (r *DelegationsRepository) CreateGrant(ctx context.Context, userId, delegateId string) (string, error) {
+	if userId == delegateId {
+		return "", ErrSelfDelegation
+	}
+
+	query := `
+		INSERT INTO delegation_grants (user_id, delegate_id)
+		VALUES ($1, $2)
+		RETURNING id
+	`
+
+	var id string
+	if err := r.db.QueryRowContext(ctx, query, userId, delegateId).Scan(&id); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			switch pqErr.Code {
+			case "23505":
+				return "", ErrDelegationExists
+			case "23503":
+				return "", ErrUserNotFound
+			}
+		}
+		return "", fmt.Errorf("failed to create delegation grant: %w", err)
+	}
+
+	return id, nil
+}
+
+// Отзывает разрешение. Отозвать его может как выдавший, так и получивший пользователь.
+// Брони, уже оформленные по разрешению, остаются.
+func (r *DelegationsRepository) DeleteGrant(ctx context.Context, grantId, userId string) error {
+	query := `
+		DELETE FROM delegation_grants
+		WHERE id = $1 AND (user_id = $2 OR delegate_id = $2)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, grantId, userId)
+	if err != nil {
+		return fmt.Errorf("failed to delete delegation grant: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check delete result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -10,25 +10,74 @@ import (
 	"time"
 
 	"github.com/lib/pq"
+
+	"place-picker/internal/offices"
 )
 
+var ErrZoneNotFound = errors.New("zone not found")
+var ErrZoneInAnotherOffice = errors.New("zone already has desks in another office")
+var ErrGroupNotFound = errors.New("group not found")
+var ErrApproverNotFound = errors.New("approver not found")
+var ErrResourceNameTaken = errors.New("resource with this name already exists")
+
 type (
 	DesksRepository struct {
 		db *sql.DB
 	}
 
+	// Status — active, pending, если бронь ждет подтверждения, или cleaning — уборка после брони.
 	TimeSlot struct {
 		DateFrom time.Time `json:"dateFrom"`
 		DateTo   time.Time `json:"dateTo"`
+		Status   string    `json:"status"`
+	}
+
+	Assignment struct {
+		Id           string   `json:"id"`
+		UserId       string   `json:"userId"`
+		UserName     string   `json:"userName"`
+		DateFrom     *string  `json:"dateFrom"`
+		DateTo       *string  `json:"dateTo"`
+		ReleasedDays []string `json:"releasedDays"`
+	}
+
+	GroupRef struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
 	}
 
+	// Координаты стола на плане этажа.
+	Position struct {
+		X float64 `json:"x"`
+		Y float64 `json:"y"`
+	}
+
+	// Ограничение бронирования стола группами пользователей.
+	// IsMember показывает, состоит ли текущий пользователь в одной из разрешенных групп.
+	Restriction struct {
+		Groups         []GroupRef `json:"groups"`
+		OpenDaysBefore *int       `json:"openDaysBefore"`
+		IsMember       bool       `json:"isMember"`
+	}
+
+	// Бронируемый ресурс: стол, переговорная, парковочное место или шкафчик.
 	Desk struct {
-		Id            string     `json:"id"`
-		Name          string     `json:"name"`
-		Reserved      bool       `json:"reserved"`
-		CreatedAt     time.Time  `json:"createdAt"`
-		UpdatedAt     time.Time  `json:"updatedAt"`
-		ReservedSlots []TimeSlot `json:"reservedSlots"`
+		Id                    string       `json:"id"`
+		Name                  string       `json:"name"`
+		Type                  string       `json:"type"`
+		Capacity              *int         `json:"capacity"`
+		ZoneId                *string      `json:"zoneId"`
+		ZoneName              *string      `json:"zoneName"`
+		Office                *string      `json:"office"`
+		Position              *Position    `json:"position"`
+		Reserved              bool         `json:"reserved"`
+		RequiresApproval      bool         `json:"requiresApproval"`
+		CleaningBufferMinutes int          `json:"cleaningBufferMinutes"`
+		CreatedAt             time.Time    `json:"createdAt"`
+		UpdatedAt             time.Time    `json:"updatedAt"`
+		ReservedSlots         []TimeSlot   `json:"reservedSlots"`
+		Assignments           []Assignment `json:"assignments"`
+		Restriction           *Restriction `json:"restriction"`
 	}
 )
 
@@ -73,11 +122,29 @@ func (r *DesksRepository) CreateDesks(ctx context.Context, desks []string) error
 	return nil
 }
 
-func (r *DesksRepository) GetAllDesks(ctx context.Context) ([]Desk, error) {
+// Возвращает ресурсы одного типа, например столы.
+func (r *DesksRepository) GetAllDesks(ctx context.Context, userId, resourceType string) ([]Desk, error) {
 	query := `
+		WITH desk_allowed_groups AS (
+			SELECT dg.desk_id, dg.group_id
+			FROM desk_group_restrictions dg
+			UNION
+			SELECT d.id, zg.group_id
+			FROM desks d
+			JOIN zone_group_restrictions zg ON zg.zone_id = d.zone_id
+		)
 		SELECT 
 			d.id,
 			d.name,
+			d.type,
+			d.capacity,
+			d.zone_id,
+			z.name,
+			d.office,
+			d.pos_x,
+			d.pos_y,
+			d.requires_approval,
+			COALESCE(d.cleaning_buffer_minutes, z.cleaning_buffer_minutes, 0),
 			d.created_at,
 			d.updated_at,
 			COALESCE(
@@ -86,19 +153,76 @@ func (r *DesksRepository) GetAllDesks(ctx context.Context) ([]Desk, error) {
 						WHEN r.id IS NOT NULL THEN 
 							json_build_object(
 								'dateFrom', r.date_from,
-								'dateTo', r.date_to
+								'dateTo', r.date_to,
+								'status', r.status
 							)
 					END
 				) FILTER (WHERE r.id IS NOT NULL),
 				'[]'::json
-			) AS reserved_slots
+			) AS reserved_slots,
+			COALESCE(
+				json_agg(
+					json_build_object(
+						'dateFrom', r.date_to,
+						'dateTo', r.occupied_until,
+						'status', 'cleaning'
+					)
+				) FILTER (WHERE r.occupied_until > r.date_to),
+				'[]'::json
+			) AS cleaning_slots,
+			COALESCE(
+				(
+					SELECT json_agg(
+						json_build_object(
+							'id', a.id,
+							'userId', a.user_id,
+							'userName', u.name,
+							'dateFrom', to_char(a.date_from, 'YYYY-MM-DD'),
+							'dateTo', to_char(a.date_to, 'YYYY-MM-DD'),
+							'releasedDays', COALESCE(
+								(
+									SELECT json_agg(to_char(dr.day, 'YYYY-MM-DD') ORDER BY dr.day)
+									FROM desk_releases dr
+									WHERE dr.assignment_id = a.id AND dr.day >= CURRENT_DATE
+								),
+								'[]'::json
+							)
+						)
+						ORDER BY a.date_from NULLS FIRST
+					)
+					FROM desk_assignments a
+					JOIN users u ON u.id = a.user_id
+					WHERE a.desk_id = d.id AND (a.date_to IS NULL OR a.date_to >= CURRENT_DATE)
+				),
+				'[]'::json
+			) AS assignments,
+			COALESCE(
+				(
+					SELECT json_agg(json_build_object('id', g.id, 'name', g.name) ORDER BY g.name)
+					FROM desk_allowed_groups ag
+					JOIN user_groups g ON g.id = ag.group_id
+					WHERE ag.desk_id = d.id
+				),
+				'[]'::json
+			) AS restriction_groups,
+			CASE
+				WHEN NOT EXISTS (SELECT 1 FROM desk_group_restrictions dg WHERE dg.desk_id = d.id) THEN z.open_days_before
+			END AS open_days_before,
+			EXISTS (
+				SELECT 1
+				FROM desk_allowed_groups ag
+				JOIN user_group_members m ON m.group_id = ag.group_id
+				WHERE ag.desk_id = d.id AND m.user_id = $1
+			) AS is_member
 		FROM desks d
-		LEFT JOIN reservations r ON d.id = r.desk_id
-		GROUP BY d.id, d.name, d.created_at, d.updated_at
+		LEFT JOIN zones z ON z.id = d.zone_id
+		LEFT JOIN reservations r ON d.id = r.desk_id AND r.status IN ('active', 'pending')
+		WHERE d.type = $2
+		GROUP BY d.id, d.name, d.type, d.capacity, d.zone_id, z.name, z.open_days_before, z.cleaning_buffer_minutes, d.office, d.pos_x, d.pos_y, d.requires_approval, d.cleaning_buffer_minutes, d.created_at, d.updated_at
 		ORDER BY d.created_at;
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, userId, resourceType)
 	if err != nil {
 		return nil, fmt.Errorf("GetAllDesks | failed to query desks: %w", err)
 	}
@@ -107,11 +231,36 @@ func (r *DesksRepository) GetAllDesks(ctx context.Context) ([]Desk, error) {
 	var desks []Desk
 	for rows.Next() {
 		var (
-			d         Desk
-			slotsJSON []byte
+			d               Desk
+			slotsJSON       []byte
+			cleaningJSON    []byte
+			assignmentsJSON []byte
+			groupsJSON      []byte
+			restriction     Restriction
+			posX, posY      sql.NullFloat64
 		)
 
-		if err := rows.Scan(&d.Id, &d.Name, &d.CreatedAt, &d.UpdatedAt, &slotsJSON); err != nil {
+		if err := rows.Scan(
+			&d.Id,
+			&d.Name,
+			&d.Type,
+			&d.Capacity,
+			&d.ZoneId,
+			&d.ZoneName,
+			&d.Office,
+			&posX,
+			&posY,
+			&d.RequiresApproval,
+			&d.CleaningBufferMinutes,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+			&slotsJSON,
+			&cleaningJSON,
+			&assignmentsJSON,
+			&groupsJSON,
+			&restriction.OpenDaysBefore,
+			&restriction.IsMember,
+		); err != nil {
 			return nil, fmt.Errorf("GetAllDesks | failed to scan row: %w", err)
 		}
 
@@ -119,8 +268,31 @@ func (r *DesksRepository) GetAllDesks(ctx context.Context) ([]Desk, error) {
 			return nil, fmt.Errorf("GetAllDesks | failed to unmarshal reserved slots: %w", err)
 		}
 
+		if err := json.Unmarshal(assignmentsJSON, &d.Assignments); err != nil {
+			return nil, fmt.Errorf("GetAllDesks | failed to unmarshal assignments: %w", err)
+		}
+
+		if err := json.Unmarshal(groupsJSON, &restriction.Groups); err != nil {
+			return nil, fmt.Errorf("GetAllDesks | failed to unmarshal restriction groups: %w", err)
+		}
+
+		if len(restriction.Groups) > 0 {
+			d.Restriction = &restriction
+		}
+
+		if posX.Valid && posY.Valid {
+			d.Position = &Position{X: posX.Float64, Y: posY.Float64}
+		}
+
 		d.Reserved = len(d.ReservedSlots) > 0
 
+		// Уборка не делает стол забронированным, но время на нее недоступно для брони.
+		var cleaningSlots []TimeSlot
+		if err := json.Unmarshal(cleaningJSON, &cleaningSlots); err != nil {
+			return nil, fmt.Errorf("GetAllDesks | failed to unmarshal cleaning slots: %w", err)
+		}
+		d.ReservedSlots = append(d.ReservedSlots, cleaningSlots...)
+
 		desks = append(desks, d)
 	}
 
@@ -153,6 +325,253 @@ func (r *DesksRepository) UpdateDeskName(ctx context.Context, id string, newName
 	return nil
 }
 
+// Переносит стол в зону. Стол можно перенести только в зону, все столы которой в том же офисе.
+func (r *DesksRepository) SetDeskZone(ctx context.Context, id string, zoneId *string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var office *string
+	if err := tx.QueryRowContext(ctx, `SELECT office FROM desks WHERE id = $1 FOR UPDATE`, id).Scan(&office); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("failed to lock desk: %w", err)
+	}
+
+	if zoneId != nil {
+		if err := checkZoneOffice(ctx, tx, *zoneId, &id, office); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE desks SET zone_id = $1, updated_at = NOW() WHERE id = $2`, zoneId, id); err != nil {
+		return fmt.Errorf("failed to update desk zone: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Зона целиком находится в одном офисе: по нему считаются ее лимит занятости и часовой пояс.
+// Проверяет, что кроме стола deskId в зоне нет столов из других офисов. Строка зоны блокируется,
+// чтобы параллельные изменения столов одной зоны проверялись по очереди.
+func checkZoneOffice(ctx context.Context, tx *sql.Tx, zoneId string, deskId, office *string) error {
+	var locked string
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM zones WHERE id = $1 FOR UPDATE`, zoneId).Scan(&locked); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrZoneNotFound
+		}
+		return fmt.Errorf("failed to lock zone: %w", err)
+	}
+
+	defaultOffice, err := offices.Get("")
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM desks
+			WHERE zone_id = $1
+			  AND ($2::uuid IS NULL OR id <> $2::uuid)
+			  AND COALESCE(office, $4) <> COALESCE($3, $4)
+		)
+	`
+	var mixed bool
+	if err := tx.QueryRowContext(ctx, query, zoneId, deskId, office, defaultOffice.Key).Scan(&mixed); err != nil {
+		return fmt.Errorf("failed to check zone office: %w", err)
+	}
+	if mixed {
+		return ErrZoneInAnotherOffice
+	}
+
+	return nil
+}
+
+// Переносит стол в другой офис. nil возвращает стол в офис по умолчанию.
+// Стол в зоне переносится, только если в зоне нет других столов: зона не может быть в двух офисах.
+func (r *DesksRepository) SetDeskOffice(ctx context.Context, id string, office *string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var zoneId *string
+	if err := tx.QueryRowContext(ctx, `SELECT zone_id FROM desks WHERE id = $1 FOR UPDATE`, id).Scan(&zoneId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("failed to lock desk: %w", err)
+	}
+
+	if zoneId != nil {
+		if err := checkZoneOffice(ctx, tx, *zoneId, &id, office); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE desks SET office = $1, updated_at = NOW() WHERE id = $2`, office, id); err != nil {
+		return fmt.Errorf("failed to update desk office: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Задает время на уборку после брони стола. nil берет значение зоны.
+// Новое значение действует на брони, созданные или перенесенные после изменения.
+func (r *DesksRepository) SetDeskCleaningBuffer(ctx context.Context, id string, minutes *int) error {
+	query := `UPDATE desks SET cleaning_buffer_minutes = $1, updated_at = NOW() WHERE id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, minutes, id)
+	if err != nil {
+		return fmt.Errorf("failed to update desk cleaning buffer: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Задает координаты стола на плане этажа. nil убирает стол с плана.
+func (r *DesksRepository) SetDeskPosition(ctx context.Context, id string, position *Position) error {
+	var x, y *float64
+	if position != nil {
+		x, y = &position.X, &position.Y
+	}
+
+	query := `UPDATE desks SET pos_x = $1, pos_y = $2, updated_at = NOW() WHERE id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, x, y, id)
+	if err != nil {
+		return fmt.Errorf("failed to update desk position: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Включает или выключает подтверждение броней стола и заменяет список подтверждающих.
+// При выключении брони стола, ожидающие подтверждения, становятся активными.
+func (r *DesksRepository) SetDeskApproval(ctx context.Context, id string, requiresApproval bool, approverIds []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE desks SET requires_approval = $1, updated_at = NOW() WHERE id = $2`, requiresApproval, id)
+	if err != nil {
+		return fmt.Errorf("failed to update desk approval: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM desk_approvers WHERE desk_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to clear desk approvers: %w", err)
+	}
+
+	query := `
+		INSERT INTO desk_approvers (desk_id, user_id)
+		SELECT $1, u FROM unnest($2::uuid[]) AS u
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, query, id, pq.Array(approverIds)); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrApproverNotFound
+		}
+		return fmt.Errorf("failed to set desk approvers: %w", err)
+	}
+
+	if !requiresApproval {
+		query := `
+			UPDATE reservations
+			SET status = 'active', updated_at = NOW()
+			WHERE desk_id = $1 AND status = 'pending'
+		`
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return fmt.Errorf("failed to activate pending reservations: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Заменяет список групп, которым разрешено бронировать стол. Пустой список снимает ограничение стола.
+func (r *DesksRepository) SetDeskGroups(ctx context.Context, id string, groupIds []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM desks WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check desk: %w", err)
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM desk_group_restrictions WHERE desk_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to clear desk groups: %w", err)
+	}
+
+	query := `
+		INSERT INTO desk_group_restrictions (desk_id, group_id)
+		SELECT $1, g FROM unnest($2::uuid[]) AS g
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, query, id, pq.Array(groupIds)); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrGroupNotFound
+		}
+		return fmt.Errorf("failed to set desk groups: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
 func (r *DesksRepository) DeleteDesk(ctx context.Context, id string) error {
 	query := `DELETE FROM desks WHERE id = $1`
 
@@ -0,0 +1,156 @@
+package desks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Тип стола. Столы создаются по умолчанию и возвращаются старым API /desks.
+const ResourceTypeDesk = "desk"
+
+var ErrUnknownResourceType = errors.New("unknown resource type")
+
+type (
+	// Правила типа ресурса. Пустые часы работы означают часы офиса.
+	ResourceType struct {
+		Key                string  `json:"key"`
+		Name               string  `json:"name"`
+		WorkStart          *string `json:"workStart"`
+		WorkEnd            *string `json:"workEnd"`
+		MinDurationMinutes *int    `json:"minDurationMinutes"`
+		MaxDurationMinutes *int    `json:"maxDurationMinutes"`
+		AllowsAttendees    bool    `json:"allowsAttendees"`
+		CheckInRequired    bool    `json:"checkInRequired"`
+	}
+
+	NewResource struct {
+		Type     string
+		Name     string
+		ZoneId   *string
+		Office   *string
+		Capacity *int
+	}
+)
+
+func (r *DesksRepository) GetResourceTypes(ctx context.Context) ([]ResourceType, error) {
+	query := `
+		SELECT key, name, to_char(work_start, 'HH24:MI'), to_char(work_end, 'HH24:MI'),
+			min_duration_minutes, max_duration_minutes, allows_attendees, checkin_required
+		FROM resource_types
+		ORDER BY key
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query resource types: %w", err)
+	}
+	defer rows.Close()
+
+	types := []ResourceType{}
+	for rows.Next() {
+		var t ResourceType
+		if err := rows.Scan(&t.Key, &t.Name, &t.WorkStart, &t.WorkEnd, &t.MinDurationMinutes, &t.MaxDurationMinutes, &t.AllowsAttendees, &t.CheckInRequired); err != nil {
+			return nil, fmt.Errorf("failed to scan resource type: %w", err)
+		}
+		types = append(types, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return types, nil
+}
+
+// Заменяет правила типа ресурса. Новые правила применяются к следующим броням, существующие брони не меняются.
+func (r *DesksRepository) UpdateResourceType(ctx context.Context, t ResourceType) error {
+	query := `
+		UPDATE resource_types
+		SET name = $2, work_start = $3::time, work_end = $4::time,
+			min_duration_minutes = $5, max_duration_minutes = $6,
+			allows_attendees = $7, checkin_required = $8
+		WHERE key = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, t.Key, t.Name, t.WorkStart, t.WorkEnd, t.MinDurationMinutes, t.MaxDurationMinutes, t.AllowsAttendees, t.CheckInRequired)
+	if err != nil {
+		return fmt.Errorf("failed to update resource type: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrUnknownResourceType
+	}
+
+	return nil
+}
+
+// Создает ресурс указанного типа и возвращает его идентификатор.
+// Ресурс можно добавить только в зону, все столы которой в том же офисе.
+func (r *DesksRepository) CreateResource(ctx context.Context, resource NewResource) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if resource.ZoneId != nil {
+		if err := checkZoneOffice(ctx, tx, *resource.ZoneId, nil, resource.Office); err != nil {
+			return "", err
+		}
+	}
+
+	query := `
+		INSERT INTO desks (type, name, zone_id, office, capacity)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	var id string
+	err = tx.QueryRowContext(ctx, query, resource.Type, resource.Name, resource.ZoneId, resource.Office, resource.Capacity).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			switch {
+			case pqErr.Code == "23505":
+				return "", ErrResourceNameTaken
+			case pqErr.Code == "23503" && pqErr.Constraint == "desks_type_fkey":
+				return "", ErrUnknownResourceType
+			case pqErr.Code == "23503":
+				return "", ErrZoneNotFound
+			}
+		}
+		return "", fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return id, nil
+}
+
+// Задает вместимость ресурса. nil снимает ограничение.
+func (r *DesksRepository) SetResourceCapacity(ctx context.Context, id string, capacity *int) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE desks SET capacity = $1, updated_at = NOW() WHERE id = $2`, capacity, id)
+	if err != nil {
+		return fmt.Errorf("failed to update resource capacity: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
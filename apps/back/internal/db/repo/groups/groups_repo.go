@@ -0,0 +1,175 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var ErrGroupAlreadyExists = errors.New("group with this name already exists")
+var ErrUserNotFound = errors.New("user not found")
+
+type (
+	GroupsRepository struct {
+		db *sql.DB
+	}
+
+	Group struct {
+		Id           string    `json:"id"`
+		Name         string    `json:"name"`
+		MembersCount int       `json:"membersCount"`
+		CreatedAt    time.Time `json:"createdAt"`
+		UpdatedAt    time.Time `json:"updatedAt"`
+	}
+
+	Member struct {
+		UserId  string    `json:"userId"`
+		Name    string    `json:"name"`
+		Email   string    `json:"email"`
+		AddedAt time.Time `json:"addedAt"`
+	}
+)
+
+func NewGroupsRepository(db *sql.DB) *GroupsRepository {
+	return &GroupsRepository{db: db}
+}
+
+func (r *GroupsRepository) CreateGroup(ctx context.Context, name string) (string, error) {
+	query := `INSERT INTO user_groups (name) VALUES ($1) RETURNING id`
+
+	var id string
+	if err := r.db.QueryRowContext(ctx, query, name).Scan(&id); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return "", ErrGroupAlreadyExists
+		}
+		return "", fmt.Errorf("failed to create group: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *GroupsRepository) GetAllGroups(ctx context.Context) ([]Group, error) {
+	query := `
+		SELECT g.id, g.name, COUNT(m.user_id), g.created_at, g.updated_at
+		FROM user_groups g
+		LEFT JOIN user_group_members m ON m.group_id = g.id
+		GROUP BY g.id, g.name, g.created_at, g.updated_at
+		ORDER BY g.name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []Group{}
+	for rows.Next() {
+		var g Group
+		if err := rows.Scan(&g.Id, &g.Name, &g.MembersCount, &g.CreatedAt, &g.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan group: %w", err)
+		}
+		groups = append(groups, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func (r *GroupsRepository) DeleteGroup(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM user_groups WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *GroupsRepository) GetGroupMembers(ctx context.Context, groupId string) ([]Member, error) {
+	query := `
+		SELECT u.id, u.name, u.email, m.created_at
+		FROM user_group_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.group_id = $1
+		ORDER BY u.name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, groupId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query group members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []Member{}
+	for rows.Next() {
+		var m Member
+		if err := rows.Scan(&m.UserId, &m.Name, &m.Email, &m.AddedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan group member: %w", err)
+		}
+		members = append(members, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (r *GroupsRepository) AddMember(ctx context.Context, groupId, userId string) error {
+	query := `
+		INSERT INTO user_group_members (group_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (group_id, user_id) DO NOTHING
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, groupId, userId); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			switch pqErr.Constraint {
+			case "user_group_members_group_id_fkey":
+				return sql.ErrNoRows
+			case "user_group_members_user_id_fkey":
+				return ErrUserNotFound
+			}
+		}
+		return fmt.Errorf("failed to add group member: %w", err)
+	}
+
+	return nil
+}
+
+func (r *GroupsRepository) RemoveMember(ctx context.Context, groupId, userId string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM user_group_members WHERE group_id = $1 AND user_id = $2`, groupId, userId)
+	if err != nil {
+		return fmt.Errorf("failed to remove group member: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type (
+	IdempotencyRepository struct {
+		db *sql.DB
+	}
+
+	// Сохраненный запрос с ключом идемпотентности. StatusCode пустой, пока запрос выполняется.
+	Record struct {
+		Fingerprint  string
+		StatusCode   *int
+		ContentType  string
+		ResponseBody []byte
+	}
+)
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Занимает ключ пользователя для запроса с отпечатком fingerprint на ttl. Если ключ свободен
+// или его срок истек, возвращает nil. Иначе возвращает уже сохраненный запрос.
+func (r *IdempotencyRepository) Acquire(ctx context.Context, userId, key, fingerprint string, ttl time.Duration) (*Record, error) {
+	query := `
+		INSERT INTO idempotency_keys (user_id, key, fingerprint, expires_at)
+		VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+			status_code = NULL,
+			content_type = NULL,
+			response_body = NULL,
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+	`
+
+	result, err := r.db.ExecContext(ctx, query, userId, key, fingerprint, ttl.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire idempotency key: %w", err)
+	}
+
+	acquired, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check idempotency key result: %w", err)
+	}
+
+	if acquired > 0 {
+		return nil, nil
+	}
+
+	var record Record
+	var contentType sql.NullString
+	err = r.db.QueryRowContext(ctx, `
+		SELECT fingerprint, status_code, content_type, response_body
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2
+	`, userId, key).Scan(&record.Fingerprint, &record.StatusCode, &contentType, &record.ResponseBody)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("idempotency key was released concurrently: %w", err)
+		}
+		return nil, fmt.Errorf("failed to load idempotency key: %w", err)
+	}
+	record.ContentType = contentType.String
+
+	return &record, nil
+}
+
+// Сохраняет ответ на запрос с занятым ключом.
+func (r *IdempotencyRepository) Complete(ctx context.Context, userId, key string, statusCode int, contentType string, body []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $3, content_type = $4, response_body = $5
+		WHERE user_id = $1 AND key = $2
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, userId, key, statusCode, contentType, body); err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+// Освобождает ключ, чтобы запрос можно было повторить с тем же ключом.
+func (r *IdempotencyRepository) Release(ctx context.Context, userId, key string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2`, userId, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Удаляет ключи с истекшим сроком и возвращает их число.
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check delete result: %w", err)
+	}
+
+	return rowsAffected, nil
+}
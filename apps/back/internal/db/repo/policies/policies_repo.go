@@ -0,0 +1,177 @@
+package policies
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Роль, правила которой применяются к ролям без собственных правил.
+const DefaultRole = "default"
+
+var ErrDefaultPolicyRequired = errors.New("default policy cannot be deleted")
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+type (
+	PoliciesRepository struct {
+		db *sql.DB
+	}
+
+	// Policy задает ограничения бронирования для роли. nil означает отсутствие ограничения.
+	Policy struct {
+		Role               string    `json:"role"`
+		MaxDaysAhead       *int      `json:"maxDaysAhead"`
+		MaxPerWeek         *int      `json:"maxPerWeek"`
+		MaxPerMonth        *int      `json:"maxPerMonth"`
+		MinDurationMinutes *int      `json:"minDurationMinutes"`
+		MaxDurationMinutes *int      `json:"maxDurationMinutes"`
+		BlackoutWeekdays   []int64   `json:"blackoutWeekdays"`
+		UpdatedAt          time.Time `json:"updatedAt"`
+	}
+)
+
+const policyColumns = `
+	role,
+	max_days_ahead,
+	max_per_week,
+	max_per_month,
+	min_duration_minutes,
+	max_duration_minutes,
+	blackout_weekdays,
+	updated_at
+`
+
+func NewPoliciesRepository(db *sql.DB) *PoliciesRepository {
+	return &PoliciesRepository{db: db}
+}
+
+func scanPolicy(row interface{ Scan(...any) error }) (Policy, error) {
+	var p Policy
+	err := row.Scan(
+		&p.Role,
+		&p.MaxDaysAhead,
+		&p.MaxPerWeek,
+		&p.MaxPerMonth,
+		&p.MinDurationMinutes,
+		&p.MaxDurationMinutes,
+		(*pq.Int64Array)(&p.BlackoutWeekdays),
+		&p.UpdatedAt,
+	)
+	return p, err
+}
+
+func (r *PoliciesRepository) GetPolicies(ctx context.Context) ([]Policy, error) {
+	query := `SELECT ` + policyColumns + ` FROM booking_policies ORDER BY role = 'default' DESC, role`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []Policy{}
+	for rows.Next() {
+		p, err := scanPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// Возвращает правила, действующие для пользователя: правила его роли или правила по умолчанию.
+// Вызывается в транзакции создания брони, поэтому изменения правил применяются без перезапуска.
+func GetUserPolicy(ctx context.Context, tx *sql.Tx, userId string) (Policy, error) {
+	query := `
+		SELECT ` + policyColumns + `
+		FROM booking_policies p
+		WHERE p.role IN ((SELECT u.role FROM users u WHERE u.id = $1), 'default')
+		ORDER BY p.role = 'default'
+		LIMIT 1
+	`
+
+	p, err := scanPolicy(tx.QueryRowContext(ctx, query, userId))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Policy{Role: DefaultRole}, nil
+		}
+		return Policy{}, fmt.Errorf("failed to get user policy: %w", err)
+	}
+
+	return p, nil
+}
+
+// Создает или заменяет правила роли.
+func (r *PoliciesRepository) UpsertPolicy(ctx context.Context, p Policy) error {
+	query := `
+		INSERT INTO booking_policies (
+			role, max_days_ahead, max_per_week, max_per_month,
+			min_duration_minutes, max_duration_minutes, blackout_weekdays
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::smallint[])
+		ON CONFLICT (role) DO UPDATE SET
+			max_days_ahead = EXCLUDED.max_days_ahead,
+			max_per_week = EXCLUDED.max_per_week,
+			max_per_month = EXCLUDED.max_per_month,
+			min_duration_minutes = EXCLUDED.min_duration_minutes,
+			max_duration_minutes = EXCLUDED.max_duration_minutes,
+			blackout_weekdays = EXCLUDED.blackout_weekdays,
+			updated_at = NOW()
+	`
+
+	weekdays := p.BlackoutWeekdays
+	if weekdays == nil {
+		weekdays = []int64{}
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		p.Role,
+		p.MaxDaysAhead,
+		p.MaxPerWeek,
+		p.MaxPerMonth,
+		p.MinDurationMinutes,
+		p.MaxDurationMinutes,
+		pq.Array(weekdays),
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23514" {
+			return fmt.Errorf("%w: %s", ErrInvalidPolicy, pqErr.Constraint)
+		}
+		return fmt.Errorf("failed to save policy: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PoliciesRepository) DeletePolicy(ctx context.Context, role string) error {
+	if role == DefaultRole {
+		return ErrDefaultPolicyRequired
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM booking_policies WHERE role = $1`, role)
+	if err != nil {
+		return fmt.Errorf("failed to delete policy: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Наибольшее число броней в одном списке администратора.
+const adminReservationsLimit = 500
+
+type (
+	// Бронь в списке администратора. UserEmail — занимающий стол или пригласивший гостя.
+	AdminReservation struct {
+		Id                 string     `json:"id"`
+		DeskId             string     `json:"deskId"`
+		DeskName           string     `json:"deskName"`
+		DeskOffice         string     `json:"deskOffice"`
+		ResourceType       string     `json:"resourceType"`
+		UserId             string     `json:"userId"`
+		UserName           string     `json:"userName"`
+		UserEmail          string     `json:"userEmail"`
+		BookedBy           *string    `json:"bookedBy"`
+		GuestName          *string    `json:"guestName"`
+		SeriesId           *string    `json:"seriesId"`
+		Status             string     `json:"status"`
+		DateFrom           time.Time  `json:"dateFrom"`
+		DateTo             time.Time  `json:"dateTo"`
+		CheckedInAt        *time.Time `json:"checkedInAt"`
+		CancelledAt        *time.Time `json:"cancelledAt"`
+		CancelledBy        *string    `json:"cancelledBy"`
+		CancellationReason *string    `json:"cancellationReason"`
+	}
+
+	// Отбор броней для администратора. Пустые поля не ограничивают выборку.
+	// From и To выбирают брони, пересекающие интервал [From, To).
+	ReservationFilter struct {
+		Ids      []string
+		DeskId   *string
+		UserId   *string
+		From     *time.Time
+		To       *time.Time
+		Statuses []string
+	}
+)
+
+// Условие отбора броней r по ReservationFilter, параметры $1-$6.
+const reservationFilterCondition = `
+	(COALESCE(cardinality($1::uuid[]), 0) = 0 OR r.id = ANY($1::uuid[]))
+	AND ($2::uuid IS NULL OR r.desk_id = $2::uuid)
+	AND ($3::uuid IS NULL OR r.user_id = $3::uuid)
+	AND ($4::timestamptz IS NULL OR r.date_to > $4)
+	AND ($5::timestamptz IS NULL OR r.date_from < $5)
+	AND (COALESCE(cardinality($6::text[]), 0) = 0 OR r.status = ANY($6::text[]))
+`
+
+func (f ReservationFilter) args() []any {
+	return []any{pq.Array(f.Ids), f.DeskId, f.UserId, f.From, f.To, pq.Array(f.Statuses)}
+}
+
+const adminReservationColumns = `
+	r.id, d.id, d.name, COALESCE(d.office, ''), r.resource_type, u.id, u.name, u.email, r.booked_by,
+	r.guest_name, r.series_id, r.status, r.date_from, r.date_to, r.checked_in_at,
+	r.cancelled_at, r.cancelled_by, r.cancellation_reason
+`
+
+// Брони всех пользователей по отбору, не больше adminReservationsLimit, по времени начала.
+func (r *ReservationsRepository) GetReservations(ctx context.Context, filter ReservationFilter) ([]AdminReservation, error) {
+	query := `
+		SELECT ` + adminReservationColumns + `
+		FROM reservations r
+		JOIN desks d ON d.id = r.desk_id
+		JOIN users u ON u.id = r.user_id
+		WHERE ` + reservationFilterCondition + `
+		ORDER BY r.date_from, d.name
+		LIMIT ` + fmt.Sprint(adminReservationsLimit)
+
+	rows, err := r.db.QueryContext(ctx, query, filter.args()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reservations: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAdminReservations(rows)
+}
+
+// Отменяет от имени администратора еще не закончившиеся брони по отбору с указанной причиной
+// и возвращает отмененные брони. Статусы отбора не учитываются: отменяются только active и pending.
+// Дни отмененных вхождений серий добавляются в исключения серий.
+func (r *ReservationsRepository) CancelReservationsByAdmin(ctx context.Context, filter ReservationFilter, adminId, reason string) ([]AdminReservation, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	filter.Statuses = []string{StatusActive, StatusPending}
+
+	query := `
+		UPDATE reservations r
+		SET status = 'cancelled', cancelled_at = NOW(), cancelled_by = $7, cancellation_reason = $8, updated_at = NOW()
+		FROM desks d, users u
+		WHERE d.id = r.desk_id
+		  AND u.id = r.user_id
+		  AND r.date_to > NOW()
+		  AND ` + reservationFilterCondition + `
+		RETURNING ` + adminReservationColumns
+
+	rows, err := tx.QueryContext(ctx, query, append(filter.args(), adminId, reason)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel reservations: %w", err)
+	}
+
+	cancelled, err := scanAdminReservations(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, res := range cancelled {
+		if res.SeriesId == nil {
+			continue
+		}
+
+		office, err := deskOffice(ctx, tx, res.DeskId)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := addSeriesExDate(ctx, tx, *res.SeriesId, office.Day(res.DateFrom)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return cancelled, nil
+}
+
+func scanAdminReservations(rows *sql.Rows) ([]AdminReservation, error) {
+	reservations := []AdminReservation{}
+	for rows.Next() {
+		var res AdminReservation
+		err := rows.Scan(
+			&res.Id,
+			&res.DeskId,
+			&res.DeskName,
+			&res.DeskOffice,
+			&res.ResourceType,
+			&res.UserId,
+			&res.UserName,
+			&res.UserEmail,
+			&res.BookedBy,
+			&res.GuestName,
+			&res.SeriesId,
+			&res.Status,
+			&res.DateFrom,
+			&res.DateTo,
+			&res.CheckedInAt,
+			&res.CancelledAt,
+			&res.CancelledBy,
+			&res.CancellationReason,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		reservations = append(reservations, res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
@@ -0,0 +1,338 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"place-picker/internal/offices"
+)
+
+const (
+	// Сколько вариантов каждого вида возвращается клиенту.
+	alternativesLimit = 3
+	// На сколько дней вперед от запрошенного ищутся свободные окна на том же столе.
+	alternativeWindowDays = 7
+)
+
+type (
+	// Свободный стол той же зоны на запрошенное время. Distance — расстояние до запрошенного стола
+	// на плане этажа, null, если один из столов не размещен на плане.
+	AlternativeDesk struct {
+		DeskId           string   `json:"deskId"`
+		DeskName         string   `json:"deskName"`
+		ZoneName         *string  `json:"zoneName"`
+		Distance         *float64 `json:"distance"`
+		RequiresApproval bool     `json:"requiresApproval"`
+		Capacity         *int     `json:"capacity"`
+	}
+
+	// Свободное окно той же длительности на запрошенном столе. ShiftMinutes — сдвиг начала
+	// относительно запрошенного, отрицательный для более раннего окна.
+	AlternativeWindow struct {
+		DateFrom     time.Time `json:"dateFrom"`
+		DateTo       time.Time `json:"dateTo"`
+		ShiftMinutes int       `json:"shiftMinutes"`
+	}
+
+	// Варианты для занятого стола, от лучшего к худшему. ZoneOccupancy — занятость зоны стола
+	// в день брони, null для стола без зоны.
+	Alternatives struct {
+		Desks         []AlternativeDesk   `json:"desks"`
+		Windows       []AlternativeWindow `json:"windows"`
+		ZoneOccupancy *ZoneOccupancy      `json:"zoneOccupancy"`
+	}
+
+	alternativeCandidate struct {
+		AlternativeDesk
+		capacityGap int
+	}
+
+	busyInterval struct {
+		from, to time.Time
+	}
+)
+
+// Подбирает замену брони, которая не удалась из-за занятого стола: ближайшие свободные столы
+// той же зоны и того же типа на то же время и ближайшие свободные окна на том же столе.
+// Варианты проверяются по закреплениям, ограничениям групп, закрытиям офиса и лимитам зон для userId.
+// excludeId исключает переносимую бронь пользователя.
+func (r *ReservationsRepository) SuggestAlternatives(ctx context.Context, deskId, userId string, dateFrom, dateTo time.Time, excludeId *string) (Alternatives, error) {
+	alternatives := Alternatives{Desks: []AlternativeDesk{}, Windows: []AlternativeWindow{}}
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return alternatives, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := loadResource(ctx, tx, deskId)
+	if err != nil {
+		return alternatives, err
+	}
+
+	alternatives.Desks, err = alternativeDesks(ctx, tx, res, deskId, userId, dateFrom, dateTo, excludeId)
+	if err != nil {
+		return alternatives, err
+	}
+
+	alternatives.Windows, err = alternativeWindows(ctx, tx, res, deskId, userId, dateFrom, dateTo, excludeId)
+	if err != nil {
+		return alternatives, err
+	}
+
+	alternatives.ZoneOccupancy, err = deskZoneOccupancy(ctx, tx, res.Office, deskId, dateFrom, excludeId)
+	if err != nil {
+		return alternatives, err
+	}
+
+	return alternatives, nil
+}
+
+// Свободные ресурсы той же зоны и того же типа. Ранжируются по расстоянию на плане этажа,
+// затем по атрибутам: столы без подтверждения и с вместимостью ближе к запрошенной идут раньше.
+func alternativeDesks(ctx context.Context, tx *sql.Tx, res resource, deskId, userId string, dateFrom, dateTo time.Time, excludeId *string) ([]AlternativeDesk, error) {
+	defaultOffice, err := offices.Get("")
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT d.id, d.name, z.name, d.requires_approval, d.capacity,
+			sqrt(power(d.pos_x - o.pos_x, 2) + power(d.pos_y - o.pos_y, 2))
+		FROM desks o
+		JOIN desks d ON d.zone_id IS NOT DISTINCT FROM o.zone_id
+			AND d.type = o.type
+			AND COALESCE(d.office, $2) = COALESCE(o.office, $2)
+			AND d.id <> o.id
+		LEFT JOIN zones z ON z.id = d.zone_id
+		WHERE o.id = $1
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM reservations r
+			WHERE r.desk_id = d.id
+			  AND r.status IN ('active', 'pending')
+			  AND tstzrange(r.date_from, r.occupied_until, '[)')
+				&& tstzrange($3, $4 + make_interval(mins => COALESCE(d.cleaning_buffer_minutes, z.cleaning_buffer_minutes, 0)), '[)')
+		  )
+	`
+
+	rows, err := tx.QueryContext(ctx, query, deskId, defaultOffice.Key, dateFrom, dateTo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alternative desks: %w", err)
+	}
+
+	var candidates []alternativeCandidate
+	for rows.Next() {
+		var c alternativeCandidate
+		if err := rows.Scan(&c.DeskId, &c.DeskName, &c.ZoneName, &c.RequiresApproval, &c.Capacity, &c.Distance); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan alternative desk: %w", err)
+		}
+
+		if res.Capacity != nil && c.Capacity != nil {
+			c.capacityGap = *c.Capacity - *res.Capacity
+			if c.capacityGap < 0 {
+				c.capacityGap = -c.capacityGap
+			}
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if da, db := distanceOrInf(a.Distance), distanceOrInf(b.Distance); da != db {
+			return da < db
+		}
+		if a.RequiresApproval != b.RequiresApproval {
+			return !a.RequiresApproval
+		}
+		if a.capacityGap != b.capacityGap {
+			return a.capacityGap < b.capacityGap
+		}
+		return a.DeskName < b.DeskName
+	})
+
+	desks := []AlternativeDesk{}
+	for _, c := range candidates {
+		if len(desks) == alternativesLimit {
+			break
+		}
+
+		if err := checkDeskRules(ctx, tx, res.Office, c.DeskId, userId, dateFrom, excludeId); err != nil {
+			if isReservationRuleError(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		desks = append(desks, c.AlternativeDesk)
+	}
+
+	return desks, nil
+}
+
+func distanceOrInf(distance *float64) float64 {
+	if distance == nil {
+		return math.Inf(1)
+	}
+
+	return *distance
+}
+
+// Свободные окна той же длительности на столе в рабочие часы ближайших дней. Из каждого свободного
+// промежутка берется одно окно, ближайшее к запрошенному времени, а окна ранжируются по сдвигу от него.
+// Занятыми считаются брони стола и брони пользователя на ресурсы того же типа.
+func alternativeWindows(ctx context.Context, tx *sql.Tx, res resource, deskId, userId string, dateFrom, dateTo time.Time, excludeId *string) ([]AlternativeWindow, error) {
+	office := res.Office
+	duration := dateTo.Sub(dateFrom)
+	now := time.Now()
+
+	firstDay, _, err := office.DayBounds(office.Day(dateFrom))
+	if err != nil {
+		return nil, err
+	}
+	searchEnd := firstDay.AddDate(0, 0, alternativeWindowDays)
+
+	busy, err := busyIntervals(ctx, tx, deskId, userId, res.Type, firstDay, searchEnd, excludeId)
+	if err != nil {
+		return nil, err
+	}
+
+	workStart, err := time.Parse("15:04", office.WorkStart)
+	if err != nil {
+		return nil, fmt.Errorf("invalid work start: %w", err)
+	}
+	workEnd, err := time.Parse("15:04", office.WorkEnd)
+	if err != nil {
+		return nil, fmt.Errorf("invalid work end: %w", err)
+	}
+
+	var windows []AlternativeWindow
+	for day := firstDay; day.Before(searchEnd); day = day.AddDate(0, 0, 1) {
+		open := time.Date(day.Year(), day.Month(), day.Day(), workStart.Hour(), workStart.Minute(), 0, 0, office.Location)
+		closeAt := time.Date(day.Year(), day.Month(), day.Day(), workEnd.Hour(), workEnd.Minute(), 0, 0, office.Location)
+		if open.Before(now) {
+			open = now.Truncate(15 * time.Minute).Add(15 * time.Minute)
+		}
+		if closeAt.Sub(open) < duration {
+			continue
+		}
+
+		if err := checkDeskRules(ctx, tx, office, deskId, userId, open, excludeId); err != nil {
+			if isReservationRuleError(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		// Время запрошенного начала в этот день.
+		target := time.Date(day.Year(), day.Month(), day.Day(), dateFrom.In(office.Location).Hour(), dateFrom.In(office.Location).Minute(), 0, 0, office.Location)
+
+		gapStart := open
+		for _, b := range append(busy, busyInterval{from: closeAt, to: closeAt}) {
+			if !b.to.After(gapStart) {
+				continue
+			}
+
+			gapEnd := b.from
+			if gapEnd.After(closeAt) {
+				gapEnd = closeAt
+			}
+
+			if gapEnd.Sub(gapStart) >= duration {
+				start := target
+				if start.Before(gapStart) {
+					start = gapStart
+				}
+				if latest := gapEnd.Add(-duration); start.After(latest) {
+					start = latest
+				}
+
+				windows = append(windows, AlternativeWindow{
+					DateFrom:     start,
+					DateTo:       start.Add(duration),
+					ShiftMinutes: int(start.Sub(dateFrom).Minutes()),
+				})
+			}
+
+			if b.to.After(gapStart) {
+				gapStart = b.to
+			}
+			if !gapStart.Before(closeAt) {
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(windows, func(i, j int) bool {
+		return absDuration(windows[i].DateFrom.Sub(dateFrom)) < absDuration(windows[j].DateFrom.Sub(dateFrom))
+	})
+
+	if len(windows) > alternativesLimit {
+		windows = windows[:alternativesLimit]
+	}
+	if windows == nil {
+		windows = []AlternativeWindow{}
+	}
+
+	return windows, nil
+}
+
+// Занятые интервалы стола и пользователя, отсортированные по началу.
+// Брони стола занимают его вместе с уборкой после них, а новая бронь должна оставить время на уборку перед ними.
+func busyIntervals(ctx context.Context, tx *sql.Tx, deskId, userId, resourceType string, from, to time.Time, excludeId *string) ([]busyInterval, error) {
+	query := `
+		WITH buffer AS (
+			SELECT make_interval(mins => COALESCE(d.cleaning_buffer_minutes, z.cleaning_buffer_minutes, 0)) AS gap
+			FROM desks d
+			LEFT JOIN zones z ON z.id = d.zone_id
+			WHERE d.id = $1
+		)
+		SELECT
+			CASE WHEN r.desk_id = $1 THEN r.date_from - b.gap ELSE r.date_from END AS busy_from,
+			CASE WHEN r.desk_id = $1 THEN r.occupied_until ELSE r.date_to END AS busy_to
+		FROM reservations r
+		CROSS JOIN buffer b
+		WHERE r.status IN ('active', 'pending')
+		  AND tstzrange(r.date_from - b.gap, r.occupied_until, '[)') && tstzrange($3, $4, '[)')
+		  AND ($6::uuid IS NULL OR r.id <> $6::uuid)
+		  AND (
+			r.desk_id = $1
+			OR (r.user_id = $2 AND r.guest_name IS NULL AND r.resource_type = $5)
+		  )
+		ORDER BY busy_from
+	`
+
+	rows, err := tx.QueryContext(ctx, query, deskId, userId, from, to, resourceType, excludeId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query busy intervals: %w", err)
+	}
+	defer rows.Close()
+
+	var busy []busyInterval
+	for rows.Next() {
+		var b busyInterval
+		if err := rows.Scan(&b.from, &b.to); err != nil {
+			return nil, fmt.Errorf("failed to scan busy interval: %w", err)
+		}
+		busy = append(busy, b)
+	}
+
+	return busy, rows.Err()
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+
+	return d
+}
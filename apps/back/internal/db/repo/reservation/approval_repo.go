@@ -0,0 +1,225 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	userRepo "place-picker/internal/db/repo/user"
+)
+
+var ErrNotPending = errors.New("reservation is not pending approval")
+var ErrNotApprover = errors.New("you are not an approver for this desk")
+
+type (
+	// Бронь, ожидающая решения подтверждающего.
+	PendingReservation struct {
+		ReservationId string    `json:"reservationId"`
+		DeskId        string    `json:"deskId"`
+		DeskName      string    `json:"deskName"`
+		UserId        string    `json:"userId"`
+		UserName      string    `json:"userName"`
+		DateFrom      time.Time `json:"dateFrom"`
+		DateTo        time.Time `json:"dateTo"`
+		CreatedAt     time.Time `json:"createdAt"`
+	}
+
+	// Решение по брони для уведомления пользователя, который занимает стол.
+	ApprovalNotice struct {
+		ReservationId string
+		Status        string
+		Comment       *string
+		DeskName      string
+		DeskOffice    string
+		UserEmail     string
+		DateFrom      time.Time
+		DateTo        time.Time
+	}
+)
+
+// Брони, ожидающие подтверждения на столах, которые подтверждает approverId.
+// Администратор видит ожидающие брони всех столов.
+func (r *ReservationsRepository) GetPendingApprovals(ctx context.Context, approverId string) ([]PendingReservation, error) {
+	query := `
+		SELECT r.id, d.id, d.name, u.id, u.name, r.date_from, r.date_to, r.created_at
+		FROM reservations r
+		JOIN desks d ON d.id = r.desk_id
+		JOIN users u ON u.id = r.user_id
+		WHERE r.status = 'pending'
+		  AND (
+			EXISTS (SELECT 1 FROM desk_approvers a WHERE a.desk_id = d.id AND a.user_id = $1)
+			OR EXISTS (SELECT 1 FROM users WHERE id = $1 AND role = $2)
+		  )
+		ORDER BY r.date_from, r.created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, approverId, userRepo.RoleAdmin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending reservations: %w", err)
+	}
+	defer rows.Close()
+
+	pending := []PendingReservation{}
+	for rows.Next() {
+		var p PendingReservation
+		if err := rows.Scan(&p.ReservationId, &p.DeskId, &p.DeskName, &p.UserId, &p.UserName, &p.DateFrom, &p.DateTo, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan pending reservation: %w", err)
+		}
+		pending = append(pending, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+// Подтверждает или отклоняет ожидающую бронь. Решение принимает подтверждающий стола или администратор.
+// Отклоненная бронь освобождает стол.
+func (r *ReservationsRepository) DecideReservation(ctx context.Context, reservationId, approverId string, approve bool, comment *string) (ApprovalNotice, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ApprovalNotice{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		deskId string
+		status string
+	)
+	lockQuery := `SELECT desk_id, status FROM reservations WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRowContext(ctx, lockQuery, reservationId).Scan(&deskId, &status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ApprovalNotice{}, sql.ErrNoRows
+		}
+		return ApprovalNotice{}, fmt.Errorf("failed to lock reservation: %w", err)
+	}
+
+	permissionQuery := `
+		SELECT
+			EXISTS (SELECT 1 FROM desk_approvers WHERE desk_id = $1 AND user_id = $2)
+			OR EXISTS (SELECT 1 FROM users WHERE id = $2 AND role = $3)
+	`
+	var allowed bool
+	if err := tx.QueryRowContext(ctx, permissionQuery, deskId, approverId, userRepo.RoleAdmin).Scan(&allowed); err != nil {
+		return ApprovalNotice{}, fmt.Errorf("failed to check approver: %w", err)
+	}
+	if !allowed {
+		return ApprovalNotice{}, ErrNotApprover
+	}
+
+	if status != StatusPending {
+		return ApprovalNotice{}, ErrNotPending
+	}
+
+	decision := StatusDeclined
+	if approve {
+		decision = StatusActive
+	}
+
+	query := `
+		WITH decided AS (
+			UPDATE reservations
+			SET status = $2, decided_by = $3, decided_at = NOW(), decision_comment = $4, updated_at = NOW()
+			WHERE id = $1
+			RETURNING id, status, decision_comment, desk_id, user_id, date_from, date_to
+		)
+		SELECT d.id, d.status, d.decision_comment, k.name, COALESCE(k.office, ''), u.email, d.date_from, d.date_to
+		FROM decided d
+		JOIN desks k ON k.id = d.desk_id
+		JOIN users u ON u.id = d.user_id
+	`
+
+	var notice ApprovalNotice
+	err = tx.QueryRowContext(ctx, query, reservationId, decision, approverId, comment).Scan(
+		&notice.ReservationId,
+		&notice.Status,
+		&notice.Comment,
+		&notice.DeskName,
+		&notice.DeskOffice,
+		&notice.UserEmail,
+		&notice.DateFrom,
+		&notice.DateTo,
+	)
+	if err != nil {
+		return ApprovalNotice{}, fmt.Errorf("failed to decide reservation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ApprovalNotice{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return notice, nil
+}
+
+// Переводит в expired брони, которые ждут подтверждения дольше ttl или уже должны были начаться.
+func (r *ReservationsRepository) ExpirePendingReservations(ctx context.Context, ttl time.Duration) ([]ApprovalNotice, error) {
+	query := `
+		WITH expired AS (
+			UPDATE reservations
+			SET status = 'expired', decided_at = NOW(), updated_at = NOW()
+			WHERE status = 'pending'
+			  AND (created_at + make_interval(secs => $1) < NOW() OR date_from <= NOW())
+			RETURNING id, status, desk_id, user_id, date_from, date_to
+		)
+		SELECT e.id, e.status, k.name, COALESCE(k.office, ''), u.email, e.date_from, e.date_to
+		FROM expired e
+		JOIN desks k ON k.id = e.desk_id
+		JOIN users u ON u.id = e.user_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, ttl.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire pending reservations: %w", err)
+	}
+	defer rows.Close()
+
+	var notices []ApprovalNotice
+	for rows.Next() {
+		var n ApprovalNotice
+		if err := rows.Scan(&n.ReservationId, &n.Status, &n.DeskName, &n.DeskOffice, &n.UserEmail, &n.DateFrom, &n.DateTo); err != nil {
+			return nil, fmt.Errorf("failed to scan expired reservation: %w", err)
+		}
+		notices = append(notices, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notices, nil
+}
+
+// Адреса тех, кто подтверждает брони стола. Если подтверждающие не назначены, брони подтверждают администраторы.
+func (r *ReservationsRepository) GetDeskApproverEmails(ctx context.Context, deskId string) ([]string, error) {
+	query := `
+		SELECT u.email
+		FROM desk_approvers a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.desk_id = $1
+		UNION
+		SELECT u.email
+		FROM users u
+		WHERE u.role = $2 AND NOT EXISTS (SELECT 1 FROM desk_approvers WHERE desk_id = $1)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, deskId, userRepo.RoleAdmin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query desk approvers: %w", err)
+	}
+	defer rows.Close()
+
+	var emails []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, fmt.Errorf("failed to scan approver email: %w", err)
+		}
+		emails = append(emails, email)
+	}
+
+	return emails, rows.Err()
+}
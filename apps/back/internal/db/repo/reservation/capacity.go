@@ -0,0 +1,178 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"place-picker/internal/offices"
+)
+
+// Занятость зоны за день. Занятыми считаются столы, на которые в этот день есть
+// хотя бы одна бронь, в том числе ожидающая подтверждения. Limit — сколько столов можно занять,
+// null, если у зоны нет ограничения. Limit округляется вверх: ненулевой процент разрешает хотя бы один стол,
+// а 0% закрывает зону. Лимиты есть только у зон: этаж с лимитом заводится как зона.
+type ZoneOccupancy struct {
+	ZoneId          string `json:"zoneId"`
+	ZoneName        string `json:"zoneName"`
+	Day             string `json:"day"`
+	CapacityPercent *int   `json:"capacityPercent"`
+	DesksCount      int    `json:"desksCount"`
+	Limit           *int   `json:"limit"`
+	Occupied        int    `json:"occupied"`
+}
+
+// Лимит и занятость зон за интервал. Ограничение действует только на столы,
+// другие ресурсы зоны не учитываются. excludeId исключает переносимую бронь.
+const zoneOccupancyQuery = `
+	SELECT z.id, z.name, z.capacity_percent,
+		COUNT(d.id),
+		COUNT(d.id) FILTER (WHERE EXISTS (
+			SELECT 1
+			FROM reservations r
+			WHERE r.desk_id = d.id
+			  AND r.status IN ('active', 'pending')
+			  AND r.date_from < $3
+			  AND r.date_to > $2
+			  AND ($4::uuid IS NULL OR r.id <> $4::uuid)
+		))
+	FROM zones z
+	JOIN desks d ON d.zone_id = z.id AND d.type = 'desk'
+`
+
+func scanZoneOccupancy(row interface{ Scan(...any) error }, day string) (ZoneOccupancy, error) {
+	occupancy := ZoneOccupancy{Day: day}
+	if err := row.Scan(&occupancy.ZoneId, &occupancy.ZoneName, &occupancy.CapacityPercent, &occupancy.DesksCount, &occupancy.Occupied); err != nil {
+		return occupancy, err
+	}
+
+	if occupancy.CapacityPercent != nil {
+		percent := *occupancy.CapacityPercent
+		limit := (occupancy.DesksCount*percent + 99) / 100
+		occupancy.Limit = &limit
+	}
+
+	return occupancy, nil
+}
+
+// Не дает занять в зоне больше столов, чем разрешает ее лимит. Бронь стола, который в этот день
+// уже занят, лимит не расходует. Параллельные брони одной зоны проверяются по очереди под advisory lock.
+func checkZoneCapacity(ctx context.Context, tx *sql.Tx, office offices.Office, deskId, day string, excludeId *string) error {
+	var zoneId string
+	query := `
+		SELECT z.id
+		FROM desks d
+		JOIN zones z ON z.id = d.zone_id
+		WHERE d.id = $1 AND d.type = 'desk' AND z.capacity_percent IS NOT NULL
+	`
+	err := tx.QueryRowContext(ctx, query, deskId).Scan(&zoneId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load zone capacity: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('zone_capacity:' || $1))`, zoneId); err != nil {
+		return fmt.Errorf("failed to lock zone capacity: %w", err)
+	}
+
+	from, to, err := office.DayBounds(day)
+	if err != nil {
+		return err
+	}
+
+	occupancy, err := scanZoneOccupancy(tx.QueryRowContext(ctx, zoneOccupancyQuery+`
+		WHERE z.id = $1
+		GROUP BY z.id, z.name, z.capacity_percent
+	`, zoneId, from, to, excludeId), day)
+	if err != nil {
+		return fmt.Errorf("failed to count zone occupancy: %w", err)
+	}
+
+	if occupancy.Occupied < *occupancy.Limit {
+		return nil
+	}
+
+	var deskOccupied bool
+	query = `
+		SELECT EXISTS (
+			SELECT 1
+			FROM reservations
+			WHERE desk_id = $1
+			  AND status IN ('active', 'pending')
+			  AND date_from < $3
+			  AND date_to > $2
+			  AND ($4::uuid IS NULL OR id <> $4::uuid)
+		)
+	`
+	if err := tx.QueryRowContext(ctx, query, deskId, from, to, excludeId).Scan(&deskOccupied); err != nil {
+		return fmt.Errorf("failed to check desk occupancy: %w", err)
+	}
+	if deskOccupied {
+		return nil
+	}
+
+	return &PolicyError{
+		Code:    PolicyZoneCapacity,
+		Message: fmt.Sprintf("zone %s is at capacity on %s: %d of %d desks are occupied", occupancy.ZoneName, day, occupancy.Occupied, *occupancy.Limit),
+	}
+}
+
+// Занятость зоны стола в день начала брони. Для стола без зоны возвращает nil.
+func deskZoneOccupancy(ctx context.Context, tx *sql.Tx, office offices.Office, deskId string, dateFrom time.Time, excludeId *string) (*ZoneOccupancy, error) {
+	day := office.Day(dateFrom)
+	from, to, err := office.DayBounds(day)
+	if err != nil {
+		return nil, err
+	}
+
+	occupancy, err := scanZoneOccupancy(tx.QueryRowContext(ctx, zoneOccupancyQuery+`
+		WHERE z.id = (SELECT zone_id FROM desks WHERE id = $1)
+		GROUP BY z.id, z.name, z.capacity_percent
+	`, deskId, from, to, excludeId), day)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to count zone occupancy: %w", err)
+	}
+
+	return &occupancy, nil
+}
+
+// Занятость зон офиса за местный день. Зона относится к офису, если в нем есть ее столы.
+func (r *ReservationsRepository) GetZonesOccupancy(ctx context.Context, office offices.Office, day string) ([]ZoneOccupancy, error) {
+	defaultOffice, err := offices.Get("")
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err := office.DayBounds(day)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, zoneOccupancyQuery+`
+		WHERE z.id IN (SELECT zone_id FROM desks WHERE COALESCE(office, $5) = $1)
+		GROUP BY z.id, z.name, z.capacity_percent
+		ORDER BY z.name
+	`, office.Key, from, to, nil, defaultOffice.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query zones occupancy: %w", err)
+	}
+	defer rows.Close()
+
+	zones := []ZoneOccupancy{}
+	for rows.Next() {
+		occupancy, err := scanZoneOccupancy(rows, day)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan zone occupancy: %w", err)
+		}
+		zones = append(zones, occupancy)
+	}
+
+	return zones, rows.Err()
+}
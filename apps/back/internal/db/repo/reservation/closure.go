@@ -0,0 +1,63 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"place-picker/internal/offices"
+)
+
+// В закрытый день офиса бронь не создается. Закрытие возвращается как нарушение правила
+// с кодом office_closed, поэтому серии и брони на несколько дней пропускают такие дни.
+func checkOfficeClosure(ctx context.Context, tx *sql.Tx, office offices.Office, day string) error {
+	query := `
+		SELECT reason
+		FROM office_closures
+		WHERE office = $1
+		  AND $2::date BETWEEN date_from AND date_to
+		ORDER BY date_from
+		LIMIT 1
+	`
+
+	var reason string
+	err := tx.QueryRowContext(ctx, query, office.Key, day).Scan(&reason)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check office closure: %w", err)
+	}
+
+	return &PolicyError{Code: PolicyOfficeClosed, Message: fmt.Sprintf("office is closed on %s: %s", day, reason)}
+}
+
+// Возвращает закрытые дни офиса в интервале [from, to] в формате YYYY-MM-DD.
+func (r *ReservationsRepository) GetClosedDays(ctx context.Context, office offices.Office, from, to string) (map[string]bool, error) {
+	query := `
+		SELECT DISTINCT to_char(day, 'YYYY-MM-DD')
+		FROM office_closures c,
+			generate_series(GREATEST(c.date_from, $2::date), LEAST(c.date_to, $3::date), interval '1 day') AS day
+		WHERE c.office = $1
+		  AND c.date_to >= $2::date
+		  AND c.date_from <= $3::date
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, office.Key, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query closed days: %w", err)
+	}
+	defer rows.Close()
+
+	closed := map[string]bool{}
+	for rows.Next() {
+		var day string
+		if err := rows.Scan(&day); err != nil {
+			return nil, fmt.Errorf("failed to scan closed day: %w", err)
+		}
+		closed[day] = true
+	}
+
+	return closed, rows.Err()
+}
@@ -0,0 +1,80 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	userRepo "place-picker/internal/db/repo/user"
+)
+
+var ErrNotDelegated = errors.New("you are not allowed to book on behalf of this user")
+var ErrUserNotFound = errors.New("user not found")
+
+// Участники брони, оформленной за другого пользователя. Используются для уведомлений.
+type BookingContacts struct {
+	DeskName   string
+	DeskOffice string
+	UserEmail  string
+	UserName   string
+	BookerName string
+}
+
+// Проверяет, может ли bookedBy бронировать за userId: за себя можно всегда,
+// за другого — по разрешению от него или с ролью администратора.
+func (r *ReservationsRepository) CanBookFor(ctx context.Context, bookedBy, userId string) (bool, error) {
+	err := checkBookingPermission(ctx, r.db, bookedBy, userId)
+	if errors.Is(err, ErrNotDelegated) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func checkBookingPermission(ctx context.Context, q interface {
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}, bookedBy, userId string) error {
+	if bookedBy == userId {
+		return nil
+	}
+
+	query := `
+		SELECT
+			EXISTS (SELECT 1 FROM users WHERE id = $1 AND role = $3)
+			OR EXISTS (SELECT 1 FROM delegation_grants WHERE user_id = $2 AND delegate_id = $1)
+	`
+
+	var allowed bool
+	if err := q.QueryRowContext(ctx, query, bookedBy, userId, userRepo.RoleAdmin).Scan(&allowed); err != nil {
+		return fmt.Errorf("failed to check booking permission: %w", err)
+	}
+
+	if !allowed {
+		return ErrNotDelegated
+	}
+
+	return nil
+}
+
+func (r *ReservationsRepository) GetBookingContacts(ctx context.Context, deskId, userId, bookedBy string) (BookingContacts, error) {
+	query := `
+		SELECT d.name, COALESCE(d.office, ''), u.email, u.name, b.name
+		FROM desks d, users u, users b
+		WHERE d.id = $1 AND u.id = $2 AND b.id = $3
+	`
+
+	var contacts BookingContacts
+	err := r.db.QueryRowContext(ctx, query, deskId, userId, bookedBy).Scan(
+		&contacts.DeskName,
+		&contacts.DeskOffice,
+		&contacts.UserEmail,
+		&contacts.UserName,
+		&contacts.BookerName,
+	)
+	if err != nil {
+		return BookingContacts{}, fmt.Errorf("failed to get booking contacts: %w", err)
+	}
+
+	return contacts, nil
+}
@@ -0,0 +1,323 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+
+	"place-picker/internal/offices"
+)
+
+var ErrNoAdjacentDesks = errors.New("not enough free desks in one zone")
+
+type (
+	// Место участника групповой брони.
+	GroupSeat struct {
+		UserId        string `json:"userId"`
+		UserName      string `json:"userName"`
+		UserEmail     string `json:"-"`
+		DeskId        string `json:"deskId"`
+		DeskName      string `json:"deskName"`
+		ReservationId string `json:"reservationId"`
+	}
+
+	GroupBooking struct {
+		ZoneId     string      `json:"zoneId"`
+		ZoneName   string      `json:"zoneName"`
+		DeskOffice string      `json:"-"`
+		BookerName string      `json:"-"`
+		Seats      []GroupSeat `json:"seats"`
+	}
+
+	// Ошибка правил бронирования для одного из участников групповой брони.
+	// Выбор других столов ее не исправит, поэтому групповая бронь отменяется целиком.
+	AttendeeError struct {
+		UserId string
+		Err    error
+	}
+
+	groupDesk struct {
+		id       string
+		name     string
+		zoneId   string
+		zoneName string
+		office   string
+		x, y     float64
+		placed   bool
+	}
+)
+
+func (e *AttendeeError) Error() string {
+	return e.UserId + ": " + e.Err.Error()
+}
+
+func (e *AttendeeError) Unwrap() error {
+	return e.Err
+}
+
+// Бронирует для участников столы одной зоны на один интервал в одной транзакции: либо все, либо ни одного.
+// Из свободных столов выбирается набор, расположенный на плане этажа плотнее всего.
+// Если стол не подошел по правилам бронирования, он исключается и набор подбирается заново.
+// Без zoneId подходящая зона ищется среди зон офиса.
+func (r *ReservationsRepository) CreateGroupBooking(ctx context.Context, bookedBy string, attendees []string, zoneId *string, office offices.Office, dateFrom, dateTo time.Time) (GroupBooking, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return GroupBooking{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, userId := range attendees {
+		if err := checkBookingPermission(ctx, tx, bookedBy, userId); err != nil {
+			return GroupBooking{}, &AttendeeError{UserId: userId, Err: err}
+		}
+	}
+
+	desks, err := freeGroupDesks(ctx, tx, zoneId, office, dateFrom, dateTo)
+	if err != nil {
+		return GroupBooking{}, err
+	}
+
+	excluded := map[string]bool{}
+	for {
+		chosen := pickAdjacentDesks(desks, excluded, len(attendees))
+		if chosen == nil {
+			return GroupBooking{}, ErrNoAdjacentDesks
+		}
+
+		booking, failedDesk, err := tryGroupDesks(ctx, tx, bookedBy, attendees, chosen, dateFrom, dateTo)
+		if err != nil {
+			return GroupBooking{}, err
+		}
+		if failedDesk != "" {
+			excluded[failedDesk] = true
+			continue
+		}
+
+		if err := fillGroupContacts(ctx, tx, bookedBy, &booking); err != nil {
+			return GroupBooking{}, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return GroupBooking{}, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+
+		return booking, nil
+	}
+}
+
+// Столы зон офиса, свободные на весь интервал. Другие ресурсы зоны и столы, требующие подтверждения, в групповую бронь не входят.
+func freeGroupDesks(ctx context.Context, tx *sql.Tx, zoneId *string, office offices.Office, dateFrom, dateTo time.Time) ([]groupDesk, error) {
+	defaultOffice, err := offices.Get("")
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT d.id, d.name, z.id, z.name, COALESCE(d.office, ''), d.pos_x, d.pos_y
+		FROM desks d
+		JOIN zones z ON z.id = d.zone_id
+		WHERE ($1::uuid IS NULL OR z.id = $1::uuid)
+		  AND COALESCE(d.office, $2) = $3
+		  AND d.type = 'desk'
+		  AND NOT d.requires_approval
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM reservations r
+			WHERE r.desk_id = d.id
+			  AND r.status IN ('active', 'pending')
+			  AND tstzrange(r.date_from, r.occupied_until, '[)')
+				&& tstzrange($4, $5 + make_interval(mins => COALESCE(d.cleaning_buffer_minutes, z.cleaning_buffer_minutes, 0)), '[)')
+		  )
+		ORDER BY z.name, d.name
+	`
+
+	rows, err := tx.QueryContext(ctx, query, zoneId, defaultOffice.Key, office.Key, dateFrom, dateTo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query free desks: %w", err)
+	}
+	defer rows.Close()
+
+	var desks []groupDesk
+	for rows.Next() {
+		var (
+			d    groupDesk
+			x, y sql.NullFloat64
+		)
+		if err := rows.Scan(&d.id, &d.name, &d.zoneId, &d.zoneName, &d.office, &x, &y); err != nil {
+			return nil, fmt.Errorf("failed to scan free desk: %w", err)
+		}
+
+		d.x, d.y, d.placed = x.Float64, y.Float64, x.Valid && y.Valid
+		desks = append(desks, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return desks, nil
+}
+
+// Выбирает n столов одной зоны с наименьшим суммарным расстоянием между соседями.
+// Набор растет от каждого стола зоны, каждый раз добавляя ближайший к уже выбранным стол.
+// Столы без координат считаются бесконечно далекими и выбираются в последнюю очередь.
+// Возвращает nil, если ни в одной зоне не хватает свободных столов.
+func pickAdjacentDesks(desks []groupDesk, excluded map[string]bool, n int) []groupDesk {
+	zones := map[string][]groupDesk{}
+	var zoneOrder []string
+	for _, d := range desks {
+		if excluded[d.id] {
+			continue
+		}
+		if _, ok := zones[d.zoneId]; !ok {
+			zoneOrder = append(zoneOrder, d.zoneId)
+		}
+		zones[d.zoneId] = append(zones[d.zoneId], d)
+	}
+
+	var (
+		best     []groupDesk
+		bestCost = math.Inf(1)
+	)
+	for _, zoneId := range zoneOrder {
+		candidates := zones[zoneId]
+		if len(candidates) < n {
+			continue
+		}
+
+		for seed := range candidates {
+			set, cost := growDeskSet(candidates, seed, n)
+			if best == nil || cost < bestCost {
+				best, bestCost = set, cost
+			}
+		}
+	}
+
+	return best
+}
+
+func growDeskSet(candidates []groupDesk, seed, n int) ([]groupDesk, float64) {
+	used := make([]bool, len(candidates))
+	used[seed] = true
+	set := []groupDesk{candidates[seed]}
+	cost := 0.0
+
+	for len(set) < n {
+		next, nextDistance := -1, math.Inf(1)
+		for i, candidate := range candidates {
+			if used[i] {
+				continue
+			}
+
+			for _, chosen := range set {
+				if distance := deskDistance(chosen, candidate); next == -1 || distance < nextDistance {
+					next, nextDistance = i, distance
+				}
+			}
+		}
+
+		used[next] = true
+		set = append(set, candidates[next])
+		cost += nextDistance
+	}
+
+	return set, cost
+}
+
+func deskDistance(a, b groupDesk) float64 {
+	if !a.placed || !b.placed {
+		return math.Inf(1)
+	}
+
+	return math.Hypot(a.x-b.x, a.y-b.y)
+}
+
+// Бронирует выбранные столы для участников. Если стол не подошел по правилам бронирования,
+// брони этого набора откатываются и возвращается идентификатор стола, чтобы исключить его из подбора.
+func tryGroupDesks(ctx context.Context, tx *sql.Tx, bookedBy string, attendees []string, desks []groupDesk, dateFrom, dateTo time.Time) (GroupBooking, string, error) {
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT group_booking`); err != nil {
+		return GroupBooking{}, "", fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	booking := GroupBooking{ZoneId: desks[0].zoneId, ZoneName: desks[0].zoneName, DeskOffice: desks[0].office}
+	for i, userId := range attendees {
+		reservationId, err := insertReservation(ctx, tx, desks[i].id, userId, bookedBy, dateFrom, dateTo, nil)
+		if err == nil {
+			booking.Seats = append(booking.Seats, GroupSeat{
+				UserId:        userId,
+				DeskId:        desks[i].id,
+				DeskName:      desks[i].name,
+				ReservationId: reservationId,
+			})
+			continue
+		}
+
+		if !isDeskRuleError(err) {
+			if isReservationRuleError(err) || errors.Is(err, ErrUserNotFound) {
+				return GroupBooking{}, "", &AttendeeError{UserId: userId, Err: err}
+			}
+			return GroupBooking{}, "", err
+		}
+
+		if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT group_booking`); err != nil {
+			return GroupBooking{}, "", fmt.Errorf("failed to rollback to savepoint: %w", err)
+		}
+
+		return GroupBooking{}, desks[i].id, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT group_booking`); err != nil {
+		return GroupBooking{}, "", fmt.Errorf("failed to release savepoint: %w", err)
+	}
+
+	return booking, "", nil
+}
+
+// Ошибки правил, которые зависят от стола, а не от участника: другой стол может подойти.
+func isDeskRuleError(err error) bool {
+	return errors.Is(err, ErrDeskAlreadyReserved) ||
+		errors.Is(err, ErrDeskAssigned) ||
+		errors.Is(err, ErrDeskRestricted)
+}
+
+func fillGroupContacts(ctx context.Context, tx *sql.Tx, bookedBy string, booking *GroupBooking) error {
+	userIds := make([]string, 0, len(booking.Seats)+1)
+	for _, seat := range booking.Seats {
+		userIds = append(userIds, seat.UserId)
+	}
+	userIds = append(userIds, bookedBy)
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, name, email FROM users WHERE id = ANY($1::uuid[])`, pq.Array(userIds))
+	if err != nil {
+		return fmt.Errorf("failed to query attendees: %w", err)
+	}
+	defer rows.Close()
+
+	type contact struct{ name, email string }
+	contacts := map[string]contact{}
+	for rows.Next() {
+		var id string
+		var c contact
+		if err := rows.Scan(&id, &c.name, &c.email); err != nil {
+			return fmt.Errorf("failed to scan attendee: %w", err)
+		}
+		contacts[id] = c
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range booking.Seats {
+		c := contacts[booking.Seats[i].UserId]
+		booking.Seats[i].UserName, booking.Seats[i].UserEmail = c.name, c.email
+	}
+	booking.BookerName = contacts[bookedBy].name
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type (
+	// Гость, для которого сотрудник бронирует стол.
+	Guest struct {
+		Name    string  `json:"name"`
+		Email   *string `json:"email"`
+		Company *string `json:"company"`
+	}
+
+	// Данные для приглашения гостя.
+	GuestInvitation struct {
+		ReservationId string
+		Guest         Guest
+		Status        string
+		DeskName      string
+		ZoneName      *string
+		DeskOffice    string
+		HostName      string
+		DateFrom      time.Time
+		DateTo        time.Time
+	}
+
+	// Гость в списке ресепшена.
+	Visitor struct {
+		ReservationId string     `json:"reservationId"`
+		Guest         Guest      `json:"guest"`
+		Status        string     `json:"status"`
+		HostId        string     `json:"hostId"`
+		HostName      string     `json:"hostName"`
+		DeskId        string     `json:"deskId"`
+		DeskName      string     `json:"deskName"`
+		ZoneName      *string    `json:"zoneName"`
+		DateFrom      time.Time  `json:"dateFrom"`
+		DateTo        time.Time  `json:"dateTo"`
+		CheckedInAt   *time.Time `json:"checkedInAt"`
+	}
+)
+
+// Бронирует стол для гостя от имени hostId. Бронь гостя не мешает собственной брони пригласившего
+// и не учитывается в его квотах. Стол, требующий подтверждения, бронируется в статусе pending.
+// Возвращает идентификатор и статус брони.
+func (r *ReservationsRepository) CreateGuestReservation(ctx context.Context, deskId, hostId string, guest Guest, dateFrom, dateTo time.Time) (string, string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkGuestReservationRules(ctx, tx, deskId, hostId, dateFrom, dateTo, nil); err != nil {
+		return "", "", err
+	}
+
+	query := `
+		INSERT INTO reservations (desk_id, user_id, booked_by, date_from, date_to, status, guest_name, guest_email, guest_company)
+		VALUES ($1, $2, $2, $3, $4, ` + deskInitialStatus + `, $5, $6, $7)
+		RETURNING id, status
+	`
+
+	var id, status string
+	if err := tx.QueryRowContext(ctx, query, deskId, hostId, dateFrom, dateTo, guest.Name, guest.Email, guest.Company).Scan(&id, &status); err != nil {
+		return "", "", mapConstraintError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return id, status, nil
+}
+
+// Возвращает данные приглашения для гостевой брони. Для брони сотрудника возвращает sql.ErrNoRows.
+func (r *ReservationsRepository) GetGuestInvitation(ctx context.Context, reservationId string) (GuestInvitation, error) {
+	query := `
+		SELECT r.id, r.guest_name, r.guest_email, r.guest_company, r.status,
+			d.name, z.name, COALESCE(d.office, ''), u.name, r.date_from, r.date_to
+		FROM reservations r
+		JOIN desks d ON d.id = r.desk_id
+		LEFT JOIN zones z ON z.id = d.zone_id
+		JOIN users u ON u.id = r.user_id
+		WHERE r.id = $1 AND r.guest_name IS NOT NULL
+	`
+
+	var invitation GuestInvitation
+	err := r.db.QueryRowContext(ctx, query, reservationId).Scan(
+		&invitation.ReservationId,
+		&invitation.Guest.Name,
+		&invitation.Guest.Email,
+		&invitation.Guest.Company,
+		&invitation.Status,
+		&invitation.DeskName,
+		&invitation.ZoneName,
+		&invitation.DeskOffice,
+		&invitation.HostName,
+		&invitation.DateFrom,
+		&invitation.DateTo,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return GuestInvitation{}, sql.ErrNoRows
+		}
+		return GuestInvitation{}, fmt.Errorf("failed to get guest invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+// Гости офиса officeKey, чьи брони начинаются в интервале [from, to).
+// Столы без офиса относятся к офису defaultOfficeKey.
+func (r *ReservationsRepository) GetVisitors(ctx context.Context, officeKey, defaultOfficeKey string, from, to time.Time) ([]Visitor, error) {
+	query := `
+		SELECT r.id, r.guest_name, r.guest_email, r.guest_company, r.status,
+			u.id, u.name, d.id, d.name, z.name, r.date_from, r.date_to, r.checked_in_at
+		FROM reservations r
+		JOIN desks d ON d.id = r.desk_id
+		LEFT JOIN zones z ON z.id = d.zone_id
+		JOIN users u ON u.id = r.user_id
+		WHERE r.guest_name IS NOT NULL
+		  AND r.status IN ('active', 'pending')
+		  AND r.date_from >= $1
+		  AND r.date_from < $2
+		  AND COALESCE(d.office, $3) = $4
+		ORDER BY r.date_from, r.guest_name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, from, to, defaultOfficeKey, officeKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query visitors: %w", err)
+	}
+	defer rows.Close()
+
+	visitors := []Visitor{}
+	for rows.Next() {
+		var v Visitor
+		if err := rows.Scan(
+			&v.ReservationId,
+			&v.Guest.Name,
+			&v.Guest.Email,
+			&v.Guest.Company,
+			&v.Status,
+			&v.HostId,
+			&v.HostName,
+			&v.DeskId,
+			&v.DeskName,
+			&v.ZoneName,
+			&v.DateFrom,
+			&v.DateTo,
+			&v.CheckedInAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan visitor: %w", err)
+		}
+		visitors = append(visitors, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return visitors, nil
+}
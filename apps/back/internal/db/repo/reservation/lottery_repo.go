@@ -0,0 +1,869 @@
+package reservation
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+
+	"place-picker/internal/offices"
+)
+
+// Состояние лотереи: open — заявки принимаются, closed — прием закрыт и лотерея ждет розыгрыша,
+// drawn — столы распределены, failed — розыгрыш не удался.
+const (
+	LotteryOpen   = "open"
+	LotteryClosed = "closed"
+	LotteryDrawn  = "drawn"
+	LotteryFailed = "failed"
+
+	LotteryRequestPending = "pending"
+	LotteryRequestWon     = "won"
+	LotteryRequestLost    = "lost"
+)
+
+var ErrLotteryExists = errors.New("lottery already exists for this day")
+var ErrLotteryClosed = errors.New("lottery requests are closed")
+var ErrLotteryDrawn = errors.New("lottery is already drawn")
+var ErrLotteryPreference = errors.New("preferred desk or zone is not in the lottery office")
+
+type (
+	// Предпочтение заявки: конкретный стол или любой стол зоны.
+	LotteryPreference struct {
+		DeskId *string `json:"deskId"`
+		ZoneId *string `json:"zoneId"`
+	}
+
+	// Лотерея на день офиса. Seed открывается после розыгрыша.
+	// Request — заявка текущего пользователя, если он ее подал.
+	Lottery struct {
+		Id            string          `json:"id"`
+		Office        string          `json:"office"`
+		Day           string          `json:"day"`
+		CutoffAt      time.Time       `json:"cutoffAt"`
+		Status        string          `json:"status"`
+		DrawnAt       *time.Time      `json:"drawnAt"`
+		FailedAt      *time.Time      `json:"failedAt"`
+		Seed          *string         `json:"seed"`
+		RequestsCount int             `json:"requestsCount"`
+		Request       *LotteryRequest `json:"request,omitempty"`
+	}
+
+	// Заявка на лотерею. Поля розыгрыша заполняются после него: DrawPosition — место в порядке розыгрыша,
+	// RecentWins и RecentLosses — история пользователя за окно справедливости, DrawHash — sha256(seed:userId).
+	LotteryRequest struct {
+		Id            string              `json:"id"`
+		UserId        string              `json:"userId"`
+		UserName      string              `json:"userName"`
+		Status        string              `json:"status"`
+		Preferences   []LotteryPreference `json:"preferences"`
+		ReservationId *string             `json:"reservationId"`
+		DeskId        *string             `json:"deskId"`
+		Rank          *int                `json:"rank"`
+		DrawPosition  *int                `json:"drawPosition"`
+		RecentWins    *int                `json:"recentWins"`
+		RecentLosses  *int                `json:"recentLosses"`
+		DrawHash      *string             `json:"drawHash"`
+		CreatedAt     time.Time           `json:"createdAt"`
+	}
+
+	// Итог розыгрыша для одного участника. Используется для уведомления пользователя.
+	// У выигравшего заполнены стол и время брони, у проигравшего — число записей в очереди ожидания.
+	LotteryOutcome struct {
+		Lottery         Lottery
+		Request         LotteryRequest
+		UserEmail       string
+		DeskName        string
+		DeskOffice      string
+		DateFrom        time.Time
+		DateTo          time.Time
+		WaitlistEntries int
+	}
+)
+
+const lotteryColumns = `
+	l.id,
+	l.office,
+	to_char(l.day, 'YYYY-MM-DD'),
+	l.cutoff_at,
+	CASE
+		WHEN l.drawn_at IS NOT NULL THEN 'drawn'
+		WHEN l.failed_at IS NOT NULL THEN 'failed'
+		WHEN l.cutoff_at <= NOW() THEN 'closed'
+		ELSE 'open'
+	END,
+	l.drawn_at,
+	l.failed_at,
+	CASE WHEN l.drawn_at IS NOT NULL THEN l.seed END,
+	(SELECT COUNT(*) FROM lottery_requests lr WHERE lr.lottery_id = l.id)
+`
+
+func scanLottery(row interface{ Scan(...any) error }) (Lottery, error) {
+	var l Lottery
+	err := row.Scan(&l.Id, &l.Office, &l.Day, &l.CutoffAt, &l.Status, &l.DrawnAt, &l.FailedAt, &l.Seed, &l.RequestsCount)
+	return l, err
+}
+
+const lotteryRequestColumns = `
+	r.id, r.user_id, u.name, r.status, r.reservation_id, r.won_desk_id, r.won_rank,
+	r.draw_position, r.recent_wins, r.recent_losses, r.draw_hash, r.created_at
+`
+
+func scanLotteryRequest(row interface{ Scan(...any) error }) (LotteryRequest, error) {
+	var req LotteryRequest
+	err := row.Scan(
+		&req.Id,
+		&req.UserId,
+		&req.UserName,
+		&req.Status,
+		&req.ReservationId,
+		&req.DeskId,
+		&req.Rank,
+		&req.DrawPosition,
+		&req.RecentWins,
+		&req.RecentLosses,
+		&req.DrawHash,
+		&req.CreatedAt,
+	)
+	req.Preferences = []LotteryPreference{}
+	return req, err
+}
+
+// Объявляет лотерею на день офиса. Заявки принимаются до cutoff.
+func (r *ReservationsRepository) CreateLottery(ctx context.Context, office, day string, cutoff time.Time, createdBy string) (Lottery, error) {
+	query := `
+		INSERT INTO lottery_days (office, day, cutoff_at, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	var id string
+	if err := r.db.QueryRowContext(ctx, query, office, day, cutoff, createdBy).Scan(&id); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Constraint == "lottery_days_office_day_key" {
+			return Lottery{}, ErrLotteryExists
+		}
+		return Lottery{}, fmt.Errorf("failed to create lottery: %w", err)
+	}
+
+	return r.getLottery(ctx, r.db, id)
+}
+
+func (r *ReservationsRepository) getLottery(ctx context.Context, q interface {
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}, lotteryId string) (Lottery, error) {
+	lottery, err := scanLottery(q.QueryRowContext(ctx, `SELECT `+lotteryColumns+` FROM lottery_days l WHERE l.id = $1`, lotteryId))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Lottery{}, sql.ErrNoRows
+		}
+		return Lottery{}, fmt.Errorf("failed to load lottery: %w", err)
+	}
+
+	return lottery, nil
+}
+
+// Лотереи офиса в интервале дат [from, to] вместе с заявками пользователя.
+func (r *ReservationsRepository) GetLotteries(ctx context.Context, userId, office, from, to string) ([]Lottery, error) {
+	query := `
+		SELECT ` + lotteryColumns + `
+		FROM lottery_days l
+		WHERE l.office = $1 AND l.day BETWEEN $2::date AND $3::date
+		ORDER BY l.day
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, office, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lotteries: %w", err)
+	}
+
+	lotteries := []Lottery{}
+	for rows.Next() {
+		l, err := scanLottery(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan lottery: %w", err)
+		}
+		lotteries = append(lotteries, l)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range lotteries {
+		requests, err := r.queryLotteryRequests(ctx, `r.lottery_id = $1 AND r.user_id = $2`, lotteries[i].Id, userId)
+		if err != nil {
+			return nil, err
+		}
+		if len(requests) > 0 {
+			lotteries[i].Request = &requests[0]
+		}
+	}
+
+	return lotteries, nil
+}
+
+// Лотерея со всеми заявками в порядке розыгрыша. По seed и истории участников порядок можно проверить.
+func (r *ReservationsRepository) GetLotteryDraw(ctx context.Context, lotteryId string) (Lottery, []LotteryRequest, error) {
+	lottery, err := r.getLottery(ctx, r.db, lotteryId)
+	if err != nil {
+		return Lottery{}, nil, err
+	}
+
+	requests, err := r.queryLotteryRequests(ctx, `r.lottery_id = $1`, lotteryId)
+	if err != nil {
+		return Lottery{}, nil, err
+	}
+
+	return lottery, requests, nil
+}
+
+func (r *ReservationsRepository) queryLotteryRequests(ctx context.Context, condition string, args ...any) ([]LotteryRequest, error) {
+	query := `
+		SELECT ` + lotteryRequestColumns + `
+		FROM lottery_requests r
+		JOIN users u ON u.id = r.user_id
+		WHERE ` + condition + `
+		ORDER BY r.draw_position NULLS LAST, r.created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lottery requests: %w", err)
+	}
+
+	requests := []LotteryRequest{}
+	byId := map[string]int{}
+	ids := []string{}
+	for rows.Next() {
+		req, err := scanLotteryRequest(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan lottery request: %w", err)
+		}
+		byId[req.Id] = len(requests)
+		ids = append(ids, req.Id)
+		requests = append(requests, req)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return requests, nil
+	}
+
+	prefRows, err := r.db.QueryContext(ctx, `
+		SELECT request_id, desk_id, zone_id
+		FROM lottery_preferences
+		WHERE request_id = ANY($1::uuid[])
+		ORDER BY request_id, rank
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lottery preferences: %w", err)
+	}
+	defer prefRows.Close()
+
+	for prefRows.Next() {
+		var (
+			requestId string
+			pref      LotteryPreference
+		)
+		if err := prefRows.Scan(&requestId, &pref.DeskId, &pref.ZoneId); err != nil {
+			return nil, fmt.Errorf("failed to scan lottery preference: %w", err)
+		}
+		i := byId[requestId]
+		requests[i].Preferences = append(requests[i].Preferences, pref)
+	}
+
+	return requests, prefRows.Err()
+}
+
+// Отменяет лотерею, пока она не разыграна. Обычная бронь на день снова открывается.
+func (r *ReservationsRepository) DeleteLottery(ctx context.Context, lotteryId string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM lottery_days WHERE id = $1 AND drawn_at IS NULL`, lotteryId)
+	if err != nil {
+		return fmt.Errorf("failed to delete lottery: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check delete result: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM lottery_days WHERE id = $1)`, lotteryId).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check lottery: %w", err)
+	}
+
+	if exists {
+		return ErrLotteryDrawn
+	}
+
+	return sql.ErrNoRows
+}
+
+// Подает или заменяет заявку пользователя на лотерею до окончания приема заявок.
+// Предпочтения должны относиться к офису лотереи.
+func (r *ReservationsRepository) SubmitLotteryRequest(ctx context.Context, lotteryId, userId string, preferences []LotteryPreference) (LotteryRequest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return LotteryRequest{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	office, err := lockOpenLottery(ctx, tx, lotteryId)
+	if err != nil {
+		return LotteryRequest{}, err
+	}
+
+	for _, pref := range preferences {
+		if err := checkLotteryPreference(ctx, tx, office, pref); err != nil {
+			return LotteryRequest{}, err
+		}
+	}
+
+	query := `
+		INSERT INTO lottery_requests (lottery_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (lottery_id, user_id) DO UPDATE SET updated_at = NOW()
+		RETURNING id
+	`
+
+	var requestId string
+	if err := tx.QueryRowContext(ctx, query, lotteryId, userId).Scan(&requestId); err != nil {
+		return LotteryRequest{}, fmt.Errorf("failed to save lottery request: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM lottery_preferences WHERE request_id = $1`, requestId); err != nil {
+		return LotteryRequest{}, fmt.Errorf("failed to replace lottery preferences: %w", err)
+	}
+
+	for i, pref := range preferences {
+		query := `INSERT INTO lottery_preferences (request_id, rank, desk_id, zone_id) VALUES ($1, $2, $3, $4)`
+		if _, err := tx.ExecContext(ctx, query, requestId, i+1, pref.DeskId, pref.ZoneId); err != nil {
+			return LotteryRequest{}, fmt.Errorf("failed to save lottery preference: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return LotteryRequest{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	requests, err := r.queryLotteryRequests(ctx, `r.id = $1`, requestId)
+	if err != nil {
+		return LotteryRequest{}, err
+	}
+	if len(requests) == 0 {
+		return LotteryRequest{}, sql.ErrNoRows
+	}
+
+	return requests[0], nil
+}
+
+// Отзывает заявку пользователя до окончания приема заявок.
+func (r *ReservationsRepository) WithdrawLotteryRequest(ctx context.Context, lotteryId, userId string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockOpenLottery(ctx, tx, lotteryId); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM lottery_requests WHERE lottery_id = $1 AND user_id = $2`, lotteryId, userId)
+	if err != nil {
+		return fmt.Errorf("failed to withdraw lottery request: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check delete result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Блокирует лотерею от розыгрыша на время изменения заявки и возвращает ее офис.
+func lockOpenLottery(ctx context.Context, tx *sql.Tx, lotteryId string) (offices.Office, error) {
+	var (
+		officeKey string
+		open      bool
+	)
+	query := `SELECT office, drawn_at IS NULL AND cutoff_at > NOW() FROM lottery_days WHERE id = $1 FOR SHARE`
+	if err := tx.QueryRowContext(ctx, query, lotteryId).Scan(&officeKey, &open); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return offices.Office{}, sql.ErrNoRows
+		}
+		return offices.Office{}, fmt.Errorf("failed to lock lottery: %w", err)
+	}
+
+	if !open {
+		return offices.Office{}, ErrLotteryClosed
+	}
+
+	return offices.Get(officeKey)
+}
+
+// Стол предпочтения должен быть столом офиса лотереи, а в зоне — хотя бы один такой стол.
+func checkLotteryPreference(ctx context.Context, tx *sql.Tx, office offices.Office, pref LotteryPreference) error {
+	if pref.DeskId != nil {
+		res, err := loadResource(ctx, tx, *pref.DeskId)
+		if err != nil {
+			return err
+		}
+		if res.Office.Key != office.Key || res.Type != "desk" {
+			return ErrLotteryPreference
+		}
+		return nil
+	}
+
+	defaultOffice, err := offices.Get("")
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM desks d
+			WHERE d.zone_id = z.id AND d.type = 'desk' AND COALESCE(d.office, $3) = $2
+		)
+		FROM zones z
+		WHERE z.id = $1
+	`
+
+	var hasDesks bool
+	if err := tx.QueryRowContext(ctx, query, *pref.ZoneId, office.Key, defaultOffice.Key).Scan(&hasDesks); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrZoneNotFound
+		}
+		return fmt.Errorf("failed to check lottery zone: %w", err)
+	}
+
+	if !hasDesks {
+		return ErrLotteryPreference
+	}
+
+	return nil
+}
+
+// Пока лотерея дня не разыграна, столы офиса на этот день обычной бронью не выдаются.
+// После неудавшегося розыгрыша бронь на день снова открыта.
+func checkLotteryDay(ctx context.Context, tx *sql.Tx, office offices.Office, day string) error {
+	query := `SELECT cutoff_at FROM lottery_days WHERE office = $1 AND day = $2::date AND drawn_at IS NULL AND failed_at IS NULL`
+
+	var cutoff time.Time
+	err := tx.QueryRowContext(ctx, query, office.Key, day).Scan(&cutoff)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check lottery day: %w", err)
+	}
+
+	return &PolicyError{
+		Code:    PolicyLotteryDay,
+		Message: fmt.Sprintf("desks on %s are allocated by lottery, requests are accepted until %s", day, cutoff.In(office.Location).Format("15:04 02.01.2006")),
+	}
+}
+
+// Разыгрывает лотереи, прием заявок на которые закончился. Каждая лотерея разыгрывается в своей транзакции.
+// История участников учитывается за fairnessWindow до дня лотереи. Ошибки отдельных лотерей и заявок
+// не останавливают розыгрыш остальных и возвращаются вместе.
+func (r *ReservationsRepository) DrawLotteries(ctx context.Context, fairnessWindow time.Duration) ([]LotteryOutcome, error) {
+	var (
+		outcomes []LotteryOutcome
+		errs     []error
+	)
+	for {
+		drawn, ok, err := r.drawNextLottery(ctx, fairnessWindow)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if !ok {
+			return outcomes, errors.Join(errs...)
+		}
+		outcomes = append(outcomes, drawn...)
+	}
+}
+
+// Участник розыгрыша с историей и предпочтениями.
+type lotteryEntrant struct {
+	request LotteryRequest
+	email   string
+	wins    int
+	losses  int
+	hash    string
+}
+
+// Разыгрывает ближайшую лотерею. ok — была ли лотерея для розыгрыша. Если лотерею не удалось разыграть,
+// она помечается неудавшейся, чтобы не задерживать следующие. Заявка, на которой розыгрыш споткнулся,
+// считается проигравшей, ее ошибка возвращается вместе с результатами остальных.
+func (r *ReservationsRepository) drawNextLottery(ctx context.Context, fairnessWindow time.Duration) ([]LotteryOutcome, bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		lotteryId string
+		seed      string
+	)
+	query := `
+		SELECT id, seed
+		FROM lottery_days
+		WHERE drawn_at IS NULL AND failed_at IS NULL AND cutoff_at <= NOW()
+		ORDER BY cutoff_at, day
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`
+	if err := tx.QueryRowContext(ctx, query).Scan(&lotteryId, &seed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to lock lottery: %w", err)
+	}
+
+	outcomes, failed, err := r.drawLottery(ctx, tx, lotteryId, seed, fairnessWindow)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		tx.Rollback()
+		err = fmt.Errorf("lottery %s: %w", lotteryId, err)
+
+		failQuery := `UPDATE lottery_days SET failed_at = NOW() WHERE id = $1 AND drawn_at IS NULL`
+		if _, failErr := r.db.ExecContext(ctx, failQuery, lotteryId); failErr != nil {
+			return nil, false, errors.Join(err, fmt.Errorf("failed to mark lottery failed: %w", failErr))
+		}
+		return nil, true, err
+	}
+
+	return outcomes, true, errors.Join(failed...)
+}
+
+// Разыгрывает лотерею в транзакции tx. failed — ошибки заявок, которые засчитаны проигравшими.
+func (r *ReservationsRepository) drawLottery(ctx context.Context, tx *sql.Tx, lotteryId, seed string, fairnessWindow time.Duration) ([]LotteryOutcome, []error, error) {
+	// Отметка о розыгрыше ставится до броней: иначе проверка дня лотереи не пропустит их.
+	if _, err := tx.ExecContext(ctx, `UPDATE lottery_days SET drawn_at = NOW() WHERE id = $1`, lotteryId); err != nil {
+		return nil, nil, fmt.Errorf("failed to mark lottery drawn: %w", err)
+	}
+
+	lottery, err := r.getLottery(ctx, tx, lotteryId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	office, err := offices.Get(lottery.Office)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entrants, err := loadLotteryEntrants(ctx, tx, lottery, seed, fairnessWindow)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Меньше выигрышей — раньше, при равенстве раньше тот, кто чаще проигрывал, дальше — по хешу.
+	sort.SliceStable(entrants, func(i, j int) bool {
+		a, b := entrants[i], entrants[j]
+		if a.wins != b.wins {
+			return a.wins < b.wins
+		}
+		if a.losses != b.losses {
+			return a.losses > b.losses
+		}
+		return a.hash < b.hash
+	})
+
+	var (
+		outcomes = make([]LotteryOutcome, 0, len(entrants))
+		failed   []error
+	)
+	for i, entrant := range entrants {
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT lottery_entrant`); err != nil {
+			return nil, nil, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		outcome, err := drawLotteryEntrant(ctx, tx, lottery, office, entrant, i+1)
+		if err == nil {
+			outcomes = append(outcomes, outcome)
+			continue
+		}
+		failed = append(failed, fmt.Errorf("lottery request %s: %w", entrant.request.Id, err))
+
+		if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT lottery_entrant`); err != nil {
+			return nil, nil, fmt.Errorf("failed to rollback to savepoint: %w", err)
+		}
+		if err := saveLotteryRequest(ctx, tx, drawnLotteryRequest(entrant, i+1)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return outcomes, failed, nil
+}
+
+func loadLotteryEntrants(ctx context.Context, tx *sql.Tx, lottery Lottery, seed string, fairnessWindow time.Duration) ([]lotteryEntrant, error) {
+	query := `
+		SELECT r.id, r.user_id, u.name, u.email, r.created_at,
+			COUNT(h.id) FILTER (WHERE h.status = 'won'),
+			COUNT(h.id) FILTER (WHERE h.status = 'lost')
+		FROM lottery_requests r
+		JOIN users u ON u.id = r.user_id
+		LEFT JOIN lottery_days hd
+			ON hd.office = $2
+			AND hd.drawn_at IS NOT NULL
+			AND hd.day < $3::date
+			AND hd.day >= $3::date - $4::int
+		LEFT JOIN lottery_requests h ON h.lottery_id = hd.id AND h.user_id = r.user_id
+		WHERE r.lottery_id = $1
+		GROUP BY r.id, u.name, u.email
+	`
+
+	windowDays := int(fairnessWindow / (24 * time.Hour))
+	rows, err := tx.QueryContext(ctx, query, lottery.Id, lottery.Office, lottery.Day, windowDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lottery requests: %w", err)
+	}
+
+	var entrants []lotteryEntrant
+	byId := map[string]int{}
+	for rows.Next() {
+		e := lotteryEntrant{request: LotteryRequest{Preferences: []LotteryPreference{}}}
+		if err := rows.Scan(&e.request.Id, &e.request.UserId, &e.request.UserName, &e.email, &e.request.CreatedAt, &e.wins, &e.losses); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan lottery request: %w", err)
+		}
+		sum := sha256.Sum256([]byte(seed + ":" + e.request.UserId))
+		e.hash = hex.EncodeToString(sum[:])
+		byId[e.request.Id] = len(entrants)
+		entrants = append(entrants, e)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	prefRows, err := tx.QueryContext(ctx, `
+		SELECT p.request_id, p.desk_id, p.zone_id
+		FROM lottery_preferences p
+		JOIN lottery_requests r ON r.id = p.request_id
+		WHERE r.lottery_id = $1
+		ORDER BY p.request_id, p.rank
+	`, lottery.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lottery preferences: %w", err)
+	}
+	defer prefRows.Close()
+
+	for prefRows.Next() {
+		var (
+			requestId string
+			pref      LotteryPreference
+		)
+		if err := prefRows.Scan(&requestId, &pref.DeskId, &pref.ZoneId); err != nil {
+			return nil, fmt.Errorf("failed to scan lottery preference: %w", err)
+		}
+		i := byId[requestId]
+		entrants[i].request.Preferences = append(entrants[i].request.Preferences, pref)
+	}
+
+	return entrants, prefRows.Err()
+}
+
+// Бронирует участнику первый доступный стол по его предпочтениям на рабочие часы дня лотереи.
+// Если ни один не подошел, ставит участника в очередь ожидания по всем предпочтениям.
+func drawLotteryEntrant(ctx context.Context, tx *sql.Tx, lottery Lottery, office offices.Office, entrant lotteryEntrant, position int) (LotteryOutcome, error) {
+	req := drawnLotteryRequest(entrant, position)
+
+	outcome := LotteryOutcome{Lottery: lottery, UserEmail: entrant.email}
+
+draw:
+	for i, pref := range req.Preferences {
+		desks, err := lotteryCandidateDesks(ctx, tx, office, pref)
+		if err != nil {
+			return outcome, err
+		}
+
+		for _, deskId := range desks {
+			if _, err := tx.ExecContext(ctx, `SAVEPOINT lottery_desk`); err != nil {
+				return outcome, fmt.Errorf("failed to create savepoint: %w", err)
+			}
+
+			// Стол могли удалить или изменить после приема заявок: такой стол пропускается.
+			var (
+				dateFrom, dateTo time.Time
+				reservationId    string
+			)
+			deskHours, err := deskOffice(ctx, tx, deskId)
+			if err == nil {
+				dateFrom, dateTo, err = deskHours.WorkBounds(lottery.Day)
+			}
+			if err == nil {
+				reservationId, err = insertReservation(ctx, tx, deskId, req.UserId, req.UserId, dateFrom, dateTo, nil)
+			}
+			if err != nil {
+				if !isReservationRuleError(err) && !isReservationInputError(err) {
+					return outcome, err
+				}
+				if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT lottery_desk`); err != nil {
+					return outcome, fmt.Errorf("failed to rollback to savepoint: %w", err)
+				}
+				continue
+			}
+
+			rank := i + 1
+			req.Status = LotteryRequestWon
+			req.ReservationId = &reservationId
+			req.DeskId = &deskId
+			req.Rank = &rank
+			outcome.DateFrom, outcome.DateTo = dateFrom, dateTo
+			outcome.DeskOffice = deskHours.Key
+
+			if err := tx.QueryRowContext(ctx, `SELECT name FROM desks WHERE id = $1`, deskId).Scan(&outcome.DeskName); err != nil {
+				return outcome, fmt.Errorf("failed to load desk name: %w", err)
+			}
+			break draw
+		}
+	}
+
+	if req.Status == LotteryRequestLost {
+		joined, err := joinWaitlistAfterLottery(ctx, tx, lottery, office, req)
+		if err != nil {
+			return outcome, err
+		}
+		outcome.WaitlistEntries = joined
+	}
+
+	if err := saveLotteryRequest(ctx, tx, req); err != nil {
+		return outcome, err
+	}
+
+	outcome.Request = req
+	return outcome, nil
+}
+
+// Проигравшая заявка с местом в порядке розыгрыша и историей участника.
+func drawnLotteryRequest(entrant lotteryEntrant, position int) LotteryRequest {
+	req := entrant.request
+	req.Status = LotteryRequestLost
+	req.DrawPosition = &position
+	req.RecentWins = &entrant.wins
+	req.RecentLosses = &entrant.losses
+	req.DrawHash = &entrant.hash
+	return req
+}
+
+func saveLotteryRequest(ctx context.Context, tx *sql.Tx, req LotteryRequest) error {
+	query := `
+		UPDATE lottery_requests
+		SET status = $2, reservation_id = $3, won_desk_id = $4, won_rank = $5,
+			draw_position = $6, recent_wins = $7, recent_losses = $8, draw_hash = $9, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := tx.ExecContext(ctx, query, req.Id, req.Status, req.ReservationId, req.DeskId, req.Rank,
+		req.DrawPosition, req.RecentWins, req.RecentLosses, req.DrawHash)
+	if err != nil {
+		return fmt.Errorf("failed to save lottery result: %w", err)
+	}
+
+	return nil
+}
+
+func lotteryCandidateDesks(ctx context.Context, tx *sql.Tx, office offices.Office, pref LotteryPreference) ([]string, error) {
+	if pref.DeskId != nil {
+		return []string{*pref.DeskId}, nil
+	}
+
+	defaultOffice, err := offices.Get("")
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id FROM desks
+		WHERE zone_id = $1 AND type = 'desk' AND COALESCE(office, $3) = $2
+		ORDER BY name, id
+	`
+
+	rows, err := tx.QueryContext(ctx, query, pref.ZoneId, office.Key, defaultOffice.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query zone desks: %w", err)
+	}
+	defer rows.Close()
+
+	var desks []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan desk: %w", err)
+		}
+		desks = append(desks, id)
+	}
+
+	return desks, rows.Err()
+}
+
+// Ставит проигравшего в очередь ожидания с предложением брони по каждому предпочтению.
+// Записи, которые у пользователя уже есть, не дублируются. Возвращает число новых записей.
+func joinWaitlistAfterLottery(ctx context.Context, tx *sql.Tx, lottery Lottery, office offices.Office, req LotteryRequest) (int, error) {
+	joined := 0
+	for _, pref := range req.Preferences {
+		window := office
+		if pref.DeskId != nil {
+			var err error
+			if window, err = deskOffice(ctx, tx, *pref.DeskId); err != nil {
+				return 0, err
+			}
+		}
+
+		dateFrom, dateTo, err := window.WorkBounds(lottery.Day)
+		if err != nil {
+			return 0, err
+		}
+
+		query := `
+			INSERT INTO waitlist_entries (user_id, desk_id, zone_id, date_from, date_to, mode)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT DO NOTHING
+		`
+		result, err := tx.ExecContext(ctx, query, req.UserId, pref.DeskId, pref.ZoneId, dateFrom, dateTo, WaitlistModeOffer)
+		if err != nil {
+			return 0, fmt.Errorf("failed to add lottery request to waitlist: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to check waitlist result: %w", err)
+		}
+		joined += int(rowsAffected)
+	}
+
+	return joined, nil
+}
@@ -0,0 +1,128 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	policiesRepo "place-picker/internal/db/repo/policies"
+	"place-picker/internal/offices"
+)
+
+// Коды нарушений правил бронирования. Возвращаются клиенту, чтобы он мог отличить причину отказа.
+const (
+	PolicyMaxDaysAhead     = "max_days_ahead"
+	PolicyWeeklyQuota      = "weekly_quota_exceeded"
+	PolicyMonthlyQuota     = "monthly_quota_exceeded"
+	PolicyDurationTooShort = "duration_too_short"
+	PolicyDurationTooLong  = "duration_too_long"
+	PolicyBlackoutWeekday  = "blackout_weekday"
+	PolicyOfficeClosed     = "office_closed"
+	PolicyLotteryDay       = "lottery_day"
+	PolicyZoneCapacity     = "zone_capacity_reached"
+)
+
+type PolicyError struct {
+	Code    string
+	Message string
+}
+
+func (e *PolicyError) Error() string {
+	return e.Message
+}
+
+// Проверяет бронь по правилам роли пользователя. Недели и месяцы считаются по местному времени офиса.
+// Квоты считаются отдельно для каждого типа ресурса. excludeId исключает из квот изменяемую бронь.
+func checkPolicy(ctx context.Context, tx *sql.Tx, office offices.Office, userId, resourceType string, dateFrom, dateTo time.Time, excludeId *string) error {
+	policy, err := policiesRepo.GetUserPolicy(ctx, tx, userId)
+	if err != nil {
+		return err
+	}
+
+	localFrom := dateFrom.In(office.Location)
+
+	if policy.MaxDaysAhead != nil {
+		now := time.Now().In(office.Location)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, office.Location)
+		day := time.Date(localFrom.Year(), localFrom.Month(), localFrom.Day(), 0, 0, 0, 0, office.Location)
+		if daysBetween(today, day) > *policy.MaxDaysAhead {
+			return &PolicyError{Code: PolicyMaxDaysAhead, Message: fmt.Sprintf("reservations can be made at most %d days ahead", *policy.MaxDaysAhead)}
+		}
+	}
+
+	for _, weekday := range policy.BlackoutWeekdays {
+		if time.Weekday(weekday) == localFrom.Weekday() {
+			return &PolicyError{Code: PolicyBlackoutWeekday, Message: fmt.Sprintf("reservations are not allowed on %s", localFrom.Weekday())}
+		}
+	}
+
+	duration := dateTo.Sub(dateFrom)
+	if policy.MinDurationMinutes != nil && duration < time.Duration(*policy.MinDurationMinutes)*time.Minute {
+		return &PolicyError{Code: PolicyDurationTooShort, Message: fmt.Sprintf("reservation must last at least %d minutes", *policy.MinDurationMinutes)}
+	}
+	if policy.MaxDurationMinutes != nil && duration > time.Duration(*policy.MaxDurationMinutes)*time.Minute {
+		return &PolicyError{Code: PolicyDurationTooLong, Message: fmt.Sprintf("reservation must last at most %d minutes", *policy.MaxDurationMinutes)}
+	}
+
+	if policy.MaxPerWeek != nil || policy.MaxPerMonth != nil {
+		// Параллельные брони одного пользователя проверяются по очереди, иначе обе могут пройти квоту.
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, userId); err != nil {
+			return fmt.Errorf("failed to lock user reservations: %w", err)
+		}
+	}
+
+	if policy.MaxPerWeek != nil {
+		// Неделя начинается с понедельника.
+		offset := (int(localFrom.Weekday()) + 6) % 7
+		weekStart := time.Date(localFrom.Year(), localFrom.Month(), localFrom.Day()-offset, 0, 0, 0, 0, office.Location)
+		count, err := countUserReservations(ctx, tx, userId, resourceType, weekStart, weekStart.AddDate(0, 0, 7), excludeId)
+		if err != nil {
+			return err
+		}
+		if count >= *policy.MaxPerWeek {
+			return &PolicyError{Code: PolicyWeeklyQuota, Message: fmt.Sprintf("at most %d reservations per week are allowed", *policy.MaxPerWeek)}
+		}
+	}
+
+	if policy.MaxPerMonth != nil {
+		monthStart := time.Date(localFrom.Year(), localFrom.Month(), 1, 0, 0, 0, 0, office.Location)
+		count, err := countUserReservations(ctx, tx, userId, resourceType, monthStart, monthStart.AddDate(0, 1, 0), excludeId)
+		if err != nil {
+			return err
+		}
+		if count >= *policy.MaxPerMonth {
+			return &PolicyError{Code: PolicyMonthlyQuota, Message: fmt.Sprintf("at most %d reservations per month are allowed", *policy.MaxPerMonth)}
+		}
+	}
+
+	return nil
+}
+
+func countUserReservations(ctx context.Context, tx *sql.Tx, userId, resourceType string, from, to time.Time, excludeId *string) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM reservations
+		WHERE user_id = $1
+		  AND status IN ('active', 'pending')
+		  AND guest_name IS NULL
+		  AND resource_type = $5
+		  AND date_from >= $2
+		  AND date_from < $3
+		  AND ($4::uuid IS NULL OR id <> $4::uuid)
+	`
+
+	var count int
+	if err := tx.QueryRowContext(ctx, query, userId, from, to, excludeId, resourceType).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count user reservations: %w", err)
+	}
+
+	return count, nil
+}
+
+// Количество календарных дней между двумя полуночами. Учитывает дни длиной 23 и 25 часов.
+func daysBetween(from, to time.Time) int {
+	fromUTC := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toUTC := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toUTC.Sub(fromUTC).Hours() / 24)
+}
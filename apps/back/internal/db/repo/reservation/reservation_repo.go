@@ -8,6 +8,41 @@ import (
 	"time"
 
 	"github.com/lib/pq"
+
+	"place-picker/internal/offices"
+)
+
+var ErrUserHasReservation = errors.New("this user already has a reservation for this period")
+var ErrDeskAlreadyReserved = errors.New("this desk is already reserved for this period")
+var ErrDeskAssigned = errors.New("this desk is assigned to another user for this day")
+var ErrDeskRestricted = errors.New("this desk is restricted to other groups")
+var ErrAlreadyCheckedIn = errors.New("reservation is already checked in")
+var ErrBatchConflict = errors.New("some days cannot be reserved")
+var ErrDeskNotFound = errors.New("desk not found")
+var ErrOutsideWorkingHours = errors.New("reservation must be within office working hours")
+var ErrAttendeesNotAllowed = errors.New("this resource type does not allow attendees")
+var ErrTooManyAttendees = errors.New("attendees exceed resource capacity")
+var ErrInvalidPeriod = errors.New("reservation must end after it starts")
+var ErrReservationNotActive = errors.New("reservation is not approved")
+
+// Статусы брони. active и pending занимают стол, остальные остаются только в истории.
+// completed — бронь завершилась, no_show — освобождена без отметки о приходе.
+const (
+	StatusActive    = "active"
+	StatusPending   = "pending"
+	StatusDeclined  = "declined"
+	StatusExpired   = "expired"
+	StatusCancelled = "cancelled"
+	StatusCompleted = "completed"
+	StatusNoShow    = "no_show"
+)
+
+// Причины отмены, которые система записывает сама.
+const (
+	cancelReasonSeriesChanged = "series changed"
+	cancelReasonSeriesDeleted = "series deleted"
+	cancelReasonOfferDeclined = "waitlist offer declined"
+	cancelReasonOfferExpired  = "waitlist offer expired"
 )
 
 type (
@@ -20,10 +55,52 @@ type (
 		DateTo   time.Time `json:"dateTo"`
 	}
 
+	// Бронь в списке пользователя: его собственная или оформленная им за другого.
 	UserReservation struct {
-		ReservationId string            `json:"reservationId"`
-		TableId       string            `json:"tableId"`
-		ReservedSlots []ReservationSlot `json:"reservedSlots"`
+		ReservationId   string            `json:"reservationId"`
+		TableId         string            `json:"tableId"`
+		ReservedSlots   []ReservationSlot `json:"reservedSlots"`
+		CheckedInAt     *time.Time        `json:"checkedInAt"`
+		SeriesId        *string           `json:"seriesId"`
+		Status          string            `json:"status"`
+		DecisionComment *string           `json:"decisionComment"`
+		Guest           *Guest            `json:"guest"`
+		ResourceType    string            `json:"resourceType"`
+		Attendees       []string          `json:"attendees"`
+		UserId          string            `json:"userId"`
+		UserName        string            `json:"userName"`
+		BookedBy        *string           `json:"bookedBy"`
+		BookedByName    *string           `json:"bookedByName"`
+		// Заполняются у отмененной брони. CancelledBy — null, если бронь отменила система.
+		CancelledAt        *time.Time `json:"cancelledAt"`
+		CancelledBy        *string    `json:"cancelledBy"`
+		CancellationReason *string    `json:"cancellationReason"`
+	}
+
+	// UserId — кто занимает стол, BookedBy — кто оформил бронь.
+	Reservation struct {
+		Id          string
+		DeskId      string
+		UserId      string
+		BookedBy    *string
+		DateFrom    time.Time
+		DateTo      time.Time
+		CheckedInAt *time.Time
+		SeriesId    *string
+		Status      string
+	}
+
+	DayConflict struct {
+		Day   string `json:"day"`
+		Error string `json:"error"`
+		Code  string `json:"code,omitempty"`
+	}
+
+	// Status — статус созданных броней: pending, если стол требует подтверждения.
+	BatchResult struct {
+		Reserved  []string      `json:"reserved"`
+		Conflicts []DayConflict `json:"conflicts"`
+		Status    string        `json:"status"`
 	}
 )
 
@@ -31,68 +108,362 @@ func NewReservationsRepository(db *sql.DB) *ReservationsRepository {
 	return &ReservationsRepository{db: db}
 }
 
-func (r *ReservationsRepository) CreateReservation(ctx context.Context, deskId, userId string, dateFrom, dateTo time.Time) error {
-	query := `
-		INSERT INTO reservations (desk_id, user_id, date_from, date_to)
-		VALUES ($1, $2, $3, $4)
-	`
+// Создает брони стола на несколько дней в одной транзакции. Каждый день проверяется отдельно,
+// поэтому в результате перечислены все конфликтующие дни, а не только первый.
+// Без bestEffort при любом конфликте ничего не сохраняется и возвращается ErrBatchConflict,
+// с bestEffort свободные дни бронируются, а занятые пропускаются.
+// bookedBy — пользователь, оформляющий бронь за userId; для брони за себя совпадает с userId.
+// attendees — участники брони переговорной, они добавляются к брони каждого дня.
+func (r *ReservationsRepository) CreateReservations(ctx context.Context, deskId, userId, bookedBy string, attendees []string, slots []ReservationSlot, bestEffort bool) (BatchResult, error) {
+	result := BatchResult{Reserved: []string{}, Conflicts: []DayConflict{}, Status: StatusActive}
 
-	_, err := r.db.ExecContext(ctx, query, deskId, userId, dateFrom, dateTo)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		var pqErr *pq.Error
-		if ok := errors.As(err, &pqErr); ok {
-			switch pqErr.Constraint {
-			case "one_desk_per_user_per_period":
-				return fmt.Errorf("this user already has a reservation for this period")
-			case "one_reservation_per_desk_per_period":
-				return fmt.Errorf("this desk is already reserved for this period")
+		return result, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkBookingPermission(ctx, tx, bookedBy, userId); err != nil {
+		return result, err
+	}
+
+	if err := checkAttendees(ctx, tx, deskId, attendees); err != nil {
+		return result, err
+	}
+
+	for _, slot := range slots {
+		day := slot.DateFrom.Format(time.DateOnly)
+
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT reservation_day`); err != nil {
+			return result, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		reservationId, err := insertReservation(ctx, tx, deskId, userId, bookedBy, slot.DateFrom, slot.DateTo, nil)
+		if err != nil {
+			if !isReservationRuleError(err) {
+				return result, err
 			}
+
+			if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT reservation_day`); err != nil {
+				return result, fmt.Errorf("failed to rollback to savepoint: %w", err)
+			}
+
+			conflict := DayConflict{Day: day, Error: err.Error()}
+			var policyErr *PolicyError
+			if errors.As(err, &policyErr) {
+				conflict.Code = policyErr.Code
+			}
+			result.Conflicts = append(result.Conflicts, conflict)
+			continue
+		}
+
+		if err := insertAttendees(ctx, tx, reservationId, attendees); err != nil {
+			return result, err
 		}
 
+		if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT reservation_day`); err != nil {
+			return result, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+
+		result.Reserved = append(result.Reserved, day)
+	}
+
+	if len(result.Conflicts) > 0 && (!bestEffort || len(result.Reserved) == 0) {
+		result.Reserved = []string{}
+		return result, ErrBatchConflict
+	}
+
+	var requiresApproval bool
+	if err := tx.QueryRowContext(ctx, `SELECT requires_approval FROM desks WHERE id = $1`, deskId).Scan(&requiresApproval); err != nil {
+		return result, fmt.Errorf("failed to check desk approval: %w", err)
+	}
+	if requiresApproval {
+		result.Status = StatusPending
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// Проверяет правила бронирования стола и добавляет бронь в рамках переданной транзакции.
+// Правила проверяются для userId, который будет занимать стол, а не для bookedBy.
+// Бронь стола, требующего подтверждения, создается в статусе pending.
+func insertReservation(ctx context.Context, tx *sql.Tx, deskId, userId, bookedBy string, dateFrom, dateTo time.Time, seriesId *string) (string, error) {
+	if err := checkReservationRules(ctx, tx, deskId, userId, dateFrom, dateTo, nil); err != nil {
+		return "", err
+	}
+
+	query := `
+		INSERT INTO reservations (desk_id, user_id, booked_by, date_from, date_to, series_id, status)
+		VALUES ($1, $2, $3, $4, $5, $6, ` + deskInitialStatus + `)
+		RETURNING id
+	`
+
+	var id string
+	if err := tx.QueryRowContext(ctx, query, deskId, userId, bookedBy, dateFrom, dateTo, seriesId).Scan(&id); err != nil {
+		return "", mapConstraintError(err)
+	}
+
+	return id, nil
+}
+
+func checkReservationRules(ctx context.Context, tx *sql.Tx, deskId, userId string, dateFrom, dateTo time.Time, excludeId *string) error {
+	res, err := loadResource(ctx, tx, deskId)
+	if err != nil {
+		return err
+	}
+
+	if err := checkWorkingHours(res.Office, dateFrom, dateTo); err != nil {
+		return err
+	}
+
+	if err := checkResourceDuration(res, dateFrom, dateTo); err != nil {
+		return err
+	}
+
+	if err := checkPolicy(ctx, tx, res.Office, userId, res.Type, dateFrom, dateTo, excludeId); err != nil {
+		return err
+	}
+
+	return checkDeskRules(ctx, tx, res.Office, deskId, userId, dateFrom, excludeId)
+}
+
+// Правила брони для гостя. Правила роли пригласившего относятся к его собственным броням и не проверяются,
+// а закрепления и ограничения стола проверяются для пригласившего.
+func checkGuestReservationRules(ctx context.Context, tx *sql.Tx, deskId, hostId string, dateFrom, dateTo time.Time, excludeId *string) error {
+	res, err := loadResource(ctx, tx, deskId)
+	if err != nil {
+		return err
+	}
+
+	if err := checkWorkingHours(res.Office, dateFrom, dateTo); err != nil {
+		return err
+	}
+
+	if err := checkResourceDuration(res, dateFrom, dateTo); err != nil {
+		return err
+	}
+
+	return checkDeskRules(ctx, tx, res.Office, deskId, hostId, dateFrom, excludeId)
+}
+
+// excludeId исключает переносимую бронь из занятости зоны.
+func checkDeskRules(ctx context.Context, tx *sql.Tx, office offices.Office, deskId, userId string, dateFrom time.Time, excludeId *string) error {
+	day := office.Day(dateFrom)
+
+	if err := checkOfficeClosure(ctx, tx, office, day); err != nil {
 		return err
 	}
 
+	if err := checkLotteryDay(ctx, tx, office, day); err != nil {
+		return err
+	}
+
+	if err := checkDeskAssignment(ctx, tx, deskId, userId, day); err != nil {
+		return err
+	}
+
+	if err := checkDeskRestriction(ctx, tx, deskId, userId, day, office.Day(time.Now())); err != nil {
+		return err
+	}
+
+	return checkZoneCapacity(ctx, tx, office, deskId, day, excludeId)
+}
+
+// Возвращает офис стола, по которому проверяются рабочие часы и местные даты брони.
+func (r *ReservationsRepository) GetDeskOffice(ctx context.Context, deskId string) (offices.Office, error) {
+	return deskOffice(ctx, r.db, deskId)
+}
+
+// Возвращает офис зоны по ее столам: все столы зоны находятся в одном офисе. Зона без столов относится к офису по умолчанию.
+func (r *ReservationsRepository) GetZoneOffice(ctx context.Context, zoneId string) (offices.Office, error) {
+	query := `
+		SELECT d.office
+		FROM zones z
+		LEFT JOIN desks d ON d.zone_id = z.id
+		WHERE z.id = $1
+		ORDER BY d.office NULLS LAST
+		LIMIT 1
+	`
+
+	var office sql.NullString
+	if err := r.db.QueryRowContext(ctx, query, zoneId).Scan(&office); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return offices.Office{}, ErrZoneNotFound
+		}
+		return offices.Office{}, fmt.Errorf("failed to get zone office: %w", err)
+	}
+
+	return offices.Get(office.String)
+}
+
+func insertAttendees(ctx context.Context, tx *sql.Tx, reservationId string, attendees []string) error {
+	if len(attendees) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO reservation_attendees (reservation_id, user_id)
+		SELECT $1, a FROM unnest($2::uuid[]) AS a
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, query, reservationId, pq.Array(attendees)); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to add attendees: %w", err)
+	}
+
 	return nil
 }
 
+// Начальный статус брони стола $1: pending, если стол требует подтверждения.
+const deskInitialStatus = `COALESCE((SELECT CASE WHEN d.requires_approval THEN 'pending' ELSE 'active' END FROM desks d WHERE d.id = $1), 'active')`
+
+func mapConstraintError(err error) error {
+	var pqErr *pq.Error
+	if ok := errors.As(err, &pqErr); ok {
+		switch pqErr.Constraint {
+		case "one_desk_per_user_per_period":
+			return ErrUserHasReservation
+		case "one_reservation_per_desk_per_period":
+			return ErrDeskAlreadyReserved
+		case "reservation_period_valid":
+			return ErrInvalidPeriod
+		case "reservations_desk_id_fkey":
+			return ErrDeskNotFound
+		case "reservations_user_id_fkey":
+			return ErrUserNotFound
+		}
+	}
+
+	return err
+}
+
+// Поля брони в списке пользователя. Запрос выбирает их из подзапроса r с колонкой attendees
+// и присоединяет занимающего стол u и оформившего бронь b.
+const userReservationColumns = `
+	r.id, r.desk_id, r.date_from, r.date_to, r.checked_in_at, r.series_id, r.status, r.decision_comment,
+	r.guest_name, r.guest_email, r.guest_company, r.resource_type, r.attendees,
+	r.user_id, COALESCE(u.name, ''), r.booked_by, b.name,
+	r.cancelled_at, r.cancelled_by, r.cancellation_reason
+`
+
+// Колонки, общие для действующих и архивных броней.
+const historyColumns = `
+	id, desk_id, user_id, booked_by, date_from, date_to, checked_in_at, series_id, status,
+	decided_by, decided_at, decision_comment,
+	guest_name, guest_email, guest_company, resource_type, cancelled_at, cancelled_by, cancellation_reason
+`
+
+// Возвращает текущие брони пользователя и брони, которые он оформил за других.
+// Отмененные, завершившиеся и неявки доступны в истории.
 func (r *ReservationsRepository) GetUserReservations(ctx context.Context, userId string) ([]UserReservation, error) {
 	query := `
-		SELECT 
-			id AS reservation_id,
-			desk_id AS table_id,
-			date_from,
-			date_to
-		FROM reservations
-		WHERE user_id = $1
-		ORDER BY date_from;
+		SELECT ` + userReservationColumns + `
+		FROM (
+			SELECT r.*, COALESCE((SELECT array_agg(a.user_id) FROM reservation_attendees a WHERE a.reservation_id = r.id), '{}') AS attendees
+			FROM reservations r
+			WHERE r.status IN ('active', 'pending', 'declined', 'expired')
+			  AND r.date_to > NOW()
+			  AND (
+				r.user_id = $1
+				OR r.booked_by = $1
+				OR EXISTS (SELECT 1 FROM reservation_attendees a WHERE a.reservation_id = r.id AND a.user_id = $1)
+			  )
+		) r
+		LEFT JOIN users u ON u.id = r.user_id
+		LEFT JOIN users b ON b.id = r.booked_by
+		ORDER BY r.date_from
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userId)
+	return r.queryUserReservations(ctx, query, userId)
+}
+
+// История броней пользователя, которые начинаются в интервале [from, to), вместе с архивом:
+// отмененные, завершившиеся, неявки и текущие. Новые брони идут первыми.
+func (r *ReservationsRepository) GetReservationHistory(ctx context.Context, userId string, from, to time.Time) ([]UserReservation, error) {
+	query := `
+		SELECT ` + userReservationColumns + `
+		FROM (
+			SELECT ` + historyColumns + `,
+				COALESCE((SELECT array_agg(a.user_id) FROM reservation_attendees a WHERE a.reservation_id = r.id), '{}') AS attendees
+			FROM reservations r
+			WHERE r.date_from >= $2
+			  AND r.date_from < $3
+			  AND (
+				r.user_id = $1
+				OR r.booked_by = $1
+				OR EXISTS (SELECT 1 FROM reservation_attendees a WHERE a.reservation_id = r.id AND a.user_id = $1)
+			  )
+			UNION ALL
+			SELECT ` + historyColumns + `, attendees
+			FROM reservations_archive
+			WHERE date_from >= $2
+			  AND date_from < $3
+			  AND (user_id = $1 OR booked_by = $1 OR $1 = ANY(attendees))
+		) r
+		LEFT JOIN users u ON u.id = r.user_id
+		LEFT JOIN users b ON b.id = r.booked_by
+		ORDER BY r.date_from DESC
+	`
+
+	return r.queryUserReservations(ctx, query, userId, from, to)
+}
+
+func (r *ReservationsRepository) queryUserReservations(ctx context.Context, query string, args ...any) ([]UserReservation, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query user reservations: %w", err)
 	}
 	defer rows.Close()
 
-	var reservations []UserReservation
+	reservations := []UserReservation{}
 	for rows.Next() {
 		var (
-			reservationId string
-			tableId       string
-			dateFrom      time.Time
-			dateTo        time.Time
+			reservation UserReservation
+			slot        ReservationSlot
+			guestName   *string
+			guest       Guest
 		)
 
-		if err := rows.Scan(&reservationId, &tableId, &dateFrom, &dateTo); err != nil {
+		err := rows.Scan(
+			&reservation.ReservationId,
+			&reservation.TableId,
+			&slot.DateFrom,
+			&slot.DateTo,
+			&reservation.CheckedInAt,
+			&reservation.SeriesId,
+			&reservation.Status,
+			&reservation.DecisionComment,
+			&guestName,
+			&guest.Email,
+			&guest.Company,
+			&reservation.ResourceType,
+			pq.Array(&reservation.Attendees),
+			&reservation.UserId,
+			&reservation.UserName,
+			&reservation.BookedBy,
+			&reservation.BookedByName,
+			&reservation.CancelledAt,
+			&reservation.CancelledBy,
+			&reservation.CancellationReason,
+		)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan reservation: %w", err)
 		}
 
-		reservations = append(reservations, UserReservation{
-			ReservationId: reservationId,
-			TableId:       tableId,
-			ReservedSlots: []ReservationSlot{
-				{DateFrom: dateFrom, DateTo: dateTo},
-			},
-		})
+		reservation.ReservedSlots = []ReservationSlot{slot}
+		if guestName != nil {
+			guest.Name = *guestName
+			reservation.Guest = &guest
+		}
+
+		reservations = append(reservations, reservation)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -102,43 +473,212 @@ func (r *ReservationsRepository) GetUserReservations(ctx context.Context, userId
 	return reservations, nil
 }
 
-func (r *ReservationsRepository) DeleteReservation(ctx context.Context, reservationId, userId string) error {
+// Возвращает бронь, которую пользователь занимает или оформил за другого.
+func (r *ReservationsRepository) GetReservation(ctx context.Context, reservationId, userId string) (*Reservation, error) {
 	query := `
-		DELETE FROM reservations
-		WHERE id = $1 AND user_id = $2
+		SELECT id, desk_id, user_id, booked_by, date_from, date_to, checked_in_at, series_id, status
+		FROM reservations
+		WHERE id = $1 AND (user_id = $2 OR booked_by = $2)
+	`
+
+	var res Reservation
+	err := r.db.QueryRowContext(ctx, query, reservationId, userId).Scan(
+		&res.Id,
+		&res.DeskId,
+		&res.UserId,
+		&res.BookedBy,
+		&res.DateFrom,
+		&res.DateTo,
+		&res.CheckedInAt,
+		&res.SeriesId,
+		&res.Status,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	return &res, nil
+}
+
+// Переносит бронь на другой стол или время. Проверяет те же правила, что и при создании.
+// Перенести бронь может тот, кто занимает стол, или тот, кто ее оформил. Отклоненные и просроченные брони не переносятся.
+// Перенесенная бронь стола, требующего подтверждения, снова ждет подтверждения.
+func (r *ReservationsRepository) UpdateReservation(ctx context.Context, reservationId, userId, deskId string, dateFrom, dateTo time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		occupantId string
+		isGuest    bool
+	)
+	lockQuery := `
+		SELECT user_id, guest_name IS NOT NULL
+		FROM reservations
+		WHERE id = $1 AND (user_id = $2 OR booked_by = $2) AND status IN ('active', 'pending')
+		FOR UPDATE
+	`
+	if err := tx.QueryRowContext(ctx, lockQuery, reservationId, userId).Scan(&occupantId, &isGuest); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("failed to lock reservation: %w", err)
+	}
+
+	if isGuest {
+		err = checkGuestReservationRules(ctx, tx, deskId, occupantId, dateFrom, dateTo, &reservationId)
+	} else {
+		err = checkReservationRules(ctx, tx, deskId, occupantId, dateFrom, dateTo, &reservationId)
+	}
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE reservations
+		SET desk_id = $1, date_from = $2, date_to = $3, status = ` + deskInitialStatus + `,
+			decided_by = NULL, decided_at = NULL, decision_comment = NULL, updated_at = NOW()
+		WHERE id = $4
+	`
+
+	if _, err := tx.ExecContext(ctx, query, deskId, dateFrom, dateTo, reservationId); err != nil {
+		return mapConstraintError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ReservationsRepository) CheckIn(ctx context.Context, reservationId string) error {
+	query := `
+		UPDATE reservations
+		SET checked_in_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND checked_in_at IS NULL AND status = 'active'
 	`
 
-	result, err := r.db.ExecContext(ctx, query, reservationId, userId)
+	result, err := r.db.ExecContext(ctx, query, reservationId)
 	if err != nil {
-		return fmt.Errorf("failed to delete reservation: %w", err)
+		return fmt.Errorf("failed to check in reservation: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to check delete result: %w", err)
+		return fmt.Errorf("failed to check update result: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return sql.ErrNoRows
+		return ErrAlreadyCheckedIn
 	}
 
 	return nil
 }
 
-func (r *ReservationsRepository) DeleteAllUserReservations(ctx context.Context, userId string) error {
+// Освобождает брони без отметки о приходе, у которых истек льготный период, переводя их в статус no_show.
+// Гостевые брони не освобождаются: у гостя нет учетной записи, чтобы отметиться.
+// Брони ресурсов, тип которых не требует отметки, например парковки, тоже не освобождаются.
+func (r *ReservationsRepository) ReleaseNoShows(ctx context.Context, gracePeriod time.Duration) (int64, error) {
 	query := `
-		DELETE FROM reservations
-		WHERE user_id = $1
+		UPDATE reservations
+		SET status = 'no_show', updated_at = NOW()
+		WHERE status = 'active'
+		  AND guest_name IS NULL
+		  AND resource_type IN (SELECT key FROM resource_types WHERE checkin_required)
+		  AND checked_in_at IS NULL
+		  AND date_from + make_interval(secs => $1) < NOW()
+	`
+
+	result, err := r.db.ExecContext(ctx, query, gracePeriod.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to release no-show reservations: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check release result: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// Отменяет бронь, которую пользователь занимает или оформил за другого, и возвращает отмененную бронь.
+// Отменить можно только бронь, которая занимает стол. Бронь остается в истории со статусом cancelled.
+// Если бронь входит в серию, ее день добавляется в исключения серии,
+// чтобы она не была создана заново при пересчете серии.
+func (r *ReservationsRepository) CancelReservation(ctx context.Context, reservationId, userId string) (*Reservation, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE reservations
+		SET status = 'cancelled', cancelled_at = NOW(), cancelled_by = $2, updated_at = NOW()
+		WHERE id = $1 AND (user_id = $2 OR booked_by = $2) AND status IN ('active', 'pending')
+		RETURNING id, desk_id, user_id, booked_by, date_from, date_to, checked_in_at, series_id, status
+	`
+
+	var res Reservation
+	err = tx.QueryRowContext(ctx, query, reservationId, userId).Scan(
+		&res.Id,
+		&res.DeskId,
+		&res.UserId,
+		&res.BookedBy,
+		&res.DateFrom,
+		&res.DateTo,
+		&res.CheckedInAt,
+		&res.SeriesId,
+		&res.Status,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("failed to cancel reservation: %w", err)
+	}
+
+	if res.SeriesId != nil {
+		office, err := deskOffice(ctx, tx, res.DeskId)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := addSeriesExDate(ctx, tx, *res.SeriesId, office.Day(res.DateFrom)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &res, nil
+}
+
+// Отменяет все еще не закончившиеся брони пользователя.
+func (r *ReservationsRepository) CancelAllUserReservations(ctx context.Context, userId string) error {
+	query := `
+		UPDATE reservations
+		SET status = 'cancelled', cancelled_at = NOW(), cancelled_by = $1, updated_at = NOW()
+		WHERE user_id = $1 AND status IN ('active', 'pending') AND date_to > NOW()
 	`
 
 	result, err := r.db.ExecContext(ctx, query, userId)
 	if err != nil {
-		return fmt.Errorf("failed to delete user reservations: %w", err)
+		return fmt.Errorf("failed to cancel user reservations: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to check delete result: %w", err)
+		return fmt.Errorf("failed to check cancel result: %w", err)
 	}
 
 	if rowsAffected == 0 {
@@ -148,20 +688,82 @@ func (r *ReservationsRepository) DeleteAllUserReservations(ctx context.Context,
 	return nil
 }
 
-func (r *ReservationsRepository) DeleteOldReservations(ctx context.Context) (int64, error) {
+// Закрывает закончившиеся брони: состоявшиеся переходят в completed,
+// так и не подтвержденные — в expired.
+func (r *ReservationsRepository) CompleteReservations(ctx context.Context) (int64, error) {
 	query := `
-		DELETE FROM reservations
-		WHERE date_to < NOW()
+		UPDATE reservations
+		SET status = CASE WHEN status = 'active' THEN 'completed' ELSE 'expired' END, updated_at = NOW()
+		WHERE status IN ('active', 'pending') AND date_to < NOW()
 	`
 
 	result, err := r.db.ExecContext(ctx, query)
 	if err != nil {
-		return 0, fmt.Errorf("failed to delete old reservations: %w", err)
+		return 0, fmt.Errorf("failed to complete reservations: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check complete result: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// Переносит в архив закрытые брони, закончившиеся раньше, чем retention назад.
+// Вместе с бронью в архив попадает список ее участников.
+func (r *ReservationsRepository) ArchiveReservations(ctx context.Context, retention time.Duration) (int64, error) {
+	// Бронь удаляется, только если попала в архив. Уже архивированная с тем же id бронь
+	// перезаписывается текущей, чтобы строка не потерялась.
+	query := `
+		WITH candidates AS (
+			SELECT ` + historyColumns + `, created_at
+			FROM reservations
+			WHERE date_to < NOW() - make_interval(secs => $1)
+			  AND status NOT IN ('active', 'pending')
+			FOR UPDATE
+		),
+		archived AS (
+			INSERT INTO reservations_archive (` + historyColumns + `, created_at, attendees)
+			SELECT ` + historyColumns + `, created_at,
+				COALESCE((SELECT array_agg(a.user_id) FROM reservation_attendees a WHERE a.reservation_id = candidates.id), '{}')
+			FROM candidates
+			ON CONFLICT (id) DO UPDATE SET
+				desk_id = EXCLUDED.desk_id,
+				user_id = EXCLUDED.user_id,
+				booked_by = EXCLUDED.booked_by,
+				date_from = EXCLUDED.date_from,
+				date_to = EXCLUDED.date_to,
+				checked_in_at = EXCLUDED.checked_in_at,
+				series_id = EXCLUDED.series_id,
+				status = EXCLUDED.status,
+				decided_by = EXCLUDED.decided_by,
+				decided_at = EXCLUDED.decided_at,
+				decision_comment = EXCLUDED.decision_comment,
+				guest_name = EXCLUDED.guest_name,
+				guest_email = EXCLUDED.guest_email,
+				guest_company = EXCLUDED.guest_company,
+				resource_type = EXCLUDED.resource_type,
+				cancelled_at = EXCLUDED.cancelled_at,
+				cancelled_by = EXCLUDED.cancelled_by,
+				cancellation_reason = EXCLUDED.cancellation_reason,
+				attendees = EXCLUDED.attendees,
+				created_at = EXCLUDED.created_at,
+				archived_at = NOW()
+			RETURNING id
+		)
+		DELETE FROM reservations
+		WHERE id IN (SELECT id FROM archived)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, retention.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive reservations: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return 0, fmt.Errorf("failed to check delete result: %w", err)
+		return 0, fmt.Errorf("failed to check archive result: %w", err)
 	}
 
 	return rowsAffected, nil
@@ -0,0 +1,198 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"place-picker/internal/offices"
+)
+
+// Ресурс брони и правила его типа. Office содержит часы работы типа, если они заданы.
+type resource struct {
+	Type            string
+	Office          offices.Office
+	MinDuration     *int
+	MaxDuration     *int
+	AllowsAttendees bool
+	Capacity        *int
+}
+
+// Возвращает офис стола. Столы без офиса относятся к офису по умолчанию.
+// Если у типа ресурса свои часы работы, они заменяют часы офиса.
+func deskOffice(ctx context.Context, q interface {
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}, deskId string) (offices.Office, error) {
+	res, err := loadResource(ctx, q, deskId)
+	if err != nil {
+		return offices.Office{}, err
+	}
+
+	return res.Office, nil
+}
+
+func loadResource(ctx context.Context, q interface {
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}, deskId string) (resource, error) {
+	query := `
+		SELECT d.office, d.type, d.capacity, to_char(t.work_start, 'HH24:MI'), to_char(t.work_end, 'HH24:MI'),
+			t.min_duration_minutes, t.max_duration_minutes, t.allows_attendees
+		FROM desks d
+		JOIN resource_types t ON t.key = d.type
+		WHERE d.id = $1
+	`
+
+	var (
+		res                resource
+		office             sql.NullString
+		workStart, workEnd sql.NullString
+	)
+	err := q.QueryRowContext(ctx, query, deskId).Scan(
+		&office, &res.Type, &res.Capacity, &workStart, &workEnd, &res.MinDuration, &res.MaxDuration, &res.AllowsAttendees,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return resource{}, ErrDeskNotFound
+		}
+		return resource{}, fmt.Errorf("failed to load resource: %w", err)
+	}
+
+	res.Office, err = offices.Get(office.String)
+	if err != nil {
+		return resource{}, err
+	}
+
+	if workStart.Valid && workEnd.Valid {
+		res.Office, err = res.Office.WithHours(workStart.String, workEnd.String)
+		if err != nil {
+			return resource{}, fmt.Errorf("invalid %s hours: %w", res.Type, err)
+		}
+	}
+
+	return res, nil
+}
+
+// Длительность брони должна укладываться в правила типа ресурса.
+// Нарушение возвращается с теми же кодами, что и у правил ролей.
+func checkResourceDuration(res resource, dateFrom, dateTo time.Time) error {
+	duration := dateTo.Sub(dateFrom)
+	if res.MinDuration != nil && duration < time.Duration(*res.MinDuration)*time.Minute {
+		return &PolicyError{Code: PolicyDurationTooShort, Message: fmt.Sprintf("%s reservation must last at least %d minutes", res.Type, *res.MinDuration)}
+	}
+	if res.MaxDuration != nil && duration > time.Duration(*res.MaxDuration)*time.Minute {
+		return &PolicyError{Code: PolicyDurationTooLong, Message: fmt.Sprintf("%s reservation must last at most %d minutes", res.Type, *res.MaxDuration)}
+	}
+
+	return nil
+}
+
+// Участников можно приглашать только в ресурсы типов, которые это разрешают.
+// Вместе с организатором участников не больше вместимости ресурса.
+func checkAttendees(ctx context.Context, tx *sql.Tx, deskId string, attendees []string) error {
+	if len(attendees) == 0 {
+		return nil
+	}
+
+	res, err := loadResource(ctx, tx, deskId)
+	if err != nil {
+		return err
+	}
+
+	if !res.AllowsAttendees {
+		return ErrAttendeesNotAllowed
+	}
+
+	if res.Capacity != nil && len(attendees)+1 > *res.Capacity {
+		return ErrTooManyAttendees
+	}
+
+	return nil
+}
+
+// Бронь должна укладываться в рабочие часы одного дня по местному времени офиса стола.
+func checkWorkingHours(office offices.Office, dateFrom, dateTo time.Time) error {
+	if !office.Contains(dateFrom, dateTo) {
+		return ErrOutsideWorkingHours
+	}
+
+	return nil
+}
+
+// Закрепленный за другим пользователем стол можно забронировать только на день,
+// который владелец освободил.
+func checkDeskAssignment(ctx context.Context, tx *sql.Tx, deskId, userId, day string) error {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM desk_assignments a
+			WHERE a.desk_id = $1
+			  AND a.user_id <> $2
+			  AND daterange(a.date_from, a.date_to, '[]') @> $3::date
+			  AND NOT EXISTS (
+				SELECT 1
+				FROM desk_releases dr
+				WHERE dr.assignment_id = a.id
+				  AND dr.day = $3::date
+			  )
+		)
+	`
+
+	var assigned bool
+	if err := tx.QueryRowContext(ctx, query, deskId, userId, day).Scan(&assigned); err != nil {
+		return fmt.Errorf("failed to check desk assignment: %w", err)
+	}
+
+	if assigned {
+		return ErrDeskAssigned
+	}
+
+	return nil
+}
+
+// Стол с ограничением по группам может забронировать только участник одной из групп стола или его зоны.
+// Ограничение зоны снимается за open_days_before дней до даты брони, но только для столов без своего ограничения:
+// стол со своими группами по-прежнему доступен их участникам и участникам групп зоны. Даты берутся по местному времени офиса.
+func checkDeskRestriction(ctx context.Context, tx *sql.Tx, deskId, userId, day, today string) error {
+	query := `
+		WITH allowed_groups AS (
+			SELECT dg.group_id
+			FROM desk_group_restrictions dg
+			WHERE dg.desk_id = $1
+			UNION
+			SELECT zg.group_id
+			FROM desks d
+			JOIN zone_group_restrictions zg ON zg.zone_id = d.zone_id
+			WHERE d.id = $1
+		)
+		SELECT
+			EXISTS (SELECT 1 FROM allowed_groups)
+			AND NOT EXISTS (
+				SELECT 1
+				FROM allowed_groups ag
+				JOIN user_group_members m ON m.group_id = ag.group_id
+				WHERE m.user_id = $2
+			)
+			AND NOT EXISTS (
+				SELECT 1
+				FROM desks d
+				JOIN zones z ON z.id = d.zone_id
+				WHERE d.id = $1
+				  AND z.open_days_before IS NOT NULL
+				  AND $3::date - $4::date <= z.open_days_before
+				  AND NOT EXISTS (SELECT 1 FROM desk_group_restrictions dg WHERE dg.desk_id = d.id)
+			)
+	`
+
+	var restricted bool
+	if err := tx.QueryRowContext(ctx, query, deskId, userId, day, today).Scan(&restricted); err != nil {
+		return fmt.Errorf("failed to check desk restriction: %w", err)
+	}
+
+	if restricted {
+		return ErrDeskRestricted
+	}
+
+	return nil
+}
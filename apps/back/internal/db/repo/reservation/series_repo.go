@@ -0,0 +1,342 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// UserId — кто занимает стол по серии, BookedBy — кто оформил серию.
+type Series struct {
+	Id               string    `json:"id"`
+	DeskId           string    `json:"deskId"`
+	UserId           string    `json:"userId"`
+	BookedBy         string    `json:"bookedBy"`
+	RRule            string    `json:"rrule"`
+	ExDates          []string  `json:"exdates"`
+	DateFrom         time.Time `json:"dateFrom"`
+	DateTo           time.Time `json:"dateTo"`
+	ExpandedUntil    time.Time `json:"expandedUntil"`
+	OccurrencesCount int       `json:"occurrencesCount"`
+}
+
+const seriesColumns = `
+	s.id,
+	s.desk_id,
+	s.user_id,
+	COALESCE(s.booked_by, s.user_id),
+	s.rrule,
+	(SELECT COALESCE(array_agg(to_char(d, 'YYYY-MM-DD') ORDER BY d), '{}') FROM unnest(s.exdates) AS d),
+	s.date_from,
+	s.date_to,
+	s.expanded_until,
+	(SELECT COUNT(*) FROM reservations r WHERE r.series_id = s.id AND r.status IN ('active', 'pending'))
+`
+
+func scanSeries(row interface{ Scan(...any) error }) (Series, error) {
+	var s Series
+	err := row.Scan(
+		&s.Id,
+		&s.DeskId,
+		&s.UserId,
+		&s.BookedBy,
+		&s.RRule,
+		pq.Array(&s.ExDates),
+		&s.DateFrom,
+		&s.DateTo,
+		&s.ExpandedUntil,
+		&s.OccurrencesCount,
+	)
+
+	return s, err
+}
+
+// Создает серию броней и все ее вхождения в одной транзакции.
+// Если хотя бы одно вхождение конфликтует с другими бронями, серия не создается.
+func (r *ReservationsRepository) CreateSeries(ctx context.Context, series Series, occurrences []ReservationSlot) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkBookingPermission(ctx, tx, series.BookedBy, series.UserId); err != nil {
+		return "", err
+	}
+
+	query := `
+		INSERT INTO reservation_series (user_id, booked_by, desk_id, rrule, exdates, date_from, date_to, expanded_until)
+		VALUES ($1, $2, $3, $4, $5::date[], $6, $7, $8)
+		RETURNING id
+	`
+
+	var seriesId string
+	err = tx.QueryRowContext(ctx, query,
+		series.UserId,
+		series.BookedBy,
+		series.DeskId,
+		series.RRule,
+		pq.Array(series.ExDates),
+		series.DateFrom,
+		series.DateTo,
+		series.ExpandedUntil,
+	).Scan(&seriesId)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Constraint == "reservation_series_user_id_fkey" {
+			return "", ErrUserNotFound
+		}
+		return "", fmt.Errorf("failed to create reservation series: %w", err)
+	}
+
+	for _, occurrence := range occurrences {
+		if _, err := insertReservation(ctx, tx, series.DeskId, series.UserId, series.BookedBy, occurrence.DateFrom, occurrence.DateTo, &seriesId); err != nil {
+			return "", fmt.Errorf("%s: %w", occurrence.DateFrom.Format(time.DateOnly), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return seriesId, nil
+}
+
+// Возвращает серии пользователя и серии, которые он оформил за других.
+func (r *ReservationsRepository) GetUserSeries(ctx context.Context, userId string) ([]Series, error) {
+	query := `SELECT ` + seriesColumns + ` FROM reservation_series s WHERE s.user_id = $1 OR s.booked_by = $1 ORDER BY s.date_from`
+
+	rows, err := r.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reservation series: %w", err)
+	}
+	defer rows.Close()
+
+	series := []Series{}
+	for rows.Next() {
+		s, err := scanSeries(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reservation series: %w", err)
+		}
+		series = append(series, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+func (r *ReservationsRepository) GetSeries(ctx context.Context, seriesId, userId string) (*Series, error) {
+	query := `SELECT ` + seriesColumns + ` FROM reservation_series s WHERE s.id = $1 AND (s.user_id = $2 OR s.booked_by = $2)`
+
+	s, err := scanSeries(r.db.QueryRowContext(ctx, query, seriesId, userId))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("failed to get reservation series: %w", err)
+	}
+
+	return &s, nil
+}
+
+// Заменяет правило серии и пересоздает все ее вхождения, которые начинаются после from.
+// Прошедшие и текущие вхождения не меняются. Изменить серию может тот, кто занимает стол, или тот, кто ее оформил.
+func (r *ReservationsRepository) ReplaceSeries(ctx context.Context, series Series, userId string, occurrences []ReservationSlot, from time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	updateQuery := `
+		UPDATE reservation_series
+		SET desk_id = $1, rrule = $2, exdates = $3::date[], date_from = $4, date_to = $5, expanded_until = $6, updated_at = NOW()
+		WHERE id = $7 AND (user_id = $8 OR booked_by = $8)
+		RETURNING user_id, COALESCE(booked_by, user_id)
+	`
+
+	err = tx.QueryRowContext(ctx, updateQuery,
+		series.DeskId,
+		series.RRule,
+		pq.Array(series.ExDates),
+		series.DateFrom,
+		series.DateTo,
+		series.ExpandedUntil,
+		series.Id,
+		userId,
+	).Scan(&series.UserId, &series.BookedBy)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("failed to update reservation series: %w", err)
+	}
+
+	if err := cancelSeriesOccurrences(ctx, tx, series.Id, userId, from, cancelReasonSeriesChanged); err != nil {
+		return err
+	}
+
+	for _, occurrence := range occurrences {
+		if _, err := insertReservation(ctx, tx, series.DeskId, series.UserId, series.BookedBy, occurrence.DateFrom, occurrence.DateTo, &series.Id); err != nil {
+			return fmt.Errorf("%s: %w", occurrence.DateFrom.Format(time.DateOnly), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Удаляет серию и отменяет ее будущие вхождения. Уже начавшиеся брони остаются без привязки к серии.
+// Удалить серию может тот, кто занимает стол, или тот, кто ее оформил.
+func (r *ReservationsRepository) DeleteSeries(ctx context.Context, seriesId, userId string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	lockQuery := `SELECT true FROM reservation_series WHERE id = $1 AND (user_id = $2 OR booked_by = $2) FOR UPDATE`
+	if err := tx.QueryRowContext(ctx, lockQuery, seriesId, userId).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("failed to lock reservation series: %w", err)
+	}
+
+	// Вхождения отменяются до удаления серии: после него series_id обнуляется каскадом
+	if err := cancelSeriesOccurrences(ctx, tx, seriesId, userId, time.Now(), cancelReasonSeriesDeleted); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM reservation_series WHERE id = $1`, seriesId); err != nil {
+		return fmt.Errorf("failed to delete reservation series: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Отменяет вхождения серии, которые начинаются не раньше from.
+func cancelSeriesOccurrences(ctx context.Context, tx *sql.Tx, seriesId, userId string, from time.Time, reason string) error {
+	query := `
+		UPDATE reservations
+		SET status = 'cancelled', cancelled_at = NOW(), cancelled_by = $3, cancellation_reason = $4, updated_at = NOW()
+		WHERE series_id = $1 AND date_from >= $2 AND status IN ('active', 'pending')
+	`
+	if _, err := tx.ExecContext(ctx, query, seriesId, from, userId, reason); err != nil {
+		return fmt.Errorf("failed to cancel series occurrences: %w", err)
+	}
+
+	return nil
+}
+
+// Возвращает серии, вхождения которых созданы не до конца горизонта планирования.
+func (r *ReservationsRepository) GetSeriesToExpand(ctx context.Context, until time.Time) ([]Series, error) {
+	query := `SELECT ` + seriesColumns + ` FROM reservation_series s WHERE s.expanded_until < $1`
+
+	rows, err := r.db.QueryContext(ctx, query, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reservation series: %w", err)
+	}
+	defer rows.Close()
+
+	series := []Series{}
+	for rows.Next() {
+		s, err := scanSeries(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reservation series: %w", err)
+		}
+		series = append(series, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+// Досоздает вхождения серии до expandedUntil. Конфликтующие и недопустимые вхождения пропускаются,
+// чтобы одна занятая дата не останавливала продление всей серии.
+func (r *ReservationsRepository) ExtendSeries(ctx context.Context, series Series, occurrences []ReservationSlot, expandedUntil time.Time) (int, error) {
+	created := 0
+	for _, occurrence := range occurrences {
+		err := r.createSeriesOccurrence(ctx, series, occurrence)
+		if err == nil {
+			created++
+			continue
+		}
+
+		if isReservationRuleError(err) || isReservationInputError(err) {
+			continue
+		}
+
+		return created, err
+	}
+
+	query := `UPDATE reservation_series SET expanded_until = $1, updated_at = NOW() WHERE id = $2`
+	if _, err := r.db.ExecContext(ctx, query, expandedUntil, series.Id); err != nil {
+		return created, fmt.Errorf("failed to update series expansion: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *ReservationsRepository) createSeriesOccurrence(ctx context.Context, series Series, occurrence ReservationSlot) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := insertReservation(ctx, tx, series.DeskId, series.UserId, series.BookedBy, occurrence.DateFrom, occurrence.DateTo, &series.Id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func addSeriesExDate(ctx context.Context, tx *sql.Tx, seriesId, day string) error {
+	query := `
+		UPDATE reservation_series
+		SET exdates = array_append(exdates, $2::date), updated_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := tx.ExecContext(ctx, query, seriesId, day); err != nil {
+		return fmt.Errorf("failed to add series exdate: %w", err)
+	}
+
+	return nil
+}
+
+// Ошибки правил бронирования, из-за которых вхождение серии можно пропустить.
+func isReservationRuleError(err error) bool {
+	return errors.Is(err, ErrUserHasReservation) ||
+		errors.Is(err, ErrDeskAlreadyReserved) ||
+		errors.Is(err, ErrDeskAssigned) ||
+		errors.Is(err, ErrDeskRestricted) ||
+		errors.As(err, new(*PolicyError))
+}
+
+// Ошибки самой брони: повтор с теми же столом и временем снова закончится ошибкой.
+func isReservationInputError(err error) bool {
+	return errors.Is(err, ErrOutsideWorkingHours) ||
+		errors.Is(err, ErrDeskNotFound) ||
+		errors.Is(err, ErrInvalidPeriod) ||
+		errors.Is(err, ErrTooManyAttendees)
+}
@@ -0,0 +1,441 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var ErrSwapTargetNotFound = errors.New("reservation to swap with not found")
+var ErrSwapSameUser = errors.New("cannot swap with your own reservation")
+var ErrSwapNotOverlapping = errors.New("reservations must overlap in time")
+var ErrSwapTypeMismatch = errors.New("reservations must be for the same resource type")
+var ErrSwapUnavailable = errors.New("only upcoming desk reservations without check-in can be swapped")
+var ErrSwapExists = errors.New("swap request for these reservations already exists")
+var ErrSwapNotPending = errors.New("swap request is no longer pending")
+
+// Статусы предложения обмена.
+const (
+	SwapPending   = "pending"
+	SwapAccepted  = "accepted"
+	SwapDeclined  = "declined"
+	SwapCancelled = "cancelled"
+	SwapExpired   = "expired"
+)
+
+type (
+	// Сторона обмена: пользователь, его бронь и стол, который был у брони на момент предложения.
+	// После переноса брони в архив ReservationId, DateFrom и DateTo пустые.
+	SwapSide struct {
+		UserId        string     `json:"userId"`
+		UserName      string     `json:"userName"`
+		UserEmail     string     `json:"-"`
+		ReservationId *string    `json:"reservationId"`
+		DeskId        string     `json:"deskId"`
+		DeskName      string     `json:"deskName"`
+		DeskOffice    string     `json:"-"`
+		DateFrom      *time.Time `json:"dateFrom"`
+		DateTo        *time.Time `json:"dateTo"`
+	}
+
+	// Предложение обмена столами. Requester предлагает свой стол в обмен на стол Target.
+	SwapRequest struct {
+		Id        string     `json:"id"`
+		Status    string     `json:"status"`
+		Requester SwapSide   `json:"requester"`
+		Target    SwapSide   `json:"target"`
+		ExpiresAt time.Time  `json:"expiresAt"`
+		DecidedAt *time.Time `json:"decidedAt"`
+		CreatedAt time.Time  `json:"createdAt"`
+	}
+
+	swapReservation struct {
+		id           string
+		userId       string
+		deskId       string
+		dateFrom     time.Time
+		dateTo       time.Time
+		resourceType string
+	}
+)
+
+// Колонки предложения s с присоединенными пользователями, бронями и столами сторон.
+const swapColumns = `
+	s.id, s.status, s.expires_at, s.decided_at, s.created_at,
+	s.requester_id, ru.name, ru.email, s.requester_reservation_id, s.requester_desk_id, rd.name, COALESCE(rd.office, ''), rr.date_from, rr.date_to,
+	s.target_id, tu.name, tu.email, s.target_reservation_id, s.target_desk_id, td.name, COALESCE(td.office, ''), tr.date_from, tr.date_to
+`
+
+const swapJoins = `
+	JOIN users ru ON ru.id = s.requester_id
+	LEFT JOIN reservations rr ON rr.id = s.requester_reservation_id
+	JOIN desks rd ON rd.id = s.requester_desk_id
+	JOIN users tu ON tu.id = s.target_id
+	LEFT JOIN reservations tr ON tr.id = s.target_reservation_id
+	JOIN desks td ON td.id = s.target_desk_id
+`
+
+// Предлагает владельцу брони targetReservationId обменяться столами с бронью reservationId пользователя userId.
+// Брони должны пересекаться по времени, быть одного типа ресурса и еще не начаться.
+// Предложение действует ttl, но не дольше начала более ранней из броней.
+func (r *ReservationsRepository) CreateSwapRequest(ctx context.Context, userId, reservationId, targetReservationId string, ttl time.Duration) (SwapRequest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return SwapRequest{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	own, err := lockSwapReservation(ctx, tx, reservationId, nil)
+	if err != nil {
+		return SwapRequest{}, err
+	}
+	if own.userId != userId {
+		return SwapRequest{}, sql.ErrNoRows
+	}
+
+	target, err := lockSwapReservation(ctx, tx, targetReservationId, nil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return SwapRequest{}, ErrSwapTargetNotFound
+		}
+		return SwapRequest{}, err
+	}
+
+	switch {
+	case target.userId == userId:
+		return SwapRequest{}, ErrSwapSameUser
+	case !own.dateFrom.Before(target.dateTo) || !target.dateFrom.Before(own.dateTo):
+		return SwapRequest{}, ErrSwapNotOverlapping
+	case own.resourceType != target.resourceType:
+		return SwapRequest{}, ErrSwapTypeMismatch
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	for _, start := range []time.Time{own.dateFrom, target.dateFrom} {
+		if start.Before(expiresAt) {
+			expiresAt = start
+		}
+	}
+
+	query := `
+		INSERT INTO reservation_swaps (requester_id, requester_reservation_id, requester_desk_id,
+			target_id, target_reservation_id, target_desk_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	var id string
+	err = tx.QueryRowContext(ctx, query, userId, own.id, own.deskId, target.userId, target.id, target.deskId, expiresAt).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Constraint == "reservation_swaps_one_pending" {
+			return SwapRequest{}, ErrSwapExists
+		}
+		return SwapRequest{}, fmt.Errorf("failed to create swap request: %w", err)
+	}
+
+	swap, err := getSwap(ctx, tx, id)
+	if err != nil {
+		return SwapRequest{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return SwapRequest{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return swap, nil
+}
+
+// Предложения обмена, в которых участвует пользователь, по брони которых еще не закончились.
+func (r *ReservationsRepository) GetUserSwaps(ctx context.Context, userId string) ([]SwapRequest, error) {
+	query := `
+		SELECT ` + swapColumns + `
+		FROM reservation_swaps s
+		` + swapJoins + `
+		WHERE (s.requester_id = $1 OR s.target_id = $1)
+		  AND GREATEST(rr.date_to, tr.date_to) > NOW()
+		ORDER BY s.created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query swap requests: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSwaps(rows)
+}
+
+// Принимает или отклоняет предложение обмена. Решает тот, кому предложен обмен.
+// При согласии брони меняются столами в одной транзакции: обе брони проверяются по тем же правилам,
+// что и при переносе, а занятость столов — после обмена. Остальные ожидающие предложения по этим броням отменяются.
+func (r *ReservationsRepository) DecideSwap(ctx context.Context, swapId, userId string, accept bool) (SwapRequest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return SwapRequest{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		status, requesterDeskId, targetDeskId       string
+		requesterReservationId, targetReservationId sql.NullString
+		expiresAt                                   time.Time
+	)
+	lockQuery := `
+		SELECT status, expires_at, requester_reservation_id, requester_desk_id, target_reservation_id, target_desk_id
+		FROM reservation_swaps
+		WHERE id = $1 AND target_id = $2
+		FOR UPDATE
+	`
+	err = tx.QueryRowContext(ctx, lockQuery, swapId, userId).Scan(&status, &expiresAt, &requesterReservationId, &requesterDeskId, &targetReservationId, &targetDeskId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return SwapRequest{}, sql.ErrNoRows
+		}
+		return SwapRequest{}, fmt.Errorf("failed to lock swap request: %w", err)
+	}
+
+	// Бронь, ушедшая в архив, обнуляет ссылку в предложении: решать по нему уже нечего.
+	if status != SwapPending || !expiresAt.After(time.Now()) || !requesterReservationId.Valid || !targetReservationId.Valid {
+		return SwapRequest{}, ErrSwapNotPending
+	}
+
+	newStatus := SwapDeclined
+	if accept {
+		newStatus = SwapAccepted
+
+		requester, err := lockSwapReservation(ctx, tx, requesterReservationId.String, &requesterDeskId)
+		if err != nil {
+			return SwapRequest{}, swapUnavailable(err)
+		}
+		target, err := lockSwapReservation(ctx, tx, targetReservationId.String, &targetDeskId)
+		if err != nil {
+			return SwapRequest{}, swapUnavailable(err)
+		}
+
+		if err := exchangeDesks(ctx, tx, requester, target); err != nil {
+			return SwapRequest{}, err
+		}
+
+		staleQuery := `
+			UPDATE reservation_swaps
+			SET status = 'cancelled', updated_at = NOW()
+			WHERE status = 'pending'
+			  AND id <> $1
+			  AND (requester_reservation_id IN ($2, $3) OR target_reservation_id IN ($2, $3))
+		`
+		if _, err := tx.ExecContext(ctx, staleQuery, swapId, requester.id, target.id); err != nil {
+			return SwapRequest{}, fmt.Errorf("failed to cancel stale swap requests: %w", err)
+		}
+	}
+
+	decideQuery := `
+		UPDATE reservation_swaps
+		SET status = $2, decided_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, decideQuery, swapId, newStatus); err != nil {
+		return SwapRequest{}, fmt.Errorf("failed to decide swap request: %w", err)
+	}
+
+	swap, err := getSwap(ctx, tx, swapId)
+	if err != nil {
+		return SwapRequest{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return SwapRequest{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return swap, nil
+}
+
+// Отзывает ожидающее предложение обмена. Отозвать может только тот, кто его сделал.
+func (r *ReservationsRepository) CancelSwapRequest(ctx context.Context, swapId, userId string) (SwapRequest, error) {
+	query := `
+		WITH cancelled AS (
+			UPDATE reservation_swaps
+			SET status = 'cancelled', updated_at = NOW()
+			WHERE id = $1 AND requester_id = $2 AND status = 'pending'
+			RETURNING *
+		)
+		SELECT ` + swapColumns + `
+		FROM cancelled s
+		` + swapJoins
+
+	rows, err := r.db.QueryContext(ctx, query, swapId, userId)
+	if err != nil {
+		return SwapRequest{}, fmt.Errorf("failed to cancel swap request: %w", err)
+	}
+	defer rows.Close()
+
+	swaps, err := scanSwaps(rows)
+	if err != nil {
+		return SwapRequest{}, err
+	}
+	if len(swaps) == 0 {
+		return SwapRequest{}, sql.ErrNoRows
+	}
+
+	return swaps[0], nil
+}
+
+// Снимает предложения, срок которых истек или брони которых уже нельзя обменять:
+// отменены, перенесены на другой стол или начались.
+func (r *ReservationsRepository) ExpireSwaps(ctx context.Context) ([]SwapRequest, error) {
+	query := `
+		WITH expired AS (
+			UPDATE reservation_swaps s
+			SET status = 'expired', updated_at = NOW()
+			WHERE s.status = 'pending'
+			  AND (
+				s.expires_at <= NOW()
+				OR NOT EXISTS (
+					SELECT 1 FROM reservations r
+					WHERE r.id = s.requester_reservation_id AND r.desk_id = s.requester_desk_id AND r.status IN ('active', 'pending')
+				)
+				OR NOT EXISTS (
+					SELECT 1 FROM reservations r
+					WHERE r.id = s.target_reservation_id AND r.desk_id = s.target_desk_id AND r.status IN ('active', 'pending')
+				)
+			  )
+			RETURNING s.*
+		)
+		SELECT ` + swapColumns + `
+		FROM expired s
+		` + swapJoins
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire swap requests: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSwaps(rows)
+}
+
+// Блокирует бронь, которую можно обменять: еще не начавшуюся бронь сотрудника без отметки о приходе.
+// С deskId бронь должна оставаться на этом столе.
+func lockSwapReservation(ctx context.Context, tx *sql.Tx, reservationId string, deskId *string) (swapReservation, error) {
+	query := `
+		SELECT id, user_id, desk_id, date_from, date_to, resource_type,
+			status IN ('active', 'pending') AND guest_name IS NULL AND checked_in_at IS NULL AND date_from > NOW()
+		FROM reservations
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	var (
+		res       swapReservation
+		swappable bool
+	)
+	err := tx.QueryRowContext(ctx, query, reservationId).Scan(&res.id, &res.userId, &res.deskId, &res.dateFrom, &res.dateTo, &res.resourceType, &swappable)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return swapReservation{}, sql.ErrNoRows
+		}
+		return swapReservation{}, fmt.Errorf("failed to lock reservation: %w", err)
+	}
+
+	if !swappable || (deskId != nil && res.deskId != *deskId) {
+		return swapReservation{}, ErrSwapUnavailable
+	}
+
+	return res, nil
+}
+
+func swapUnavailable(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSwapUnavailable
+	}
+
+	return err
+}
+
+// Меняет столы двух броней. Пока первая бронь переходит на стол второй, стол еще занят,
+// поэтому проверка занятости столов откладывается и выполняется после обеих замен.
+func exchangeDesks(ctx context.Context, tx *sql.Tx, a, b swapReservation) error {
+	if err := checkReservationRules(ctx, tx, b.deskId, a.userId, a.dateFrom, a.dateTo, &a.id); err != nil {
+		return err
+	}
+	if err := checkReservationRules(ctx, tx, a.deskId, b.userId, b.dateFrom, b.dateTo, &b.id); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `SET CONSTRAINTS one_reservation_per_desk_per_period DEFERRED`); err != nil {
+		return fmt.Errorf("failed to defer desk constraint: %w", err)
+	}
+
+	query := `
+		UPDATE reservations
+		SET desk_id = $1, status = ` + deskInitialStatus + `,
+			decided_by = NULL, decided_at = NULL, decision_comment = NULL, updated_at = NOW()
+		WHERE id = $2
+	`
+	for _, move := range []struct{ deskId, reservationId string }{{b.deskId, a.id}, {a.deskId, b.id}} {
+		if _, err := tx.ExecContext(ctx, query, move.deskId, move.reservationId); err != nil {
+			return mapConstraintError(err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `SET CONSTRAINTS one_reservation_per_desk_per_period IMMEDIATE`); err != nil {
+		return mapConstraintError(err)
+	}
+
+	return nil
+}
+
+func getSwap(ctx context.Context, tx *sql.Tx, id string) (SwapRequest, error) {
+	query := `
+		SELECT ` + swapColumns + `
+		FROM reservation_swaps s
+		` + swapJoins + `
+		WHERE s.id = $1
+	`
+
+	rows, err := tx.QueryContext(ctx, query, id)
+	if err != nil {
+		return SwapRequest{}, fmt.Errorf("failed to query swap request: %w", err)
+	}
+	defer rows.Close()
+
+	swaps, err := scanSwaps(rows)
+	if err != nil {
+		return SwapRequest{}, err
+	}
+	if len(swaps) == 0 {
+		return SwapRequest{}, sql.ErrNoRows
+	}
+
+	return swaps[0], nil
+}
+
+func scanSwaps(rows *sql.Rows) ([]SwapRequest, error) {
+	swaps := []SwapRequest{}
+	for rows.Next() {
+		var (
+			s      SwapRequest
+			req    = &s.Requester
+			target = &s.Target
+		)
+		err := rows.Scan(
+			&s.Id, &s.Status, &s.ExpiresAt, &s.DecidedAt, &s.CreatedAt,
+			&req.UserId, &req.UserName, &req.UserEmail, &req.ReservationId, &req.DeskId, &req.DeskName, &req.DeskOffice, &req.DateFrom, &req.DateTo,
+			&target.UserId, &target.UserName, &target.UserEmail, &target.ReservationId, &target.DeskId, &target.DeskName, &target.DeskOffice, &target.DateFrom, &target.DateTo,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan swap request: %w", err)
+		}
+		swaps = append(swaps, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return swaps, nil
+}
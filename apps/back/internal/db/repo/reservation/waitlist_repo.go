@@ -0,0 +1,428 @@
+package reservation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	WaitlistModeOffer    = "offer"
+	WaitlistModeAutoBook = "auto_book"
+
+	WaitlistWaiting = "waiting"
+	WaitlistOffered = "offered"
+	WaitlistBooked  = "booked"
+	WaitlistExpired = "expired"
+)
+
+var ErrAlreadyInWaitlist = errors.New("user is already in the waitlist for this slot")
+var ErrZoneNotFound = errors.New("zone not found")
+var ErrOfferNotAvailable = errors.New("offer is not available or has expired")
+
+type (
+	WaitlistEntry struct {
+		Id             string     `json:"id"`
+		UserId         string     `json:"-"`
+		DeskId         *string    `json:"deskId"`
+		ZoneId         *string    `json:"zoneId"`
+		DateFrom       time.Time  `json:"dateFrom"`
+		DateTo         time.Time  `json:"dateTo"`
+		Mode           string     `json:"mode"`
+		Status         string     `json:"status"`
+		ReservationId  *string    `json:"reservationId"`
+		OfferExpiresAt *time.Time `json:"offerExpiresAt"`
+		CreatedAt      time.Time  `json:"createdAt"`
+	}
+
+	// Стол, забронированный из очереди ожидания. Используется для уведомления пользователя.
+	WaitlistAllocation struct {
+		Entry      WaitlistEntry
+		DeskName   string
+		DeskOffice string
+		UserEmail  string
+	}
+)
+
+const waitlistColumns = `
+	id,
+	user_id,
+	desk_id,
+	zone_id,
+	date_from,
+	date_to,
+	mode,
+	status,
+	reservation_id,
+	offer_expires_at,
+	created_at
+`
+
+func scanWaitlistEntry(row interface{ Scan(...any) error }) (WaitlistEntry, error) {
+	var e WaitlistEntry
+	err := row.Scan(
+		&e.Id,
+		&e.UserId,
+		&e.DeskId,
+		&e.ZoneId,
+		&e.DateFrom,
+		&e.DateTo,
+		&e.Mode,
+		&e.Status,
+		&e.ReservationId,
+		&e.OfferExpiresAt,
+		&e.CreatedAt,
+	)
+	return e, err
+}
+
+func (r *ReservationsRepository) CreateWaitlistEntry(ctx context.Context, entry WaitlistEntry) (string, error) {
+	query := `
+		INSERT INTO waitlist_entries (user_id, desk_id, zone_id, date_from, date_to, mode)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	var id string
+	err := r.db.QueryRowContext(ctx, query, entry.UserId, entry.DeskId, entry.ZoneId, entry.DateFrom, entry.DateTo, entry.Mode).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			switch pqErr.Constraint {
+			case "waitlist_one_entry_per_target":
+				return "", ErrAlreadyInWaitlist
+			case "waitlist_entries_desk_id_fkey":
+				return "", ErrDeskNotFound
+			case "waitlist_entries_zone_id_fkey":
+				return "", ErrZoneNotFound
+			}
+		}
+		return "", fmt.Errorf("failed to create waitlist entry: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *ReservationsRepository) GetUserWaitlist(ctx context.Context, userId string) ([]WaitlistEntry, error) {
+	query := `
+		SELECT ` + waitlistColumns + `
+		FROM waitlist_entries
+		WHERE user_id = $1 AND date_to > NOW()
+		ORDER BY date_from, created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query waitlist: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []WaitlistEntry{}
+	for rows.Next() {
+		e, err := scanWaitlistEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan waitlist entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Удаляет запись из очереди. Если пользователю уже предложена бронь, отказ от записи освобождает стол.
+func (r *ReservationsRepository) DeleteWaitlistEntry(ctx context.Context, entryId, userId string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		DELETE FROM waitlist_entries
+		WHERE id = $1 AND user_id = $2
+		RETURNING status, reservation_id
+	`
+
+	var (
+		status        string
+		reservationId *string
+	)
+	if err := tx.QueryRowContext(ctx, query, entryId, userId).Scan(&status, &reservationId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("failed to delete waitlist entry: %w", err)
+	}
+
+	if status == WaitlistOffered && reservationId != nil {
+		query := `
+			UPDATE reservations
+			SET status = 'cancelled', cancelled_at = NOW(), cancelled_by = $2, cancellation_reason = $3, updated_at = NOW()
+			WHERE id = $1 AND status IN ('active', 'pending')
+		`
+		if _, err := tx.ExecContext(ctx, query, *reservationId, userId, cancelReasonOfferDeclined); err != nil {
+			return fmt.Errorf("failed to release offered reservation: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Подтверждает предложенную бронь до истечения срока.
+func (r *ReservationsRepository) ConfirmWaitlistOffer(ctx context.Context, entryId, userId string) error {
+	query := `
+		UPDATE waitlist_entries
+		SET status = 'booked', offer_expires_at = NULL, updated_at = NOW()
+		WHERE id = $1
+		  AND user_id = $2
+		  AND status = 'offered'
+		  AND reservation_id IS NOT NULL
+		  AND offer_expires_at > NOW()
+	`
+
+	result, err := r.db.ExecContext(ctx, query, entryId, userId)
+	if err != nil {
+		return fmt.Errorf("failed to confirm waitlist offer: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrOfferNotAvailable
+	}
+
+	return nil
+}
+
+// Снимает неподтвержденные в срок предложения и освобождает их брони,
+// а также закрывает записи, время которых уже наступило.
+func (r *ReservationsRepository) ExpireWaitlist(ctx context.Context) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	offersQuery := `
+		WITH expired AS (
+			UPDATE waitlist_entries
+			SET status = 'expired', updated_at = NOW()
+			WHERE status = 'offered' AND offer_expires_at <= NOW()
+			RETURNING reservation_id
+		)
+		UPDATE reservations
+		SET status = 'cancelled', cancelled_at = NOW(), cancellation_reason = $1, updated_at = NOW()
+		WHERE id IN (SELECT reservation_id FROM expired WHERE reservation_id IS NOT NULL)
+		  AND status IN ('active', 'pending')
+	`
+
+	result, err := tx.ExecContext(ctx, offersQuery, cancelReasonOfferExpired)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire waitlist offers: %w", err)
+	}
+
+	released, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check expire result: %w", err)
+	}
+
+	waitingQuery := `
+		UPDATE waitlist_entries
+		SET status = 'expired', updated_at = NOW()
+		WHERE status = 'waiting' AND date_from <= NOW()
+	`
+
+	if _, err := tx.ExecContext(ctx, waitingQuery); err != nil {
+		return 0, fmt.Errorf("failed to expire waitlist entries: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return released, nil
+}
+
+// Пытается забронировать освободившиеся столы для записей очереди в порядке их создания.
+// В режиме offer бронь держится за пользователем до offerExpiresAt, но не дольше начала брони.
+func (r *ReservationsRepository) ProcessWaitlist(ctx context.Context, offerTTL time.Duration) ([]WaitlistAllocation, error) {
+	query := `
+		SELECT ` + waitlistColumns + `
+		FROM waitlist_entries
+		WHERE status = 'waiting' AND date_from > NOW()
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query waitlist: %w", err)
+	}
+
+	var entries []WaitlistEntry
+	for rows.Next() {
+		e, err := scanWaitlistEntry(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan waitlist entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Ошибка одной записи не останавливает очередь: запись, которую нельзя забронировать ни при каком
+	// освобождении, истекает, остальные ошибки возвращаются вместе после обработки всей очереди.
+	var (
+		allocations []WaitlistAllocation
+		errs        []error
+	)
+	for _, entry := range entries {
+		allocation, err := r.allocateWaitlistEntry(ctx, entry, offerTTL)
+		if err != nil {
+			if isReservationInputError(err) {
+				err = r.expireWaitlistEntry(ctx, entry.Id)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("waitlist entry %s: %w", entry.Id, err))
+			}
+			continue
+		}
+		if allocation != nil {
+			allocations = append(allocations, *allocation)
+		}
+	}
+
+	return allocations, errors.Join(errs...)
+}
+
+func (r *ReservationsRepository) expireWaitlistEntry(ctx context.Context, entryId string) error {
+	query := `UPDATE waitlist_entries SET status = 'expired', updated_at = NOW() WHERE id = $1 AND status = 'waiting'`
+	if _, err := r.db.ExecContext(ctx, query, entryId); err != nil {
+		return fmt.Errorf("failed to expire waitlist entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ReservationsRepository) allocateWaitlistEntry(ctx context.Context, entry WaitlistEntry, offerTTL time.Duration) (*WaitlistAllocation, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Запись могли удалить или обработать параллельно.
+	var status string
+	lockQuery := `SELECT status FROM waitlist_entries WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRowContext(ctx, lockQuery, entry.Id).Scan(&status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to lock waitlist entry: %w", err)
+	}
+	if status != WaitlistWaiting {
+		return nil, nil
+	}
+
+	candidates, err := waitlistCandidateDesks(ctx, tx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, deskId := range candidates {
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT waitlist_desk`); err != nil {
+			return nil, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		reservationId, err := insertReservation(ctx, tx, deskId, entry.UserId, entry.UserId, entry.DateFrom, entry.DateTo, nil)
+		if err != nil {
+			// Стол могли удалить после выбора кандидатов.
+			if !isReservationRuleError(err) && !errors.Is(err, ErrDeskNotFound) {
+				return nil, err
+			}
+			if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT waitlist_desk`); err != nil {
+				return nil, fmt.Errorf("failed to rollback to savepoint: %w", err)
+			}
+			continue
+		}
+
+		entry.ReservationId = &reservationId
+		entry.Status = WaitlistBooked
+		if entry.Mode == WaitlistModeOffer {
+			expiresAt := time.Now().Add(offerTTL)
+			if expiresAt.After(entry.DateFrom) {
+				expiresAt = entry.DateFrom
+			}
+			entry.Status = WaitlistOffered
+			entry.OfferExpiresAt = &expiresAt
+		}
+
+		updateQuery := `
+			UPDATE waitlist_entries
+			SET status = $1, reservation_id = $2, offer_expires_at = $3, updated_at = NOW()
+			WHERE id = $4
+		`
+		if _, err := tx.ExecContext(ctx, updateQuery, entry.Status, reservationId, entry.OfferExpiresAt, entry.Id); err != nil {
+			return nil, fmt.Errorf("failed to update waitlist entry: %w", err)
+		}
+
+		allocation := WaitlistAllocation{Entry: entry}
+		detailsQuery := `
+			SELECT d.name, COALESCE(d.office, ''), u.email
+			FROM desks d, users u
+			WHERE d.id = $1 AND u.id = $2
+		`
+		if err := tx.QueryRowContext(ctx, detailsQuery, deskId, entry.UserId).Scan(&allocation.DeskName, &allocation.DeskOffice, &allocation.UserEmail); err != nil {
+			return nil, fmt.Errorf("failed to load allocation details: %w", err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+
+		return &allocation, nil
+	}
+
+	return nil, nil
+}
+
+func waitlistCandidateDesks(ctx context.Context, tx *sql.Tx, entry WaitlistEntry) ([]string, error) {
+	if entry.DeskId != nil {
+		return []string{*entry.DeskId}, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM desks WHERE zone_id = $1 AND type = 'desk' ORDER BY name`, entry.ZoneId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query zone desks: %w", err)
+	}
+	defer rows.Close()
+
+	var desks []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan desk: %w", err)
+		}
+		desks = append(desks, id)
+	}
+
+	return desks, rows.Err()
+}
@@ -0,0 +1,316 @@
+package slots
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	desksRepo "place-picker/internal/db/repo/desks"
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"place-picker/internal/offices"
+
+	"github.com/lib/pq"
+)
+
+var ErrTemplateNameTaken = errors.New("slot template with this name already exists in the office")
+var ErrInvalidTemplate = errors.New("slot template must end after it starts")
+
+type (
+	SlotsRepository struct {
+		db           *sql.DB
+		reservations *reservationsRepo.ReservationsRepository
+	}
+
+	// Именованный интервал брони офиса. StartTime и EndTime — местное время офиса в формате HH:MM.
+	Template struct {
+		Id        string    `json:"id"`
+		Office    string    `json:"office"`
+		Name      string    `json:"name"`
+		StartTime string    `json:"startTime"`
+		EndTime   string    `json:"endTime"`
+		CreatedAt time.Time `json:"createdAt"`
+		UpdatedAt time.Time `json:"updatedAt"`
+	}
+
+	SlotAvailability struct {
+		TemplateId string `json:"templateId"`
+		Available  bool   `json:"available"`
+	}
+
+	// Доступность ресурса по шаблонам дня в порядке шаблонов.
+	ResourceAvailability struct {
+		DeskId   string             `json:"deskId"`
+		DeskName string             `json:"deskName"`
+		ZoneName *string            `json:"zoneName"`
+		Slots    []SlotAvailability `json:"slots"`
+	}
+
+	// Сетка доступности ресурсов офиса по шаблонам за день. В закрытый день и в день лотереи
+	// до ее розыгрыша все интервалы недоступны. Zones — занятость зон офиса за день.
+	Availability struct {
+		Office    string                           `json:"office"`
+		Day       string                           `json:"day"`
+		Closed    bool                             `json:"closed"`
+		Lottery   bool                             `json:"lottery"`
+		Templates []Template                       `json:"templates"`
+		Zones     []reservationsRepo.ZoneOccupancy `json:"zones"`
+		Desks     []ResourceAvailability           `json:"desks"`
+	}
+)
+
+func NewSlotsRepository(db *sql.DB) *SlotsRepository {
+	return &SlotsRepository{db: db, reservations: reservationsRepo.NewReservationsRepository(db)}
+}
+
+const templateColumns = `id, office, name, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI'), created_at, updated_at`
+
+func scanTemplate(row interface{ Scan(...any) error }) (Template, error) {
+	var t Template
+	err := row.Scan(&t.Id, &t.Office, &t.Name, &t.StartTime, &t.EndTime, &t.CreatedAt, &t.UpdatedAt)
+	return t, err
+}
+
+// Шаблоны офиса в порядке начала.
+func (r *SlotsRepository) GetTemplates(ctx context.Context, office string) ([]Template, error) {
+	query := `SELECT ` + templateColumns + ` FROM slot_templates WHERE office = $1 ORDER BY start_time, end_time`
+
+	rows, err := r.db.QueryContext(ctx, query, office)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query slot templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []Template{}
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan slot template: %w", err)
+		}
+		templates = append(templates, t)
+	}
+
+	return templates, rows.Err()
+}
+
+func (r *SlotsRepository) GetTemplate(ctx context.Context, id string) (Template, error) {
+	query := `SELECT ` + templateColumns + ` FROM slot_templates WHERE id = $1`
+
+	t, err := scanTemplate(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Template{}, sql.ErrNoRows
+		}
+		return Template{}, fmt.Errorf("failed to get slot template: %w", err)
+	}
+
+	return t, nil
+}
+
+func (r *SlotsRepository) CreateTemplate(ctx context.Context, office, name, startTime, endTime string) (string, error) {
+	query := `INSERT INTO slot_templates (office, name, start_time, end_time) VALUES ($1, $2, $3, $4) RETURNING id`
+
+	var id string
+	if err := r.db.QueryRowContext(ctx, query, office, name, startTime, endTime).Scan(&id); err != nil {
+		return "", mapTemplateError(err)
+	}
+
+	return id, nil
+}
+
+// Изменяет шаблон. Уже сделанные по нему брони не меняются.
+func (r *SlotsRepository) UpdateTemplate(ctx context.Context, id, name, startTime, endTime string) error {
+	query := `
+		UPDATE slot_templates
+		SET name = $1, start_time = $2, end_time = $3, updated_at = NOW()
+		WHERE id = $4
+	`
+
+	result, err := r.db.ExecContext(ctx, query, name, startTime, endTime, id)
+	if err != nil {
+		return mapTemplateError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *SlotsRepository) DeleteTemplate(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM slot_templates WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete slot template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func mapTemplateError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			return ErrTemplateNameTaken
+		case "23514":
+			return ErrInvalidTemplate
+		}
+	}
+	return fmt.Errorf("failed to save slot template: %w", err)
+}
+
+// Доступность ресурсов офиса одного типа по шаблонам офиса за местный день для пользователя userId.
+// Интервал доступен, если не пересекается с бронями ресурса и временем на уборку после них, а сам оставляет
+// время на уборку. Ресурс недоступен весь день, если он закреплен за другим пользователем и день не освобожден,
+// а стол — еще и если его зона заполнена до лимита и стол в этот день не занят.
+// Ограничения групп и правила роли здесь не проверяются: они проверяются при брони.
+func (r *SlotsRepository) GetAvailability(ctx context.Context, office offices.Office, defaultOfficeKey, resourceType, userId, day string) (Availability, error) {
+	availability := Availability{Office: office.Key, Day: day, Zones: []reservationsRepo.ZoneOccupancy{}, Desks: []ResourceAvailability{}}
+
+	templates, err := r.GetTemplates(ctx, office.Key)
+	if err != nil {
+		return availability, err
+	}
+	availability.Templates = templates
+
+	starts := make([]string, len(templates))
+	ends := make([]string, len(templates))
+	for i, t := range templates {
+		from, to, err := t.Bounds(office.Location, day)
+		if err != nil {
+			return availability, err
+		}
+		starts[i], ends[i] = from.Format(time.RFC3339), to.Format(time.RFC3339)
+	}
+
+	dayFrom, dayTo, err := office.DayBounds(day)
+	if err != nil {
+		return availability, err
+	}
+
+	dayQuery := `
+		SELECT
+			EXISTS (SELECT 1 FROM office_closures WHERE office = $1 AND $2::date BETWEEN date_from AND date_to),
+			EXISTS (SELECT 1 FROM lottery_days WHERE office = $1 AND day = $2::date AND drawn_at IS NULL AND failed_at IS NULL)
+	`
+	if err := r.db.QueryRowContext(ctx, dayQuery, office.Key, day).Scan(&availability.Closed, &availability.Lottery); err != nil {
+		return availability, fmt.Errorf("failed to check office day: %w", err)
+	}
+
+	availability.Zones, err = r.reservations.GetZonesOccupancy(ctx, office, day)
+	if err != nil {
+		return availability, err
+	}
+
+	fullZones := map[string]bool{}
+	for _, zone := range availability.Zones {
+		fullZones[zone.ZoneId] = zone.Limit != nil && zone.Occupied >= *zone.Limit
+	}
+
+	query := `
+		SELECT d.id, d.name, d.zone_id, z.name, t.idx,
+			EXISTS (
+				SELECT 1
+				FROM desk_assignments a
+				WHERE a.desk_id = d.id
+				  AND a.user_id <> $6
+				  AND daterange(a.date_from, a.date_to, '[]') @> $7::date
+				  AND NOT EXISTS (SELECT 1 FROM desk_releases dr WHERE dr.assignment_id = a.id AND dr.day = $7::date)
+			),
+			EXISTS (
+				SELECT 1
+				FROM reservations r
+				WHERE r.desk_id = d.id
+				  AND r.status IN ('active', 'pending')
+				  AND r.date_from < $9
+				  AND r.date_to > $8
+			),
+			NOT EXISTS (
+				SELECT 1
+				FROM reservations r
+				WHERE r.desk_id = d.id
+				  AND r.status IN ('active', 'pending')
+				  AND tstzrange(r.date_from, r.occupied_until, '[)')
+					&& tstzrange(t.date_from, t.date_to + make_interval(mins => COALESCE(d.cleaning_buffer_minutes, z.cleaning_buffer_minutes, 0)), '[)')
+			)
+		FROM desks d
+		LEFT JOIN zones z ON z.id = d.zone_id
+		CROSS JOIN unnest($4::timestamptz[], $5::timestamptz[]) WITH ORDINALITY AS t(date_from, date_to, idx)
+		WHERE d.type = $1
+		  AND COALESCE(d.office, $2) = $3
+		ORDER BY z.name NULLS LAST, d.name, d.id, t.idx
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, resourceType, defaultOfficeKey, office.Key, pq.Array(starts), pq.Array(ends),
+		userId, day, dayFrom, dayTo)
+	if err != nil {
+		return availability, fmt.Errorf("failed to query slot availability: %w", err)
+	}
+	defer rows.Close()
+
+	dayClosed := availability.Closed || availability.Lottery
+	for rows.Next() {
+		var (
+			desk        ResourceAvailability
+			zoneId      *string
+			idx         int
+			assigned    bool
+			occupiedDay bool
+			available   bool
+		)
+		if err := rows.Scan(&desk.DeskId, &desk.DeskName, &zoneId, &desk.ZoneName, &idx, &assigned, &occupiedDay, &available); err != nil {
+			return availability, fmt.Errorf("failed to scan slot availability: %w", err)
+		}
+
+		last := len(availability.Desks) - 1
+		if last < 0 || availability.Desks[last].DeskId != desk.DeskId {
+			desk.Slots = make([]SlotAvailability, 0, len(templates))
+			availability.Desks = append(availability.Desks, desk)
+			last++
+		}
+
+		// Лимит зоны действует только на столы. Стол, уже занятый в этот день, лимит не расходует.
+		zoneFull := resourceType == desksRepo.ResourceTypeDesk && zoneId != nil && fullZones[*zoneId] && !occupiedDay
+
+		availability.Desks[last].Slots = append(availability.Desks[last].Slots, SlotAvailability{
+			TemplateId: templates[idx-1].Id,
+			Available:  available && !dayClosed && !assigned && !zoneFull,
+		})
+	}
+
+	return availability, rows.Err()
+}
+
+// Начало и конец интервала шаблона в местный день day в формате YYYY-MM-DD.
+func (t Template) Bounds(location *time.Location, day string) (time.Time, time.Time, error) {
+	date, err := time.ParseInLocation(time.DateOnly, day, location)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	start, err := time.Parse("15:04", t.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid slot template start: %w", err)
+	}
+	end, err := time.Parse("15:04", t.EndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid slot template end: %w", err)
+	}
+
+	return time.Date(date.Year(), date.Month(), date.Day(), start.Hour(), start.Minute(), 0, 0, location),
+		time.Date(date.Year(), date.Month(), date.Day(), end.Hour(), end.Minute(), 0, 0, location), nil
+}
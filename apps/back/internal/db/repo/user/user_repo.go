@@ -14,6 +14,12 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	RoleUser      = "user"
+	RoleAdmin     = "admin"
+	RoleReception = "reception"
+)
+
 var ErrUserAlreadyExists = errors.New("user with this email already exists")
 var ErrInvalidCredentials = errors.New("invalid email or password")
 
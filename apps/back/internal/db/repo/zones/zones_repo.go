@@ -0,0 +1,201 @@
+package zones
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var ErrZoneAlreadyExists = errors.New("zone with this name already exists")
+var ErrGroupNotFound = errors.New("group not found")
+
+type (
+	ZonesRepository struct {
+		db *sql.DB
+	}
+
+	GroupRef struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	Zone struct {
+		Id                    string     `json:"id"`
+		Name                  string     `json:"name"`
+		OpenDaysBefore        *int       `json:"openDaysBefore"`
+		CleaningBufferMinutes *int       `json:"cleaningBufferMinutes"`
+		CapacityPercent       *int       `json:"capacityPercent"`
+		DesksCount            int        `json:"desksCount"`
+		Groups                []GroupRef `json:"groups"`
+		CreatedAt             time.Time  `json:"createdAt"`
+		UpdatedAt             time.Time  `json:"updatedAt"`
+	}
+)
+
+func NewZonesRepository(db *sql.DB) *ZonesRepository {
+	return &ZonesRepository{db: db}
+}
+
+func (r *ZonesRepository) CreateZone(ctx context.Context, name string, openDaysBefore, cleaningBufferMinutes, capacityPercent *int) (string, error) {
+	query := `INSERT INTO zones (name, open_days_before, cleaning_buffer_minutes, capacity_percent) VALUES ($1, $2, $3, $4) RETURNING id`
+
+	var id string
+	if err := r.db.QueryRowContext(ctx, query, name, openDaysBefore, cleaningBufferMinutes, capacityPercent).Scan(&id); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return "", ErrZoneAlreadyExists
+		}
+		return "", fmt.Errorf("failed to create zone: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *ZonesRepository) GetAllZones(ctx context.Context) ([]Zone, error) {
+	query := `
+		SELECT
+			z.id,
+			z.name,
+			z.open_days_before,
+			z.cleaning_buffer_minutes,
+			z.capacity_percent,
+			(SELECT COUNT(*) FROM desks d WHERE d.zone_id = z.id),
+			COALESCE(
+				(
+					SELECT json_agg(json_build_object('id', g.id, 'name', g.name) ORDER BY g.name)
+					FROM zone_group_restrictions zg
+					JOIN user_groups g ON g.id = zg.group_id
+					WHERE zg.zone_id = z.id
+				),
+				'[]'::json
+			),
+			z.created_at,
+			z.updated_at
+		FROM zones z
+		ORDER BY z.name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query zones: %w", err)
+	}
+	defer rows.Close()
+
+	zones := []Zone{}
+	for rows.Next() {
+		var (
+			z          Zone
+			groupsJSON []byte
+		)
+
+		if err := rows.Scan(&z.Id, &z.Name, &z.OpenDaysBefore, &z.CleaningBufferMinutes, &z.CapacityPercent, &z.DesksCount, &groupsJSON, &z.CreatedAt, &z.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan zone: %w", err)
+		}
+
+		if err := json.Unmarshal(groupsJSON, &z.Groups); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal zone groups: %w", err)
+		}
+
+		zones = append(zones, z)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return zones, nil
+}
+
+// Изменение времени на уборку действует на брони, созданные или перенесенные после него.
+// Новый лимит занятости не отменяет уже сделанные брони.
+func (r *ZonesRepository) UpdateZone(ctx context.Context, id, name string, openDaysBefore, cleaningBufferMinutes, capacityPercent *int) error {
+	query := `
+		UPDATE zones
+		SET name = $1, open_days_before = $2, cleaning_buffer_minutes = $3, capacity_percent = $4, updated_at = NOW()
+		WHERE id = $5
+	`
+
+	result, err := r.db.ExecContext(ctx, query, name, openDaysBefore, cleaningBufferMinutes, capacityPercent, id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrZoneAlreadyExists
+		}
+		return fmt.Errorf("failed to update zone: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *ZonesRepository) DeleteZone(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM zones WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete zone: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Заменяет список групп, которым разрешено бронировать столы зоны.
+// Пустой список снимает ограничение.
+func (r *ZonesRepository) SetZoneGroups(ctx context.Context, zoneId string, groupIds []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM zones WHERE id = $1)`, zoneId).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check zone: %w", err)
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM zone_group_restrictions WHERE zone_id = $1`, zoneId); err != nil {
+		return fmt.Errorf("failed to clear zone groups: %w", err)
+	}
+
+	query := `
+		INSERT INTO zone_group_restrictions (zone_id, group_id)
+		SELECT $1, g FROM unnest($2::uuid[]) AS g
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, query, zoneId, pq.Array(groupIds)); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrGroupNotFound
+		}
+		return fmt.Errorf("failed to set zone groups: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
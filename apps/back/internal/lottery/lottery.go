@@ -0,0 +1,96 @@
+package lottery
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"place-picker/internal/notifications"
+	"place-picker/internal/waitlist"
+)
+
+// Периодически разыгрывает лотереи, прием заявок на которые закончился, и уведомляет участников.
+// fairnessWindow — за какой срок до дня лотереи учитываются прошлые выигрыши и проигрыши.
+func StartLotteryDraws(ctx context.Context, logger *slog.Logger, repo *reservationsRepo.ReservationsRepository, interval, fairnessWindow time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	drawLotteries(ctx, logger, repo, fairnessWindow)
+
+	logger.Info("StartLotteryDraws | Started lottery draws", "interval", interval, "fairnessWindow", fairnessWindow)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("StartLotteryDraws | Stopping lottery draws")
+			return
+		case <-ticker.C:
+			drawLotteries(ctx, logger, repo, fairnessWindow)
+		}
+	}
+}
+
+func drawLotteries(ctx context.Context, logger *slog.Logger, repo *reservationsRepo.ReservationsRepository, fairnessWindow time.Duration) {
+	outcomes, err := repo.DrawLotteries(ctx, fairnessWindow)
+	if err != nil {
+		logger.Error("drawLotteries | Failed to draw lotteries", "error", err.Error())
+	}
+
+	if len(outcomes) == 0 {
+		return
+	}
+
+	joinedWaitlist := false
+	for _, outcome := range outcomes {
+		logger.Info("drawLotteries | Lottery request drawn",
+			"lotteryId", outcome.Lottery.Id,
+			"requestId", outcome.Request.Id,
+			"status", outcome.Request.Status,
+			"position", *outcome.Request.DrawPosition,
+		)
+
+		if outcome.WaitlistEntries > 0 {
+			joinedWaitlist = true
+		}
+		notify(outcome)
+	}
+
+	// Брони проигравших из очереди ожидания подбираются сразу, если столы уже освободились.
+	if joinedWaitlist {
+		waitlist.Wake()
+	}
+}
+
+func notify(outcome reservationsRepo.LotteryOutcome) {
+	day := outcome.Lottery.Day
+	if parsed, err := time.Parse(time.DateOnly, day); err == nil {
+		day = parsed.Format("02.01.2006")
+	}
+
+	if outcome.Request.Status == reservationsRepo.LotteryRequestWon {
+		notifications.Send(outcome.UserEmail, "Вы выиграли стол в лотерее", fmt.Sprintf(`
+			<h2>Стол %s ваш</h2>
+			<p>По итогам лотереи на %s для вас забронирован стол %s, %s.</p>
+			<p>Это ваш вариант №%d.</p>
+		`, notifications.Escape(outcome.DeskName), day, notifications.Escape(outcome.DeskName), notifications.FormatSlot(outcome.DeskOffice, outcome.DateFrom, outcome.DateTo), *outcome.Request.Rank))
+		return
+	}
+
+	waitlistNote := "Вы уже стоите в очереди ожидания по всем вашим вариантам."
+	if outcome.WaitlistEntries > 0 {
+		waitlistNote = "Вы добавлены в очередь ожидания по вашим вариантам: если стол освободится, мы предложим его вам."
+	}
+
+	notifications.Send(outcome.UserEmail, "Стол в лотерее не достался", fmt.Sprintf(`
+		<h2>Стол в лотерее не достался</h2>
+		<p>По итогам лотереи на %s свободных столов по вашим вариантам не осталось.</p>
+		<p>%s</p>
+		<p>Проигрыш учитывается в следующих лотереях: участники, которым реже везло, разыгрываются раньше.</p>
+	`, day, waitlistNote))
+}
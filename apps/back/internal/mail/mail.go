@@ -13,19 +13,14 @@ import (
 )
 
 func SendVerificationEmail(email, token string) error {
-	user := viper.GetString("mail.user")
-	password := viper.GetString("mail.password")
-	portStr := viper.GetString("smtp.port")
 	domain := viper.GetString("domain")
-	provider := viper.GetString("smtp.provider")
-
-	if user == "" || password == "" || portStr == "" || domain == "" || provider == "" {
+	if domain == "" {
 		return fmt.Errorf("SendVerificationEmail | one or more required configuration variables are missing")
 	}
 
-	port, err := strconv.Atoi(portStr)
+	d, user, err := newDialer()
 	if err != nil {
-		return fmt.Errorf("SendVerificationEmail | invalid SMTP port: %v", err)
+		return fmt.Errorf("SendVerificationEmail | %v", err)
 	}
 
 	m := gomail.NewMessage()
@@ -38,17 +33,58 @@ func SendVerificationEmail(email, token string) error {
 		<a href="%s/api/auth/verify?token=%s">Подтвердить регистрацию</a>
 	`, domain, token))
 
-	d := gomail.NewDialer(provider, port, user, password)
+	if err := d.DialAndSend(m); err != nil {
+		return fmt.Errorf("failed to send verification email: %v", err)
+	}
 
-	if !config.IsProdMode() {
-		d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+	slog.Info("SendVerificationEmail | Send email verification")
+
+	return nil
+}
+
+// Отправляет уведомление о бронировании. body — HTML-разметка письма.
+func SendNotificationEmail(email, subject, body string) error {
+	d, user, err := newDialer()
+	if err != nil {
+		return fmt.Errorf("SendNotificationEmail | %v", err)
 	}
 
+	m := gomail.NewMessage()
+	m.SetHeader("From", user)
+	m.SetHeader("To", email)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", body)
+
 	if err := d.DialAndSend(m); err != nil {
-		return fmt.Errorf("failed to send verification email: %v", err)
+		return fmt.Errorf("failed to send notification email: %v", err)
 	}
 
-	slog.Info("SendVerificationEmail | Send email verification")
+	slog.Info("SendNotificationEmail | Send notification", "subject", subject)
 
 	return nil
 }
+
+// Возвращает SMTP-клиент и адрес отправителя из конфигурации.
+func newDialer() (*gomail.Dialer, string, error) {
+	user := viper.GetString("mail.user")
+	password := viper.GetString("mail.password")
+	portStr := viper.GetString("smtp.port")
+	provider := viper.GetString("smtp.provider")
+
+	if user == "" || password == "" || portStr == "" || provider == "" {
+		return nil, "", fmt.Errorf("one or more required configuration variables are missing")
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid SMTP port: %v", err)
+	}
+
+	d := gomail.NewDialer(provider, port, user, password)
+
+	if !config.IsProdMode() {
+		d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return d, user, nil
+}
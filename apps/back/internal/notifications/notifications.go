@@ -0,0 +1,46 @@
+package notifications
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"time"
+
+	"place-picker/internal/mail"
+	"place-picker/internal/offices"
+)
+
+// Отправляет письмо в фоне. Ошибка отправки не влияет на результат операции и только логируется.
+func Send(email, subject, body string) {
+	go func() {
+		if err := mail.SendNotificationEmail(email, subject, body); err != nil {
+			slog.Error("Send | Failed to send notification", "error", err.Error(), "subject", subject)
+		}
+	}()
+}
+
+// Экранирует значение для вставки в HTML письма. Через нее проходят все данные, которые вводят
+// пользователи и администраторы: имена, названия столов и зон, комментарии.
+func Escape(value string) string {
+	return html.EscapeString(value)
+}
+
+// Форматирует интервал брони по местному времени офиса стола, например «12.03.2025 09:00–18:00».
+func FormatSlot(office string, dateFrom, dateTo time.Time) string {
+	location := Location(office)
+
+	return fmt.Sprintf("%s %s–%s",
+		dateFrom.In(location).Format("02.01.2006"),
+		dateFrom.In(location).Format("15:04"),
+		dateTo.In(location).Format("15:04"),
+	)
+}
+
+// Возвращает часовой пояс офиса. Для неизвестного офиса время выводится в UTC.
+func Location(office string) *time.Location {
+	if o, err := offices.Get(office); err == nil {
+		return o.Location
+	}
+
+	return time.UTC
+}
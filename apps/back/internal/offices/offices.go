@@ -0,0 +1,196 @@
+package offices
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"place-picker/internal/config"
+)
+
+const clockLayout = "15:04"
+
+var ErrUnknownOffice = errors.New("unknown office")
+
+// Office описывает рабочие часы офиса. WorkStart и WorkEnd отсчитываются от полуночи
+// по местному времени офиса, поэтому переход на летнее время их не сдвигает.
+type Office struct {
+	Key       string         `json:"key"`
+	Location  *time.Location `json:"-"`
+	Timezone  string         `json:"timezone"`
+	WorkStart string         `json:"workStart"`
+	WorkEnd   string         `json:"workEnd"`
+
+	start time.Duration
+	end   time.Duration
+}
+
+var (
+	registry   = map[string]Office{}
+	defaultKey string
+)
+
+// Загружает офисы из конфигурации. Паникует, если часовой пояс или рабочие часы заданы неверно.
+func MustLoad(offices map[string]config.Office, defaultOffice string) {
+	loaded := make(map[string]Office, len(offices))
+	for key, cfg := range offices {
+		office, err := newOffice(key, cfg)
+		if err != nil {
+			log.Panicf("offices.MustLoad | Invalid office %q: %v", key, err)
+		}
+		loaded[key] = office
+	}
+
+	if _, ok := loaded[defaultOffice]; !ok {
+		log.Panicf("offices.MustLoad | Default office %q is not configured", defaultOffice)
+	}
+
+	registry = loaded
+	defaultKey = defaultOffice
+}
+
+func newOffice(key string, cfg config.Office) (Office, error) {
+	if cfg.Timezone == "Local" {
+		return Office{}, errors.New("timezone must be an IANA name")
+	}
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return Office{}, fmt.Errorf("failed to load timezone: %w", err)
+	}
+
+	start, err := parseClock(cfg.WorkStart)
+	if err != nil {
+		return Office{}, fmt.Errorf("invalid work_start: %w", err)
+	}
+
+	end, err := parseClock(cfg.WorkEnd)
+	if err != nil {
+		return Office{}, fmt.Errorf("invalid work_end: %w", err)
+	}
+
+	if end <= start {
+		return Office{}, errors.New("work_end must be after work_start")
+	}
+
+	return Office{
+		Key:       key,
+		Location:  location,
+		Timezone:  cfg.Timezone,
+		WorkStart: cfg.WorkStart,
+		WorkEnd:   cfg.WorkEnd,
+		start:     start,
+		end:       end,
+	}, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	parsed, err := time.Parse(clockLayout, value)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
+
+// Возвращает офис по ключу. Пустой ключ означает офис по умолчанию.
+func Get(key string) (Office, error) {
+	if key == "" {
+		key = defaultKey
+	}
+
+	office, ok := registry[key]
+	if !ok {
+		return Office{}, fmt.Errorf("%w: %s", ErrUnknownOffice, key)
+	}
+
+	return office, nil
+}
+
+func Exists(key string) bool {
+	_, ok := registry[key]
+	return ok
+}
+
+func All() []Office {
+	offices := make([]Office, 0, len(registry))
+	for _, office := range registry {
+		offices = append(offices, office)
+	}
+
+	sort.Slice(offices, func(i, j int) bool { return offices[i].Key < offices[j].Key })
+
+	return offices
+}
+
+// Возвращает офис с другими рабочими часами, например часами работы парковки.
+func (o Office) WithHours(workStart, workEnd string) (Office, error) {
+	start, err := parseClock(workStart)
+	if err != nil {
+		return Office{}, fmt.Errorf("invalid work start: %w", err)
+	}
+
+	end, err := parseClock(workEnd)
+	if err != nil {
+		return Office{}, fmt.Errorf("invalid work end: %w", err)
+	}
+
+	if end <= start {
+		return Office{}, errors.New("work end must be after work start")
+	}
+
+	o.WorkStart, o.WorkEnd, o.start, o.end = workStart, workEnd, start, end
+	return o, nil
+}
+
+// Проверяет, что интервал укладывается в рабочие часы одного дня по местному времени офиса.
+func (o Office) Contains(from, to time.Time) bool {
+	localFrom := from.In(o.Location)
+	localTo := to.In(o.Location)
+
+	if !localTo.After(localFrom) || o.Day(localFrom) != o.Day(localTo) {
+		return false
+	}
+
+	return clock(localFrom) >= o.start && clock(localTo) <= o.end
+}
+
+// Возвращает местную дату в формате YYYY-MM-DD.
+func (o Office) Day(t time.Time) string {
+	return t.In(o.Location).Format(time.DateOnly)
+}
+
+// Возвращает начало и конец местного дня. С учетом перехода на летнее время день может длиться не 24 часа.
+func (o Office) DayBounds(day string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation(time.DateOnly, day, o.Location)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return start, start.AddDate(0, 0, 1), nil
+}
+
+// Возвращает начало и конец рабочих часов местного дня.
+func (o Office) WorkBounds(day string) (time.Time, time.Time, error) {
+	date, err := time.ParseInLocation(time.DateOnly, day, o.Location)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	at := func(offset time.Duration) time.Time {
+		return time.Date(date.Year(), date.Month(), date.Day(), int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, o.Location)
+	}
+
+	return at(o.start), at(o.end), nil
+}
+
+// Рабочие часы в виде "08:00-21:00 Europe/Moscow" для сообщений об ошибках.
+func (o Office) Hours() string {
+	return fmt.Sprintf("%s-%s %s", o.WorkStart, o.WorkEnd, o.Timezone)
+}
+
+func clock(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
@@ -0,0 +1,74 @@
+package recurrence
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+)
+
+// Периодически досоздает вхождения серий, чтобы они всегда были забронированы на horizon вперед.
+func StartSeriesExpansion(ctx context.Context, logger *slog.Logger, repo *reservationsRepo.ReservationsRepository, interval, horizon time.Duration) {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	expandSeries(ctx, logger, repo, horizon)
+
+	logger.Info("StartSeriesExpansion | Started reservation series expansion", "interval", interval, "horizon", horizon)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("StartSeriesExpansion | Stopping reservation series expansion")
+			return
+		case <-ticker.C:
+			expandSeries(ctx, logger, repo, horizon)
+		}
+	}
+}
+
+func expandSeries(ctx context.Context, logger *slog.Logger, repo *reservationsRepo.ReservationsRepository, horizon time.Duration) {
+	until := time.Now().Add(horizon)
+
+	seriesList, err := repo.GetSeriesToExpand(ctx, until)
+	if err != nil {
+		logger.Error("expandSeries | Failed to load reservation series", "error", err.Error())
+		return
+	}
+
+	for _, series := range seriesList {
+		office, err := repo.GetDeskOffice(ctx, series.DeskId)
+		if err != nil {
+			logger.Error("expandSeries | Failed to load desk office", "seriesId", series.Id, "error", err.Error())
+			continue
+		}
+
+		// Правило разворачивается по местному времени офиса, чтобы вхождения не сдвигались при переходе на летнее время.
+		rule, err := ParseRule(series.RRule, series.DateFrom.In(office.Location))
+		if err != nil {
+			logger.Error("expandSeries | Invalid series rule", "seriesId", series.Id, "error", err.Error())
+			continue
+		}
+
+		duration := series.DateTo.Sub(series.DateFrom)
+		var slots []reservationsRepo.ReservationSlot
+		for _, start := range Occurrences(rule, series.ExDates, series.ExpandedUntil.Add(time.Second), until) {
+			slots = append(slots, reservationsRepo.ReservationSlot{DateFrom: start, DateTo: start.Add(duration)})
+		}
+
+		created, err := repo.ExtendSeries(ctx, series, slots, until)
+		if err != nil {
+			logger.Error("expandSeries | Failed to extend series", "seriesId", series.Id, "error", err.Error())
+			continue
+		}
+
+		if skipped := len(slots) - created; created > 0 || skipped > 0 {
+			logger.Info("expandSeries | Series extended", "seriesId", series.Id, "created", created, "skipped", skipped)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+const dateLayout = "2006-01-02"
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+var ErrInvalidExDate = errors.New("invalid exdate")
+
+// Разбирает значение RRULE (RFC 5545) относительно начала первой брони серии.
+// Поддерживаются только правила с шагом не меньше дня: бронь длится в пределах одного дня.
+func ParseRule(rule string, dtstart time.Time) (*rrule.RRule, error) {
+	rule = strings.TrimSpace(rule)
+	rule = strings.TrimPrefix(strings.TrimPrefix(rule, "RRULE:"), "rrule:")
+
+	option, err := rrule.StrToROption(rule)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+
+	if option.Freq > rrule.DAILY {
+		return nil, fmt.Errorf("%w: frequency must be DAILY or less frequent", ErrInvalidRule)
+	}
+
+	option.Dtstart = dtstart
+
+	r, err := rrule.NewRRule(*option)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+
+	return r, nil
+}
+
+// Приводит значения EXDATE к датам YYYY-MM-DD. Принимает форматы DATE и DATE-TIME из RFC 5545,
+// а также ISO 8601 дату. Исключается весь день вхождения.
+func ParseExDates(values []string) ([]string, error) {
+	layouts := []string{dateLayout, "20060102", "20060102T150405Z", "20060102T150405"}
+
+	exdates := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+
+		var (
+			parsed time.Time
+			err    error
+		)
+		for _, layout := range layouts {
+			if parsed, err = time.Parse(layout, value); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidExDate, value)
+		}
+
+		exdates = append(exdates, parsed.Format(dateLayout))
+	}
+
+	return exdates, nil
+}
+
+// Возвращает начала вхождений правила в интервале [from, to], кроме дней из exdates.
+// Дни сравниваются в часовом поясе начала серии.
+func Occurrences(rule *rrule.RRule, exdates []string, from, to time.Time) []time.Time {
+	excluded := make(map[string]bool, len(exdates))
+	for _, exdate := range exdates {
+		excluded[exdate] = true
+	}
+
+	var occurrences []time.Time
+	for _, occurrence := range rule.Between(from, to, true) {
+		if excluded[occurrence.Format(dateLayout)] {
+			continue
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+
+	return occurrences
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	userRepo "place-picker/internal/db/repo/user"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Пропускает запрос только для пользователей с ролью администратора.
+// Должен подключаться после AuthMiddleware.
+func AdminMiddleware(repo *userRepo.UserRepository) gin.HandlerFunc {
+	return requireRole(repo, "admin role required", userRepo.RoleAdmin)
+}
+
+// Пропускает запрос для сотрудников ресепшена и администраторов.
+// Должен подключаться после AuthMiddleware.
+func ReceptionMiddleware(repo *userRepo.UserRepository) gin.HandlerFunc {
+	return requireRole(repo, "reception role required", userRepo.RoleReception, userRepo.RoleAdmin)
+}
+
+func requireRole(repo *userRepo.UserRepository, message string, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, ok := c.Get("userId")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		userIdStr, ok := userId.(string)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		user, err := repo.GetUserByID(c.Request.Context(), userIdStr)
+		if err != nil {
+			slog.Error("requireRole | Failed to get user", "error", err.Error())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		if !slices.Contains(roles, user.Role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": message})
+			return
+		}
+
+		c.Set("userRole", user.Role)
+		c.Next()
+	}
+}
@@ -9,9 +9,10 @@ import (
 // Возвращает настроенный CORS.
 func ConfigureCORS() cors.Config {
 	return cors.Config{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders: []string{"Origin", "Content-Type", "Authorization"},
-		MaxAge:       12 * time.Hour,
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowHeaders:  []string{"Origin", "Content-Type", "Authorization", "Idempotency-Key"},
+		ExposeHeaders: []string{"Idempotent-Replayed"},
+		MaxAge:        12 * time.Hour,
 	}
 }
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	idempotencyRepo "place-picker/internal/db/repo/idempotency"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// Делает изменяющие запросы с заголовком Idempotency-Key идемпотентными. Ответ на первый запрос
+// хранится idempotency.ttl и возвращается на повторы с тем же ключом. Повтор ключа с другим запросом
+// отклоняется с 422. Ответы 5xx не сохраняются, чтобы запрос можно было повторить.
+// Ключи действуют в пределах пользователя, поэтому мидлвар подключается после AuthMiddleware.
+func IdempotencyMiddleware(repo *idempotencyRepo.IdempotencyRepository) gin.HandlerFunc {
+	ttl := viper.GetDuration("idempotency.ttl")
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderKey)
+		if key == "" || !isMutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "idempotency key is too long"})
+			return
+		}
+
+		userId, ok := c.Get("userId")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		userIdStr, ok := userId.(string)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		fingerprint, err := requestFingerprint(c)
+		if err != nil {
+			slog.Error("IdempotencyMiddleware | Failed to read request body", "error", err.Error())
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+
+		record, err := repo.Acquire(c.Request.Context(), userIdStr, key, fingerprint, ttl)
+		if err != nil {
+			slog.Error("IdempotencyMiddleware | Failed to acquire idempotency key", "error", err.Error())
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check idempotency key"})
+			return
+		}
+
+		if record != nil {
+			replay(c, record, fingerprint)
+			return
+		}
+
+		// Ответ сохраняется и при обрыве соединения клиентом: иначе ключ останется занятым до истечения срока.
+		ctx := context.WithoutCancel(c.Request.Context())
+		writer := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+
+		saved := false
+		defer func() {
+			if saved {
+				return
+			}
+			if err := repo.Release(ctx, userIdStr, key); err != nil {
+				slog.Error("IdempotencyMiddleware | Failed to release idempotency key", "error", err.Error())
+			}
+		}()
+
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		if err := repo.Complete(ctx, userIdStr, key, status, writer.Header().Get("Content-Type"), writer.body.Bytes()); err != nil {
+			slog.Error("IdempotencyMiddleware | Failed to save response", "error", err.Error())
+			return
+		}
+		saved = true
+	}
+}
+
+// Отвечает на повтор запроса сохраненным ответом.
+func replay(c *gin.Context, record *idempotencyRepo.Record, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "idempotency key was already used with a different request"})
+		return
+	}
+
+	if record.StatusCode == nil {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "request with this idempotency key is still in progress"})
+		return
+	}
+
+	c.Header(HeaderReplayed, "true")
+	c.Data(*record.StatusCode, record.ContentType, record.ResponseBody)
+	c.Abort()
+}
+
+// Отпечаток запроса: метод, путь, параметры и тело.
+func requestFingerprint(c *gin.Context) (string, error) {
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		if body, err = io.ReadAll(c.Request.Body); err != nil {
+			return "", err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + "\n" + c.Request.URL.Path + "\n" + c.Request.URL.RawQuery + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -8,8 +8,10 @@ import (
 	"os"
 	"path/filepath"
 	"place-picker/internal/config"
+	idempotencyRepo "place-picker/internal/db/repo/idempotency"
 	authMiddleware "place-picker/internal/server/middleware/auth"
 	corsMiddleware "place-picker/internal/server/middleware/cors"
+	idempotencyMiddleware "place-picker/internal/server/middleware/idempotency"
 	loggerMiddleware "place-picker/internal/server/middleware/logger"
 	"strings"
 
@@ -24,13 +26,13 @@ type RouteModule interface {
 }
 
 // Настраивает мидлвары и эндпоинты сервера. Возвращает роутер.
-func setupRouter(logger *slog.Logger, modules ...RouteModule) *gin.Engine {
+func setupRouter(logger *slog.Logger, idempotency *idempotencyRepo.IdempotencyRepository, modules ...RouteModule) *gin.Engine {
 	router := gin.New()
 	router.Use(gin.Recovery(), loggerMiddleware.SlogLogger(logger), cors.New(corsMiddleware.ConfigureCORS()))
 
 	publicApi := router.Group("/api")
 	privateApi := router.Group("/api/private")
-	privateApi.Use(authMiddleware.AuthMiddleware())
+	privateApi.Use(authMiddleware.AuthMiddleware(), idempotencyMiddleware.IdempotencyMiddleware(idempotency))
 
 	for _, m := range modules {
 		m.RegisterPublicRoutes(publicApi)
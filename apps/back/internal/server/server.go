@@ -6,10 +6,18 @@ import (
 	"log/slog"
 	"net/http"
 	"place-picker/internal/api/auth"
+	"place-picker/internal/api/closures"
+	"place-picker/internal/api/delegations"
 	"place-picker/internal/api/desks"
+	"place-picker/internal/api/groups"
+	"place-picker/internal/api/offices"
+	"place-picker/internal/api/policies"
 	"place-picker/internal/api/reservation"
+	"place-picker/internal/api/slots"
 	"place-picker/internal/api/user"
+	"place-picker/internal/api/zones"
 	"place-picker/internal/config"
+	idempotencyRepo "place-picker/internal/db/repo/idempotency"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -45,7 +53,7 @@ func stopServer(srv *http.Server, logger *slog.Logger) {
 
 // Создает HTTP сервер с переданной конфигурацией и возвращает его.
 func newHTTPServerInstance(logger *slog.Logger, serverConfig config.HTTPServer, db *sql.DB) *http.Server {
-	router := setupRouter(logger, auth.New(db), desks.New(db), reservation.New(db), user.New(db))
+	router := setupRouter(logger, idempotencyRepo.NewIdempotencyRepository(db), auth.New(db), desks.New(db), reservation.New(db), user.New(db), groups.New(db), zones.New(db), offices.New(), policies.New(db), delegations.New(db), closures.New(db), slots.New(db))
 
 	if config.IsProdMode() {
 		gin.SetMode(gin.ReleaseMode)
@@ -0,0 +1,105 @@
+package swaps
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"place-picker/internal/notifications"
+)
+
+// Периодически снимает просроченные предложения обмена столами и уведомляет обе стороны.
+func StartSwapExpiry(ctx context.Context, logger *slog.Logger, repo *reservationsRepo.ReservationsRepository, interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	expireSwaps(ctx, logger, repo)
+
+	logger.Info("StartSwapExpiry | Started swap requests expiry", "interval", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("StartSwapExpiry | Stopping swap requests expiry")
+			return
+		case <-ticker.C:
+			expireSwaps(ctx, logger, repo)
+		}
+	}
+}
+
+func expireSwaps(ctx context.Context, logger *slog.Logger, repo *reservationsRepo.ReservationsRepository) {
+	expired, err := repo.ExpireSwaps(ctx)
+	if err != nil {
+		logger.Error("expireSwaps | Failed to expire swap requests", "error", err.Error())
+		return
+	}
+
+	if len(expired) == 0 {
+		return
+	}
+
+	logger.Info("expireSwaps | Expired swap requests", "count", len(expired))
+
+	for _, swap := range expired {
+		Notify(swap)
+	}
+}
+
+// Уведомляет стороны обмена о его текущем статусе: о новом предложении — того, кому предложен обмен,
+// о решении — предложившего, об обмене и истечении срока — обоих, об отзыве — того, кому был предложен обмен.
+func Notify(swap reservationsRepo.SwapRequest) {
+	requester, target := swap.Requester, swap.Target
+	offer := fmt.Sprintf("стол %s, %s в обмен на стол %s, %s",
+		notifications.Escape(requester.DeskName), slot(requester),
+		notifications.Escape(target.DeskName), slot(target),
+	)
+
+	switch swap.Status {
+	case reservationsRepo.SwapPending:
+		notifications.Send(target.UserEmail, "Вам предложили обмен столами", fmt.Sprintf(`
+			<h2>Предложение обмена столами</h2>
+			<p>%s предлагает %s.</p>
+			<p>Предложение действует до %s.</p>
+		`, notifications.Escape(requester.UserName), offer, swap.ExpiresAt.In(notifications.Location(target.DeskOffice)).Format("02.01.2006 15:04")))
+	case reservationsRepo.SwapAccepted:
+		notifications.Send(requester.UserEmail, "Обмен столами состоялся", fmt.Sprintf(`
+			<h2>Обмен столами состоялся</h2>
+			<p>%s принял(а) обмен. Ваша бронь теперь на стол %s, %s.</p>
+		`, notifications.Escape(target.UserName), notifications.Escape(target.DeskName), slot(requester)))
+		notifications.Send(target.UserEmail, "Обмен столами состоялся", fmt.Sprintf(`
+			<h2>Обмен столами состоялся</h2>
+			<p>Ваша бронь теперь на стол %s, %s.</p>
+		`, notifications.Escape(requester.DeskName), slot(target)))
+	case reservationsRepo.SwapDeclined:
+		notifications.Send(requester.UserEmail, "Обмен столами отклонен", fmt.Sprintf(`
+			<h2>Обмен столами отклонен</h2>
+			<p>%s отклонил(а) предложение: %s.</p>
+		`, notifications.Escape(target.UserName), offer))
+	case reservationsRepo.SwapCancelled:
+		notifications.Send(target.UserEmail, "Предложение обмена отозвано", fmt.Sprintf(`
+			<h2>Предложение обмена отозвано</h2>
+			<p>%s отозвал(а) предложение: %s.</p>
+		`, notifications.Escape(requester.UserName), offer))
+	case reservationsRepo.SwapExpired:
+		body := fmt.Sprintf(`
+			<h2>Предложение обмена истекло</h2>
+			<p>Обмен не состоялся: %s. Брони остались без изменений.</p>
+		`, offer)
+		notifications.Send(requester.UserEmail, "Предложение обмена истекло", body)
+		notifications.Send(target.UserEmail, "Предложение обмена истекло", body)
+	}
+}
+
+func slot(side reservationsRepo.SwapSide) string {
+	if side.DateFrom == nil || side.DateTo == nil {
+		return "бронь в архиве"
+	}
+	return notifications.FormatSlot(side.DeskOffice, *side.DateFrom, *side.DateTo)
+}
@@ -0,0 +1,92 @@
+package waitlist
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	reservationsRepo "place-picker/internal/db/repo/reservation"
+	"place-picker/internal/mail"
+	"place-picker/internal/notifications"
+)
+
+var wake = make(chan struct{}, 1)
+
+// Запускает внеочередную обработку очереди ожидания, например после отмены брони.
+func Wake() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// Периодически снимает просроченные предложения и бронирует освободившиеся столы для очереди ожидания.
+func StartWaitlistProcessing(ctx context.Context, logger *slog.Logger, repo *reservationsRepo.ReservationsRepository, interval, offerTTL time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	processWaitlist(ctx, logger, repo, offerTTL)
+
+	logger.Info("StartWaitlistProcessing | Started waitlist processing", "interval", interval, "offerTTL", offerTTL)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("StartWaitlistProcessing | Stopping waitlist processing")
+			return
+		case <-ticker.C:
+			processWaitlist(ctx, logger, repo, offerTTL)
+		case <-wake:
+			processWaitlist(ctx, logger, repo, offerTTL)
+		}
+	}
+}
+
+func processWaitlist(ctx context.Context, logger *slog.Logger, repo *reservationsRepo.ReservationsRepository, offerTTL time.Duration) {
+	released, err := repo.ExpireWaitlist(ctx)
+	if err != nil {
+		logger.Error("processWaitlist | Failed to expire waitlist offers", "error", err.Error())
+		return
+	}
+
+	if released > 0 {
+		logger.Info("processWaitlist | Released expired waitlist offers", "count", released)
+	}
+
+	allocations, err := repo.ProcessWaitlist(ctx, offerTTL)
+	if err != nil {
+		logger.Error("processWaitlist | Failed to process waitlist", "error", err.Error())
+	}
+
+	for _, allocation := range allocations {
+		logger.Info("processWaitlist | Desk allocated from waitlist", "entryId", allocation.Entry.Id, "status", allocation.Entry.Status)
+
+		subject, body := allocationEmail(allocation)
+		if err := mail.SendNotificationEmail(allocation.UserEmail, subject, body); err != nil {
+			logger.Error("processWaitlist | Failed to send waitlist notification", "entryId", allocation.Entry.Id, "error", err.Error())
+		}
+	}
+}
+
+func allocationEmail(allocation reservationsRepo.WaitlistAllocation) (string, string) {
+	entry := allocation.Entry
+	slot := notifications.FormatSlot(allocation.DeskOffice, entry.DateFrom, entry.DateTo)
+
+	if entry.Status == reservationsRepo.WaitlistOffered {
+		return "Освободился стол из листа ожидания", fmt.Sprintf(`
+			<h2>Стол %s свободен</h2>
+			<p>Для вас забронирован стол на %s.</p>
+			<p>Подтвердите бронь до %s, иначе она будет отменена и предложена следующему в очереди.</p>
+		`, notifications.Escape(allocation.DeskName), slot, entry.OfferExpiresAt.In(notifications.Location(allocation.DeskOffice)).Format("15:04 02.01.2006"))
+	}
+
+	return "Стол забронирован из листа ожидания", fmt.Sprintf(`
+		<h2>Стол %s забронирован</h2>
+		<p>Для вас забронирован стол на %s.</p>
+	`, notifications.Escape(allocation.DeskName), slot)
+}
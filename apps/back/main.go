@@ -3,19 +3,26 @@ package main
 import (
 	"context"
 	"place-picker/internal/api/desks"
+	"place-picker/internal/approval"
 	"place-picker/internal/config"
 	"place-picker/internal/db"
 	"place-picker/internal/db/cleanup"
 	desksRepo "place-picker/internal/db/repo/desks"
+	idempotencyRepo "place-picker/internal/db/repo/idempotency"
 	reservationsRepo "place-picker/internal/db/repo/reservation"
 	"place-picker/internal/logger"
+	"place-picker/internal/lottery"
+	"place-picker/internal/offices"
+	"place-picker/internal/recurrence"
 	"place-picker/internal/server"
-	"time"
+	"place-picker/internal/swaps"
+	"place-picker/internal/waitlist"
 )
 
 func main() {
 	config := config.MustLoadConfig()
 	slogLogger := logger.MustSetupLogger(config.LogsPath, config.Mode)
+	offices.MustLoad(config.Offices, config.DefaultOffice)
 
 	conn, err := db.MustConnectDB()
 	if err != nil {
@@ -35,7 +42,14 @@ func main() {
 	}
 
 	reservationsRepository := reservationsRepo.NewReservationsRepository(conn)
-	go cleanup.StartOldReservationsCleanup(ctx, slogLogger, reservationsRepository, 1*time.Hour)
+	go cleanup.StartReservationsArchival(ctx, slogLogger, reservationsRepository, config.Retention.ArchiveInterval, config.Retention.ArchiveAfter)
+	go cleanup.StartNoShowsRelease(ctx, slogLogger, reservationsRepository, config.CheckIn.ReleaseInterval, config.CheckIn.GracePeriod)
+	go recurrence.StartSeriesExpansion(ctx, slogLogger, reservationsRepository, config.Recurrence.ExpandInterval, config.Recurrence.Horizon)
+	go waitlist.StartWaitlistProcessing(ctx, slogLogger, reservationsRepository, config.Waitlist.ProcessInterval, config.Waitlist.OfferTTL)
+	go approval.StartApprovalExpiry(ctx, slogLogger, reservationsRepository, config.Approval.ExpireInterval, config.Approval.PendingTTL)
+	go lottery.StartLotteryDraws(ctx, slogLogger, reservationsRepository, config.Lottery.DrawInterval, config.Lottery.FairnessWindow)
+	go swaps.StartSwapExpiry(ctx, slogLogger, reservationsRepository, config.Swaps.ExpireInterval)
+	go cleanup.StartIdempotencyKeysCleanup(ctx, slogLogger, idempotencyRepo.NewIdempotencyRepository(conn), config.Idempotency.CleanupInterval)
 
 	server.NewHTTPServer(ctx, slogLogger, config.HTTPServer, conn)
 }
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS desk_releases;
+DROP TABLE IF EXISTS desk_assignments;
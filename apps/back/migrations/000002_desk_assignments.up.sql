@@ -0,0 +1,30 @@
+-- 002_desk_assignments.sql
+
+CREATE TABLE IF NOT EXISTS desk_assignments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    desk_id UUID NOT NULL REFERENCES desks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date_from DATE,
+    date_to DATE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT desk_assignment_dates_order CHECK (
+        date_from IS NULL OR date_to IS NULL OR date_from <= date_to
+    )
+);
+
+-- NULL в date_from / date_to означает открытую границу закрепления
+ALTER TABLE desk_assignments
+ADD CONSTRAINT one_assignment_per_desk_per_period
+EXCLUDE USING gist (
+    desk_id WITH =,
+    daterange(date_from, date_to, '[]') WITH &&
+);
+
+CREATE TABLE IF NOT EXISTS desk_releases (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    assignment_id UUID NOT NULL REFERENCES desk_assignments(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT one_release_per_assignment_per_day UNIQUE (assignment_id, day)
+);
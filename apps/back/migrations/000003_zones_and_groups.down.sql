@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS desk_group_restrictions;
+DROP TABLE IF EXISTS zone_group_restrictions;
+DROP TABLE IF EXISTS user_group_members;
+DROP TABLE IF EXISTS user_groups;
+ALTER TABLE desks DROP COLUMN IF EXISTS zone_id;
+DROP TABLE IF EXISTS zones;
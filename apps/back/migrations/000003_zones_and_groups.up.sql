@@ -0,0 +1,38 @@
+-- 003_zones_and_groups.sql
+
+CREATE TABLE IF NOT EXISTS zones (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL UNIQUE,
+    -- За сколько дней до даты брони зона открывается для всех пользователей. NULL — не открывается
+    open_days_before INTEGER CHECK (open_days_before >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE desks ADD COLUMN IF NOT EXISTS zone_id UUID REFERENCES zones(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS user_groups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS user_group_members (
+    group_id UUID NOT NULL REFERENCES user_groups(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS zone_group_restrictions (
+    zone_id UUID NOT NULL REFERENCES zones(id) ON DELETE CASCADE,
+    group_id UUID NOT NULL REFERENCES user_groups(id) ON DELETE CASCADE,
+    PRIMARY KEY (zone_id, group_id)
+);
+
+CREATE TABLE IF NOT EXISTS desk_group_restrictions (
+    desk_id UUID NOT NULL REFERENCES desks(id) ON DELETE CASCADE,
+    group_id UUID NOT NULL REFERENCES user_groups(id) ON DELETE CASCADE,
+    PRIMARY KEY (desk_id, group_id)
+);
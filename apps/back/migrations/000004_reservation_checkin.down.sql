@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS reservation_no_shows;
+ALTER TABLE reservations DROP COLUMN IF EXISTS checked_in_at;
@@ -0,0 +1,19 @@
+-- 004_reservation_checkin.sql
+
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS checked_in_at TIMESTAMP WITH TIME ZONE;
+
+-- Брони, начавшиеся до появления отметок, считаются подтвержденными,
+-- иначе первый запуск освобождения неявок снимет их все
+UPDATE reservations SET checked_in_at = date_from WHERE date_from < NOW();
+
+CREATE TABLE IF NOT EXISTS reservation_no_shows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reservation_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    desk_id UUID NOT NULL REFERENCES desks(id) ON DELETE CASCADE,
+    date_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    date_to TIMESTAMP WITH TIME ZONE NOT NULL,
+    released_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS reservation_no_shows_user_id_idx ON reservation_no_shows (user_id);
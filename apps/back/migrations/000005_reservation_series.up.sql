@@ -0,0 +1,21 @@
+-- 005_reservation_series.sql
+
+CREATE TABLE IF NOT EXISTS reservation_series (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    desk_id UUID NOT NULL REFERENCES desks(id) ON DELETE CASCADE,
+    -- Правило повторения в формате RFC 5545 (значение RRULE)
+    rrule TEXT NOT NULL,
+    exdates DATE[] NOT NULL DEFAULT '{}',
+    -- Время первого вхождения серии, от него отсчитывается правило
+    date_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    date_to TIMESTAMP WITH TIME ZONE NOT NULL,
+    -- До какого момента вхождения серии уже созданы в reservations
+    expanded_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS series_id UUID REFERENCES reservation_series(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS reservations_series_id_idx ON reservations (series_id);
@@ -0,0 +1,9 @@
+ALTER TABLE desks DROP COLUMN IF EXISTS office;
+
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS reservation_period_valid;
+
+ALTER TABLE reservations
+    ADD CONSTRAINT reservation_within_working_hours CHECK (
+        EXTRACT(HOUR FROM date_from AT TIME ZONE 'UTC') >= 3
+        AND EXTRACT(HOUR FROM date_to AT TIME ZONE 'UTC') <= 18
+    ) NOT VALID;
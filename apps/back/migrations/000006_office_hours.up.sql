@@ -0,0 +1,10 @@
+-- 006_office_hours.sql
+
+-- Рабочие часы и часовой пояс задаются в конфигурации для каждого офиса и проверяются при создании брони.
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS reservation_within_working_hours;
+
+ALTER TABLE reservations
+    ADD CONSTRAINT reservation_period_valid CHECK (date_to > date_from);
+
+-- Ключ офиса из конфигурации. NULL означает офис по умолчанию.
+ALTER TABLE desks ADD COLUMN IF NOT EXISTS office TEXT;
@@ -0,0 +1,30 @@
+-- 007_booking_policies.sql
+
+-- Правила бронирования для роли пользователя. Строка default применяется к ролям без собственных правил.
+-- NULL в ограничении означает, что ограничения нет.
+CREATE TABLE IF NOT EXISTS booking_policies (
+    role TEXT PRIMARY KEY,
+    max_days_ahead INTEGER CHECK (max_days_ahead >= 0),
+    max_per_week INTEGER CHECK (max_per_week >= 0),
+    max_per_month INTEGER CHECK (max_per_month >= 0),
+    min_duration_minutes INTEGER CHECK (min_duration_minutes > 0),
+    max_duration_minutes INTEGER CHECK (max_duration_minutes > 0),
+    -- Дни недели, в которые бронирование запрещено: 0 — воскресенье, 6 — суббота
+    blackout_weekdays SMALLINT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT booking_policy_duration_order CHECK (
+        min_duration_minutes IS NULL
+        OR max_duration_minutes IS NULL
+        OR min_duration_minutes <= max_duration_minutes
+    ),
+    CONSTRAINT booking_policy_weekdays_valid CHECK (blackout_weekdays <@ ARRAY[0, 1, 2, 3, 4, 5, 6]::SMALLINT[])
+);
+
+INSERT INTO booking_policies (role, max_days_ahead)
+VALUES ('default', 90)
+ON CONFLICT (role) DO NOTHING;
+
+INSERT INTO booking_policies (role)
+VALUES ('admin')
+ON CONFLICT (role) DO NOTHING;
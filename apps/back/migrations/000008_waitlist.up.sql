@@ -0,0 +1,28 @@
+-- 008_waitlist.sql
+
+-- Очередь ожидания на занятый стол или любой стол зоны. Когда подходящий стол освобождается,
+-- первому в очереди бронь либо предлагается с подтверждением до offer_expires_at, либо создается сразу.
+CREATE TABLE IF NOT EXISTS waitlist_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    desk_id UUID REFERENCES desks(id) ON DELETE CASCADE,
+    zone_id UUID REFERENCES zones(id) ON DELETE CASCADE,
+    date_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    date_to TIMESTAMP WITH TIME ZONE NOT NULL,
+    mode TEXT NOT NULL DEFAULT 'offer' CHECK (mode IN ('offer', 'auto_book')),
+    status TEXT NOT NULL DEFAULT 'waiting' CHECK (status IN ('waiting', 'offered', 'booked', 'expired')),
+    reservation_id UUID REFERENCES reservations(id) ON DELETE SET NULL,
+    offer_expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT waitlist_target_required CHECK ((desk_id IS NULL) <> (zone_id IS NULL)),
+    CONSTRAINT waitlist_period_valid CHECK (date_to > date_from)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS waitlist_one_entry_per_target
+    ON waitlist_entries (user_id, COALESCE(desk_id, zone_id), date_from)
+    WHERE status IN ('waiting', 'offered');
+
+CREATE INDEX IF NOT EXISTS idx_waitlist_entries_waiting
+    ON waitlist_entries (created_at)
+    WHERE status = 'waiting';
@@ -0,0 +1,6 @@
+ALTER TABLE reservation_series DROP COLUMN IF EXISTS booked_by;
+
+DROP INDEX IF EXISTS reservations_booked_by_idx;
+ALTER TABLE reservations DROP COLUMN IF EXISTS booked_by;
+
+DROP TABLE IF EXISTS delegation_grants;
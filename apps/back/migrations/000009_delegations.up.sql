@@ -0,0 +1,21 @@
+-- 009_delegations.sql
+
+-- Разрешения бронировать за другого пользователя: user_id разрешает delegate_id бронировать столы от своего имени.
+CREATE TABLE IF NOT EXISTS delegation_grants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    delegate_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT delegation_grants_unique UNIQUE (user_id, delegate_id),
+    CONSTRAINT delegation_not_self CHECK (user_id <> delegate_id)
+);
+
+CREATE INDEX IF NOT EXISTS delegation_grants_delegate_id_idx ON delegation_grants (delegate_id);
+
+-- user_id — кто занимает стол, booked_by — кто оформил бронь
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS booked_by UUID REFERENCES users(id) ON DELETE SET NULL;
+UPDATE reservations SET booked_by = user_id WHERE booked_by IS NULL;
+CREATE INDEX IF NOT EXISTS reservations_booked_by_idx ON reservations (booked_by);
+
+ALTER TABLE reservation_series ADD COLUMN IF NOT EXISTS booked_by UUID REFERENCES users(id) ON DELETE SET NULL;
+UPDATE reservation_series SET booked_by = user_id WHERE booked_by IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE desks DROP CONSTRAINT IF EXISTS desk_position_complete;
+ALTER TABLE desks DROP COLUMN IF EXISTS pos_y;
+ALTER TABLE desks DROP COLUMN IF EXISTS pos_x;
@@ -0,0 +1,8 @@
+-- 010_group_booking.sql
+
+-- Координаты стола на плане этажа. По ним групповая бронь подбирает соседние столы
+ALTER TABLE desks ADD COLUMN IF NOT EXISTS pos_x DOUBLE PRECISION;
+ALTER TABLE desks ADD COLUMN IF NOT EXISTS pos_y DOUBLE PRECISION;
+
+ALTER TABLE desks
+ADD CONSTRAINT desk_position_complete CHECK ((pos_x IS NULL) = (pos_y IS NULL));
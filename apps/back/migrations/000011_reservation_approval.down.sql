@@ -0,0 +1,30 @@
+DROP INDEX IF EXISTS reservations_pending_idx;
+
+-- Без статуса отклоненные брони снова занимали бы стол
+DELETE FROM reservations WHERE status IN ('declined', 'expired');
+
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS one_desk_per_user_per_period;
+ALTER TABLE reservations
+ADD CONSTRAINT one_desk_per_user_per_period
+EXCLUDE USING gist (
+    user_id WITH =,
+    tstzrange(date_from, date_to, '[)') WITH &&
+);
+
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS one_reservation_per_desk_per_period;
+ALTER TABLE reservations
+ADD CONSTRAINT one_reservation_per_desk_per_period
+EXCLUDE USING gist (
+    desk_id WITH =,
+    tstzrange(date_from, date_to, '[)') WITH &&
+);
+
+ALTER TABLE reservations DROP COLUMN IF EXISTS decision_comment;
+ALTER TABLE reservations DROP COLUMN IF EXISTS decided_at;
+ALTER TABLE reservations DROP COLUMN IF EXISTS decided_by;
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS reservation_status_valid;
+ALTER TABLE reservations DROP COLUMN IF EXISTS status;
+
+DROP TABLE IF EXISTS desk_approvers;
+
+ALTER TABLE desks DROP COLUMN IF EXISTS requires_approval;
@@ -0,0 +1,40 @@
+-- 011_reservation_approval.sql
+
+-- Брони столов с requires_approval создаются в статусе pending и ждут решения подтверждающего
+ALTER TABLE desks ADD COLUMN IF NOT EXISTS requires_approval BOOLEAN NOT NULL DEFAULT false;
+
+-- Кто подтверждает брони стола. Администраторы могут подтверждать брони любых столов
+CREATE TABLE IF NOT EXISTS desk_approvers (
+    desk_id UUID NOT NULL REFERENCES desks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (desk_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS desk_approvers_user_id_idx ON desk_approvers (user_id);
+
+-- active и pending занимают стол, declined и expired остаются только для истории решения
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE reservations
+ADD CONSTRAINT reservation_status_valid CHECK (status IN ('active', 'pending', 'declined', 'expired'));
+
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS decided_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS decided_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS decision_comment TEXT;
+
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS one_reservation_per_desk_per_period;
+ALTER TABLE reservations
+ADD CONSTRAINT one_reservation_per_desk_per_period
+EXCLUDE USING gist (
+    desk_id WITH =,
+    tstzrange(date_from, date_to, '[)') WITH &&
+) WHERE (status IN ('active', 'pending'));
+
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS one_desk_per_user_per_period;
+ALTER TABLE reservations
+ADD CONSTRAINT one_desk_per_user_per_period
+EXCLUDE USING gist (
+    user_id WITH =,
+    tstzrange(date_from, date_to, '[)') WITH &&
+) WHERE (status IN ('active', 'pending'));
+
+CREATE INDEX IF NOT EXISTS reservations_pending_idx ON reservations (created_at) WHERE status = 'pending';
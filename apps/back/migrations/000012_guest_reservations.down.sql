@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS reservations_guests_idx;
+
+-- Без отметки гостя брони гостей стали бы бронями пригласивших и нарушили бы ограничение
+DELETE FROM reservations WHERE guest_name IS NOT NULL;
+
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS one_desk_per_user_per_period;
+ALTER TABLE reservations
+ADD CONSTRAINT one_desk_per_user_per_period
+EXCLUDE USING gist (
+    user_id WITH =,
+    tstzrange(date_from, date_to, '[)') WITH &&
+) WHERE (status IN ('active', 'pending'));
+
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS reservation_guest_named;
+ALTER TABLE reservations DROP COLUMN IF EXISTS guest_company;
+ALTER TABLE reservations DROP COLUMN IF EXISTS guest_email;
+ALTER TABLE reservations DROP COLUMN IF EXISTS guest_name;
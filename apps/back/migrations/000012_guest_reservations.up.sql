@@ -0,0 +1,21 @@
+-- 012_guest_reservations.sql
+
+-- Бронь для гостя оформляется на пригласившего сотрудника (user_id), но стол занимает гость
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS guest_name TEXT;
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS guest_email TEXT;
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS guest_company TEXT;
+
+ALTER TABLE reservations
+ADD CONSTRAINT reservation_guest_named CHECK (guest_name IS NOT NULL OR (guest_email IS NULL AND guest_company IS NULL));
+
+-- Гостевые брони не занимают стол пригласившего: у него может быть своя бронь на то же время
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS one_desk_per_user_per_period;
+ALTER TABLE reservations
+ADD CONSTRAINT one_desk_per_user_per_period
+EXCLUDE USING gist (
+    user_id WITH =,
+    tstzrange(date_from, date_to, '[)') WITH &&
+) WHERE (status IN ('active', 'pending') AND guest_name IS NULL);
+
+-- Список гостей на день для ресепшена
+CREATE INDEX IF NOT EXISTS reservations_guests_idx ON reservations (date_from) WHERE guest_name IS NOT NULL;
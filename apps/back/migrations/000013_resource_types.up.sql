@@ -0,0 +1,69 @@
+-- 013_resource_types.sql
+
+-- Типы бронируемых ресурсов и их правила. Часы работы типа заменяют часы офиса,
+-- NULL означает часы офиса. Длительность брони ограничивается в минутах
+CREATE TABLE IF NOT EXISTS resource_types (
+    key TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    work_start TIME,
+    work_end TIME,
+    min_duration_minutes INTEGER CHECK (min_duration_minutes > 0),
+    max_duration_minutes INTEGER CHECK (max_duration_minutes > 0),
+    allows_attendees BOOLEAN NOT NULL DEFAULT false,
+    checkin_required BOOLEAN NOT NULL DEFAULT true,
+    CONSTRAINT resource_type_hours_valid CHECK (
+        (work_start IS NULL AND work_end IS NULL) OR work_end > work_start
+    ),
+    CONSTRAINT resource_type_duration_valid CHECK (
+        min_duration_minutes IS NULL OR max_duration_minutes IS NULL OR max_duration_minutes >= min_duration_minutes
+    )
+);
+
+INSERT INTO resource_types (key, name, work_start, work_end, min_duration_minutes, max_duration_minutes, allows_attendees, checkin_required)
+VALUES
+    ('desk', 'Стол', NULL, NULL, NULL, NULL, false, true),
+    ('meeting_room', 'Переговорная', NULL, NULL, 15, 240, true, true),
+    ('parking', 'Парковочное место', '06:00', '23:00', NULL, NULL, false, false),
+    ('locker', 'Шкафчик', NULL, NULL, NULL, NULL, false, false)
+ON CONFLICT (key) DO NOTHING;
+
+-- Таблица desks хранит ресурсы всех типов. Вместимость ограничивает число участников брони
+ALTER TABLE desks ADD COLUMN IF NOT EXISTS type TEXT NOT NULL DEFAULT 'desk' REFERENCES resource_types(key);
+ALTER TABLE desks ADD COLUMN IF NOT EXISTS capacity INTEGER CHECK (capacity > 0);
+
+CREATE INDEX IF NOT EXISTS desks_type_idx ON desks (type);
+
+-- Тип ресурса копируется в бронь, чтобы ограничение «одна бронь на пользователя» действовало для каждого типа отдельно:
+-- стол и парковочное место на одно время не конфликтуют
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS resource_type TEXT NOT NULL DEFAULT 'desk';
+
+CREATE OR REPLACE FUNCTION set_reservation_resource_type() RETURNS trigger AS $$
+BEGIN
+    SELECT type INTO NEW.resource_type FROM desks WHERE id = NEW.desk_id;
+    NEW.resource_type := COALESCE(NEW.resource_type, 'desk');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS reservations_resource_type ON reservations;
+CREATE TRIGGER reservations_resource_type
+BEFORE INSERT OR UPDATE OF desk_id ON reservations
+FOR EACH ROW EXECUTE FUNCTION set_reservation_resource_type();
+
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS one_desk_per_user_per_period;
+ALTER TABLE reservations
+ADD CONSTRAINT one_desk_per_user_per_period
+EXCLUDE USING gist (
+    user_id WITH =,
+    resource_type WITH =,
+    tstzrange(date_from, date_to, '[)') WITH &&
+) WHERE (status IN ('active', 'pending') AND guest_name IS NULL);
+
+-- Участники брони переговорной. Организатор — пользователь брони, в список не входит
+CREATE TABLE IF NOT EXISTS reservation_attendees (
+    reservation_id UUID NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (reservation_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS reservation_attendees_user_id_idx ON reservation_attendees (user_id);
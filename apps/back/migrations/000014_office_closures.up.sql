@@ -0,0 +1,18 @@
+-- 014_office_closures.sql
+
+-- Дни, когда офис закрыт: праздники и остановки офиса. Даты по местному времени офиса, date_to включительно.
+-- source_uid — UID события календаря, из которого импортировано закрытие; повторный импорт обновляет закрытие.
+CREATE TABLE IF NOT EXISTS office_closures (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    office TEXT NOT NULL,
+    date_from DATE NOT NULL,
+    date_to DATE NOT NULL,
+    reason TEXT NOT NULL,
+    source_uid TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT office_closure_period_valid CHECK (date_to >= date_from),
+    CONSTRAINT office_closures_source_uid_key UNIQUE (office, source_uid)
+);
+
+CREATE INDEX IF NOT EXISTS office_closures_office_idx ON office_closures (office, date_to);
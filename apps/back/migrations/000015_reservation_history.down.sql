@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS reservation_no_shows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reservation_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    desk_id UUID NOT NULL REFERENCES desks(id) ON DELETE CASCADE,
+    date_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    date_to TIMESTAMP WITH TIME ZONE NOT NULL,
+    released_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS reservation_no_shows_user_id_idx ON reservation_no_shows (user_id);
+
+INSERT INTO reservation_no_shows (reservation_id, user_id, desk_id, date_from, date_to, released_at)
+SELECT id, user_id, desk_id, date_from, date_to, COALESCE(updated_at, date_from)
+FROM reservations
+WHERE status = 'no_show';
+
+INSERT INTO reservation_no_shows (reservation_id, user_id, desk_id, date_from, date_to, released_at)
+SELECT a.id, a.user_id, a.desk_id, a.date_from, a.date_to, a.archived_at
+FROM reservations_archive a
+WHERE a.status = 'no_show'
+  AND EXISTS (SELECT 1 FROM users u WHERE u.id = a.user_id)
+  AND EXISTS (SELECT 1 FROM desks d WHERE d.id = a.desk_id);
+
+DROP TABLE IF EXISTS reservations_archive;
+
+-- Без новых статусов отмененные и завершившиеся брони снова удаляются
+DELETE FROM reservations WHERE status IN ('cancelled', 'completed', 'no_show');
+
+DROP INDEX IF EXISTS reservations_date_to_idx;
+DROP INDEX IF EXISTS reservations_current_date_to_idx;
+DROP INDEX IF EXISTS reservations_current_user_idx;
+DROP INDEX IF EXISTS reservations_current_desk_idx;
+
+ALTER TABLE reservations DROP COLUMN IF EXISTS cancellation_reason;
+ALTER TABLE reservations DROP COLUMN IF EXISTS cancelled_by;
+ALTER TABLE reservations DROP COLUMN IF EXISTS cancelled_at;
+
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS reservation_status_valid;
+ALTER TABLE reservations
+ADD CONSTRAINT reservation_status_valid CHECK (status IN ('active', 'pending', 'declined', 'expired'));
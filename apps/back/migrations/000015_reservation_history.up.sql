@@ -0,0 +1,59 @@
+-- 015_reservation_history.sql
+
+-- Брони больше не удаляются: отмененные, завершившиеся и неявки остаются в истории со своим статусом.
+-- Стол по-прежнему занимают только active и pending.
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS reservation_status_valid;
+ALTER TABLE reservations
+ADD CONSTRAINT reservation_status_valid CHECK (status IN ('active', 'pending', 'declined', 'expired', 'cancelled', 'completed', 'no_show'));
+
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS cancelled_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS cancellation_reason TEXT;
+
+-- Действующие брони составляют малую часть таблицы, поэтому запросы по ним идут по частичным индексам
+CREATE INDEX IF NOT EXISTS reservations_current_desk_idx ON reservations (desk_id, date_from) WHERE status IN ('active', 'pending');
+CREATE INDEX IF NOT EXISTS reservations_current_user_idx ON reservations (user_id, date_from) WHERE status IN ('active', 'pending');
+CREATE INDEX IF NOT EXISTS reservations_current_date_to_idx ON reservations (date_to) WHERE status IN ('active', 'pending');
+CREATE INDEX IF NOT EXISTS reservations_date_to_idx ON reservations (date_to);
+
+-- Брони старше срока хранения переносятся сюда. Внешних ключей нет: история переживает удаление столов и пользователей.
+-- attendees — участники брони переговорной на момент переноса.
+CREATE TABLE IF NOT EXISTS reservations_archive (
+    id UUID PRIMARY KEY,
+    desk_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    booked_by UUID,
+    date_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    date_to TIMESTAMP WITH TIME ZONE NOT NULL,
+    status TEXT NOT NULL,
+    checked_in_at TIMESTAMP WITH TIME ZONE,
+    series_id UUID,
+    guest_name TEXT,
+    guest_email TEXT,
+    guest_company TEXT,
+    resource_type TEXT NOT NULL,
+    decided_by UUID,
+    decided_at TIMESTAMP WITH TIME ZONE,
+    decision_comment TEXT,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    cancelled_by UUID,
+    cancellation_reason TEXT,
+    attendees UUID[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE,
+    archived_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS reservations_archive_user_idx ON reservations_archive (user_id, date_from);
+CREATE INDEX IF NOT EXISTS reservations_archive_desk_idx ON reservations_archive (desk_id, date_from);
+
+-- Неявки хранились отдельно от броней, теперь это статус брони
+INSERT INTO reservations_archive (id, desk_id, user_id, booked_by, date_from, date_to, status, resource_type, created_at, archived_at)
+SELECT n.reservation_id, n.desk_id, n.user_id, n.user_id, n.date_from, n.date_to, 'no_show',
+    COALESCE((SELECT d.type FROM desks d WHERE d.id = n.desk_id), 'desk'), n.released_at, n.released_at
+FROM reservation_no_shows n
+ON CONFLICT (id) DO NOTHING;
+
+DROP TABLE IF EXISTS reservation_no_shows;
+
+UPDATE reservations SET status = 'completed' WHERE status = 'active' AND date_to < NOW();
+UPDATE reservations SET status = 'expired' WHERE status = 'pending' AND date_to < NOW();
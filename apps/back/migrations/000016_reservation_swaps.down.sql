@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS reservation_swaps;
+
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS one_reservation_per_desk_per_period;
+ALTER TABLE reservations
+ADD CONSTRAINT one_reservation_per_desk_per_period
+EXCLUDE USING gist (
+    desk_id WITH =,
+    tstzrange(date_from, date_to, '[)') WITH &&
+) WHERE (status IN ('active', 'pending'));
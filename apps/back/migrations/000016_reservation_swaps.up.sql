@@ -0,0 +1,38 @@
+-- 016_reservation_swaps.sql
+
+-- Обмен столами: две брони меняются столами в одной транзакции. Пока первая бронь переходит на стол второй,
+-- он еще занят, поэтому проверка занятости стола откладывается до конца обмена.
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS one_reservation_per_desk_per_period;
+ALTER TABLE reservations
+ADD CONSTRAINT one_reservation_per_desk_per_period
+EXCLUDE USING gist (
+    desk_id WITH =,
+    tstzrange(date_from, date_to, '[)') WITH &&
+) WHERE (status IN ('active', 'pending'))
+DEFERRABLE INITIALLY IMMEDIATE;
+
+-- Предложение обмена. Столы запоминаются на момент предложения: после обмена брони указывают на другие столы.
+CREATE TABLE IF NOT EXISTS reservation_swaps (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    requester_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    requester_reservation_id UUID NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+    requester_desk_id UUID NOT NULL REFERENCES desks(id) ON DELETE CASCADE,
+    target_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_reservation_id UUID NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+    target_desk_id UUID NOT NULL REFERENCES desks(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    decided_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT reservation_swap_status_valid CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled', 'expired')),
+    CONSTRAINT reservation_swap_distinct CHECK (requester_reservation_id <> target_reservation_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS reservation_swaps_one_pending
+    ON reservation_swaps (requester_reservation_id, target_reservation_id)
+    WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS reservation_swaps_pending_idx ON reservation_swaps (expires_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS reservation_swaps_requester_idx ON reservation_swaps (requester_id);
+CREATE INDEX IF NOT EXISTS reservation_swaps_target_idx ON reservation_swaps (target_id);
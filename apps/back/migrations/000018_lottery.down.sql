@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS lottery_preferences;
+DROP TABLE IF EXISTS lottery_requests;
+DROP TABLE IF EXISTS lottery_days;
@@ -0,0 +1,49 @@
+-- 018_lottery.sql
+
+-- День офиса, столы на который распределяются лотереей. До розыгрыша обычная бронь на этот день закрыта.
+-- seed публикуется после розыгрыша: по нему любой может пересчитать порядок участников.
+CREATE TABLE IF NOT EXISTS lottery_days (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    office TEXT NOT NULL,
+    day DATE NOT NULL,
+    cutoff_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    seed TEXT NOT NULL DEFAULT encode(gen_random_bytes(16), 'hex'),
+    drawn_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT lottery_days_office_day_key UNIQUE (office, day)
+);
+
+CREATE INDEX IF NOT EXISTS lottery_days_pending_idx ON lottery_days(cutoff_at) WHERE drawn_at IS NULL;
+
+-- Заявка пользователя на лотерею. После розыгрыша в ней сохраняется, как она была разыграна:
+-- место в порядке розыгрыша, выигрыши и проигрыши за окно справедливости и хеш для равных по истории.
+CREATE TABLE IF NOT EXISTS lottery_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    lottery_id UUID NOT NULL REFERENCES lottery_days(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    reservation_id UUID REFERENCES reservations(id) ON DELETE SET NULL,
+    won_desk_id UUID REFERENCES desks(id) ON DELETE SET NULL,
+    won_rank INTEGER,
+    draw_position INTEGER,
+    recent_wins INTEGER,
+    recent_losses INTEGER,
+    draw_hash TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT lottery_request_status_valid CHECK (status IN ('pending', 'won', 'lost')),
+    CONSTRAINT lottery_requests_lottery_user_key UNIQUE (lottery_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS lottery_requests_user_idx ON lottery_requests(user_id, status);
+
+-- Предпочтения заявки в порядке убывания: конкретный стол или любой стол зоны.
+CREATE TABLE IF NOT EXISTS lottery_preferences (
+    request_id UUID NOT NULL REFERENCES lottery_requests(id) ON DELETE CASCADE,
+    rank INTEGER NOT NULL,
+    desk_id UUID REFERENCES desks(id) ON DELETE CASCADE,
+    zone_id UUID REFERENCES zones(id) ON DELETE CASCADE,
+    PRIMARY KEY (request_id, rank),
+    CONSTRAINT lottery_preference_target CHECK ((desk_id IS NULL) <> (zone_id IS NULL))
+);
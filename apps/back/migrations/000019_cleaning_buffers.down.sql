@@ -0,0 +1,18 @@
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS one_reservation_per_desk_per_period;
+ALTER TABLE reservations
+ADD CONSTRAINT one_reservation_per_desk_per_period
+EXCLUDE USING gist (
+    desk_id WITH =,
+    tstzrange(date_from, date_to, '[)') WITH &&
+) WHERE (status IN ('active', 'pending'))
+DEFERRABLE INITIALLY IMMEDIATE;
+
+DROP TRIGGER IF EXISTS reservations_occupied_until ON reservations;
+DROP FUNCTION IF EXISTS reservation_occupied_until();
+ALTER TABLE reservations DROP COLUMN IF EXISTS occupied_until;
+
+ALTER TABLE zones DROP CONSTRAINT IF EXISTS zone_cleaning_buffer_valid;
+ALTER TABLE zones DROP COLUMN IF EXISTS cleaning_buffer_minutes;
+
+ALTER TABLE desks DROP CONSTRAINT IF EXISTS desk_cleaning_buffer_valid;
+ALTER TABLE desks DROP COLUMN IF EXISTS cleaning_buffer_minutes;
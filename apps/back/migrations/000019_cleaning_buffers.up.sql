@@ -0,0 +1,43 @@
+-- 019_cleaning_buffers.sql
+
+-- Время на уборку после брони. Задается для стола или для всех столов зоны, у стола приоритет.
+ALTER TABLE desks ADD COLUMN IF NOT EXISTS cleaning_buffer_minutes INTEGER;
+ALTER TABLE desks DROP CONSTRAINT IF EXISTS desk_cleaning_buffer_valid;
+ALTER TABLE desks ADD CONSTRAINT desk_cleaning_buffer_valid CHECK (cleaning_buffer_minutes IS NULL OR cleaning_buffer_minutes >= 0);
+
+ALTER TABLE zones ADD COLUMN IF NOT EXISTS cleaning_buffer_minutes INTEGER;
+ALTER TABLE zones DROP CONSTRAINT IF EXISTS zone_cleaning_buffer_valid;
+ALTER TABLE zones ADD CONSTRAINT zone_cleaning_buffer_valid CHECK (cleaning_buffer_minutes IS NULL OR cleaning_buffer_minutes >= 0);
+
+-- Стол занят до конца брони и уборки после нее. Значение считается триггером по настройке стола
+-- на момент создания брони или смены ее стола и времени, поэтому изменение настройки действует на новые брони.
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS occupied_until TIMESTAMP WITH TIME ZONE;
+UPDATE reservations SET occupied_until = date_to WHERE occupied_until IS NULL;
+ALTER TABLE reservations ALTER COLUMN occupied_until SET NOT NULL;
+
+CREATE OR REPLACE FUNCTION reservation_occupied_until() RETURNS trigger AS $$
+BEGIN
+    NEW.occupied_until := NEW.date_to + make_interval(mins => COALESCE((
+        SELECT COALESCE(d.cleaning_buffer_minutes, z.cleaning_buffer_minutes, 0)
+        FROM desks d
+        LEFT JOIN zones z ON z.id = d.zone_id
+        WHERE d.id = NEW.desk_id
+    ), 0));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS reservations_occupied_until ON reservations;
+CREATE TRIGGER reservations_occupied_until
+BEFORE INSERT OR UPDATE OF desk_id, date_from, date_to ON reservations
+FOR EACH ROW EXECUTE FUNCTION reservation_occupied_until();
+
+-- Брони одного стола не пересекаются вместе со временем на уборку.
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS one_reservation_per_desk_per_period;
+ALTER TABLE reservations
+ADD CONSTRAINT one_reservation_per_desk_per_period
+EXCLUDE USING gist (
+    desk_id WITH =,
+    tstzrange(date_from, occupied_until, '[)') WITH &&
+) WHERE (status IN ('active', 'pending'))
+DEFERRABLE INITIALLY IMMEDIATE;
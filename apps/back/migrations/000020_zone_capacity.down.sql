@@ -0,0 +1,2 @@
+ALTER TABLE zones DROP CONSTRAINT IF EXISTS zone_capacity_percent_valid;
+ALTER TABLE zones DROP COLUMN IF EXISTS capacity_percent;
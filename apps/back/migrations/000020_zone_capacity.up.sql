@@ -0,0 +1,6 @@
+-- 020_zone_capacity.sql
+
+-- Доля столов зоны, которые можно занять в один день. NULL — без ограничения.
+ALTER TABLE zones ADD COLUMN IF NOT EXISTS capacity_percent INTEGER;
+ALTER TABLE zones DROP CONSTRAINT IF EXISTS zone_capacity_percent_valid;
+ALTER TABLE zones ADD CONSTRAINT zone_capacity_percent_valid CHECK (capacity_percent IS NULL OR capacity_percent BETWEEN 0 AND 100);
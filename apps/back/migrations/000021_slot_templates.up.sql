@@ -0,0 +1,15 @@
+-- 021_slot_templates.sql
+
+-- Именованные интервалы брони офиса, например утро 09:00–13:00 и день 13:00–18:00.
+-- Время по местному времени офиса.
+CREATE TABLE IF NOT EXISTS slot_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    office TEXT NOT NULL,
+    name TEXT NOT NULL,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT slot_template_period_valid CHECK (end_time > start_time),
+    CONSTRAINT slot_templates_office_name_key UNIQUE (office, name)
+);
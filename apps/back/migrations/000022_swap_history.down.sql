@@ -0,0 +1,14 @@
+DELETE FROM reservation_swaps WHERE requester_reservation_id IS NULL OR target_reservation_id IS NULL;
+
+ALTER TABLE reservation_swaps DROP CONSTRAINT IF EXISTS reservation_swaps_requester_reservation_id_fkey;
+ALTER TABLE reservation_swaps
+ADD CONSTRAINT reservation_swaps_requester_reservation_id_fkey
+FOREIGN KEY (requester_reservation_id) REFERENCES reservations(id) ON DELETE CASCADE;
+
+ALTER TABLE reservation_swaps DROP CONSTRAINT IF EXISTS reservation_swaps_target_reservation_id_fkey;
+ALTER TABLE reservation_swaps
+ADD CONSTRAINT reservation_swaps_target_reservation_id_fkey
+FOREIGN KEY (target_reservation_id) REFERENCES reservations(id) ON DELETE CASCADE;
+
+ALTER TABLE reservation_swaps ALTER COLUMN requester_reservation_id SET NOT NULL;
+ALTER TABLE reservation_swaps ALTER COLUMN target_reservation_id SET NOT NULL;
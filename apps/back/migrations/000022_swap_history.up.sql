@@ -0,0 +1,15 @@
+-- 022_swap_history.sql
+
+-- Предложение обмена переживает перенос своих броней в архив: ссылка на бронь обнуляется, а стороны и столы остаются.
+ALTER TABLE reservation_swaps ALTER COLUMN requester_reservation_id DROP NOT NULL;
+ALTER TABLE reservation_swaps ALTER COLUMN target_reservation_id DROP NOT NULL;
+
+ALTER TABLE reservation_swaps DROP CONSTRAINT IF EXISTS reservation_swaps_requester_reservation_id_fkey;
+ALTER TABLE reservation_swaps
+ADD CONSTRAINT reservation_swaps_requester_reservation_id_fkey
+FOREIGN KEY (requester_reservation_id) REFERENCES reservations(id) ON DELETE SET NULL;
+
+ALTER TABLE reservation_swaps DROP CONSTRAINT IF EXISTS reservation_swaps_target_reservation_id_fkey;
+ALTER TABLE reservation_swaps
+ADD CONSTRAINT reservation_swaps_target_reservation_id_fkey
+FOREIGN KEY (target_reservation_id) REFERENCES reservations(id) ON DELETE SET NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS lottery_days_pending_idx;
+CREATE INDEX IF NOT EXISTS lottery_days_pending_idx ON lottery_days(cutoff_at) WHERE drawn_at IS NULL;
+
+ALTER TABLE lottery_days DROP COLUMN IF EXISTS failed_at;
@@ -0,0 +1,7 @@
+-- 023_lottery_failures.sql
+
+-- Лотерея, которую не удалось разыграть. Она не задерживает розыгрыш следующих, а бронь на ее день снова открыта.
+ALTER TABLE lottery_days ADD COLUMN IF NOT EXISTS failed_at TIMESTAMP WITH TIME ZONE;
+
+DROP INDEX IF EXISTS lottery_days_pending_idx;
+CREATE INDEX IF NOT EXISTS lottery_days_pending_idx ON lottery_days(cutoff_at) WHERE drawn_at IS NULL AND failed_at IS NULL;